@@ -0,0 +1,380 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/alecthomas/kong"
+	"github.com/spandigital/token-visualizer/internal/output"
+	"gopkg.in/yaml.v3"
+)
+
+// projectConfigName is the config file looked up in the working directory and its parents
+const projectConfigName = ".token-visualizer.yaml"
+
+// modelAliases maps short model names from the config files to tokenizer specs
+var modelAliases = map[string]string{}
+
+// projectSpecs are the model specs named in a project config file. Their
+// environment variable references are not expanded, since the file may come
+// with a repository the user didn't write.
+var projectSpecs = map[string]bool{}
+
+// pathFlags are the flags whose values are file paths
+var pathFlags = map[string]bool{"theme": true, "file": true}
+
+// modelFlags are the flags whose values are model specs
+var modelFlags = map[string]bool{"model": true, "models": true}
+
+// configFile is the layout of a config file. Keys other than aliases and
+// profiles are flag defaults, either for every command that has the flag or,
+// under a command name, for that command only.
+type configFile struct {
+	Aliases  map[string]string         `yaml:"aliases"`
+	Profiles map[string]map[string]any `yaml:"profiles"`
+	Flags    map[string]any            `yaml:",inline"`
+}
+
+// Config holds the flag defaults, profiles and model aliases of the config
+// files. It resolves flags that are not set on the command line, with a
+// command's own section taking precedence over top-level defaults, and the
+// active profile taking precedence over both.
+type Config struct {
+	flags        map[string]any
+	profiles     map[string]map[string]any
+	aliases      map[string]string
+	projectSpecs map[string]bool
+}
+
+var _ kong.Resolver = (*Config)(nil)
+
+// configPaths returns the user config under $XDG_CONFIG_HOME (or ~/.config),
+// and the nearest .token-visualizer.yaml in the working directory or a parent.
+// The search stops at the root of a git repository or at the home directory;
+// outside of both, only the working directory is searched.
+func configPaths() (user, project string) {
+	home, _ := os.UserHomeDir()
+
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" && home != "" {
+		dir = filepath.Join(home, ".config")
+	}
+	if dir != "" {
+		user = filepath.Join(dir, "token-visualizer", "config.yaml")
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return user, ""
+	}
+
+	var dirs []string
+	for dir := cwd; ; {
+		dirs = append(dirs, dir)
+		if dir == home || exists(filepath.Join(dir, ".git")) {
+			break
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			// No repository or home directory above
+			dirs = dirs[:1]
+			break
+		}
+		dir = parent
+	}
+
+	for _, dir := range dirs {
+		if path := filepath.Join(dir, projectConfigName); exists(path) {
+			return user, path
+		}
+	}
+	return user, ""
+}
+
+// exists reports whether a file or directory exists at path
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// loadConfig reads and merges the user and project config files, skipping
+// those that don't exist; the project file overrides the user file key by key
+func loadConfig(user, project string) (*Config, error) {
+	config := &Config{
+		flags:        map[string]any{},
+		profiles:     map[string]map[string]any{},
+		aliases:      map[string]string{},
+		projectSpecs: map[string]bool{},
+	}
+
+	for _, path := range []string{user, project} {
+		if path == "" {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config: %w", err)
+		}
+
+		var file configFile
+		if err := yaml.Unmarshal(data, &file); err != nil {
+			return nil, fmt.Errorf("invalid config %s: %w", path, err)
+		}
+
+		// Relative paths are relative to the config file, not the working directory
+		dir := filepath.Dir(path)
+		resolvePaths(file.Flags, dir)
+		for _, profile := range file.Profiles {
+			resolvePaths(profile, dir)
+		}
+
+		for alias, spec := range file.Aliases {
+			switch {
+			case spec == "":
+				return nil, fmt.Errorf("invalid config %s: alias %s has no model", path, alias)
+			case slices.Contains(builtinModels, alias) || strings.Contains(alias, ":"):
+				return nil, fmt.Errorf("invalid config %s: alias %s would replace a built-in model", path, alias)
+			}
+			file.Aliases[alias] = resolveSpecPath(spec, dir)
+			config.aliases[alias] = file.Aliases[alias]
+		}
+
+		mergeFlags(config.flags, file.Flags)
+		for name, profile := range file.Profiles {
+			if config.profiles[name] == nil {
+				config.profiles[name] = map[string]any{}
+			}
+			mergeFlags(config.profiles[name], profile)
+		}
+
+		if path == project {
+			collectSpecs(file.Flags, config.projectSpecs)
+			collectSpecs(file.Aliases, config.projectSpecs)
+			for _, profile := range file.Profiles {
+				collectSpecs(profile, config.projectSpecs)
+			}
+		}
+	}
+
+	return config, nil
+}
+
+// resolvePaths makes the file paths in flag values, and in llama: and
+// llama3: model specs, absolute by joining relative ones to dir
+func resolvePaths(flags map[string]any, dir string) {
+	for key, value := range flags {
+		switch v := value.(type) {
+		case map[string]any:
+			resolvePaths(v, dir)
+		case []any:
+			if modelFlags[key] {
+				for i, item := range v {
+					if spec, ok := item.(string); ok {
+						v[i] = resolveSpecPath(spec, dir)
+					}
+				}
+			}
+		case string:
+			switch {
+			case key == "theme" && slices.Contains(output.Themes(), v):
+			case pathFlags[key]:
+				flags[key] = resolvePath(v, dir)
+			case modelFlags[key]:
+				specs := strings.Split(v, ",")
+				for i, spec := range specs {
+					specs[i] = resolveSpecPath(spec, dir)
+				}
+				flags[key] = strings.Join(specs, ",")
+			}
+		}
+	}
+}
+
+// resolveSpecPath resolves the path of a llama: or llama3: model spec against dir
+func resolveSpecPath(spec, dir string) string {
+	prefix, path, ok := strings.Cut(spec, ":")
+	if !ok || (prefix != "llama" && prefix != "llama3") || path == "" {
+		return spec
+	}
+	return prefix + ":" + resolvePath(path, dir)
+}
+
+// resolvePath expands a leading ~/ to the home directory, and joins a relative path to dir
+func resolvePath(path, dir string) string {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+// collectSpecs adds every string in a config value to specs, along with the
+// parts of comma-separated lists, which is how kong splits model lists
+func collectSpecs(value any, specs map[string]bool) {
+	switch v := value.(type) {
+	case string:
+		specs[v] = true
+		for _, part := range strings.Split(v, ",") {
+			specs[part] = true
+		}
+	case []any:
+		for _, item := range v {
+			collectSpecs(item, specs)
+		}
+	case map[string]any:
+		for _, item := range v {
+			collectSpecs(item, specs)
+		}
+	case map[string]string:
+		for _, item := range v {
+			collectSpecs(item, specs)
+		}
+	}
+}
+
+// mergeFlags copies flag defaults from src over dst, merging command
+// sections flag by flag
+func mergeFlags(dst, src map[string]any) {
+	for key, value := range src {
+		section, isSection := value.(map[string]any)
+		existing, hasSection := dst[key].(map[string]any)
+		if isSection && hasSection {
+			maps.Copy(existing, section)
+			continue
+		}
+		dst[key] = value
+	}
+}
+
+// Resolve returns the configured value of a flag, or nil if it has none
+func (c *Config) Resolve(ctx *kong.Context, parent *kong.Path, flag *kong.Flag) (any, error) {
+	command := ""
+	if parent.Command != nil {
+		command = parent.Command.Name
+	}
+
+	value := lookupFlag(c.flags, command, flag.Name)
+	if profile, ok := c.profiles[c.activeProfile(ctx)]; ok {
+		if override := lookupFlag(profile, command, flag.Name); override != nil {
+			value = override
+		}
+	}
+	return value, nil
+}
+
+// lookupFlag returns the value of a flag in a command's section, or at the top level
+func lookupFlag(flags map[string]any, command, name string) any {
+	if section, ok := flags[command].(map[string]any); ok {
+		if value, ok := section[name]; ok {
+			return value
+		}
+	}
+	return flags[name]
+}
+
+// activeProfile returns the profile chosen with --profile, or else the
+// default profile of the config files
+func (c *Config) activeProfile(ctx *kong.Context) string {
+	for _, flag := range ctx.Flags() {
+		if flag.Name != "profile" {
+			continue
+		}
+		if profile, _ := ctx.FlagValue(flag).(string); profile != "" {
+			return profile
+		}
+	}
+	profile, _ := c.flags["profile"].(string)
+	return profile
+}
+
+// checkProfile returns an error if a profile is chosen that no config file defines
+func (c *Config) checkProfile(profile string) error {
+	if profile == "" {
+		return nil
+	}
+	if _, ok := c.profiles[profile]; !ok {
+		names := slices.Sorted(maps.Keys(c.profiles))
+		if len(names) == 0 {
+			return fmt.Errorf("unknown profile %s (no profiles are configured)", profile)
+		}
+		return fmt.Errorf("unknown profile %s (configured: %s)", profile, strings.Join(names, ", "))
+	}
+	return nil
+}
+
+// Validate checks that every key in the config is a flag or command of the application
+func (c *Config) Validate(app *kong.Application) error {
+	global := flagNames(app.Node)
+	commands := map[string]map[string]bool{}
+	all := maps.Clone(global)
+	for _, command := range app.Children {
+		commands[command.Name] = flagNames(command)
+		maps.Copy(all, commands[command.Name])
+	}
+
+	validate := func(flags map[string]any, profile string) error {
+		where := ""
+		if profile != "" {
+			where = " in profile " + profile
+			if _, ok := flags["profile"]; ok {
+				return fmt.Errorf("config: profile %s can't choose another profile", profile)
+			}
+		}
+
+		for _, key := range slices.Sorted(maps.Keys(flags)) {
+			section, isSection := flags[key].(map[string]any)
+			known, isCommand := commands[key]
+			switch {
+			case isCommand && isSection:
+				for _, name := range slices.Sorted(maps.Keys(section)) {
+					if !known[name] && !global[name] {
+						return fmt.Errorf("config: %s has no flag %s%s", key, name, where)
+					}
+				}
+			case isCommand:
+				return fmt.Errorf("config: %s must be a section of %s flags%s", key, key, where)
+			case !all[key]:
+				return fmt.Errorf("config: unknown flag or command %s%s", key, where)
+			}
+		}
+		return nil
+	}
+
+	if err := validate(c.flags, ""); err != nil {
+		return err
+	}
+	for _, name := range slices.Sorted(maps.Keys(c.profiles)) {
+		if err := validate(c.profiles[name], name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// flagNames returns the names of the flags of a command, or the global flags of the application
+func flagNames(node *kong.Node) map[string]bool {
+	names := map[string]bool{}
+	for _, flag := range node.Flags {
+		names[flag.Name] = true
+	}
+	return names
+}
+
+// resolveAlias returns the tokenizer spec a model alias stands for, or the model itself
+func resolveAlias(model string) string {
+	if spec, ok := modelAliases[model]; ok {
+		return spec
+	}
+	return model
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/kong"
+)
+
+// testCLI is a small command line with the shapes of flags the config resolves
+type testCLI struct {
+	Count struct {
+		Models   []string `default:"gpt4"`
+		Encoding string   `default:"cl100k_base"`
+		Format   string   `default:"text"`
+	} `cmd:""`
+	Visualize struct {
+		Model  string `default:"gpt4"`
+		Format string `default:"text"`
+	} `cmd:""`
+
+	Theme   string
+	Profile string
+}
+
+// writeConfig writes a config file to a new directory and returns its path
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), projectConfigName)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// parse parses args with config as the resolver
+func parse(t *testing.T, config *Config, args ...string) (*testCLI, error) {
+	t.Helper()
+	var cli testCLI
+	parser, err := kong.New(&cli, kong.Resolvers(config), kong.Exit(func(int) {}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = parser.Parse(args)
+	return &cli, err
+}
+
+func TestConfigPrecedence(t *testing.T) {
+	config, err := loadConfig("", writeConfig(t, `
+format: plain
+encoding: o200k_base
+count:
+  format: json
+profiles:
+  prod:
+    format: csv
+    count:
+      models: [gpt5, gpt5-mini]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		args     []string
+		format   string
+		models   []string
+		encoding string
+	}{
+		{"command section over top level", []string{"count"}, "json", []string{"gpt4"}, "o200k_base"},
+		{"profile over command section", []string{"count", "--profile", "prod"}, "csv", []string{"gpt5", "gpt5-mini"}, "o200k_base"},
+		{"command line over profile", []string{"count", "--profile", "prod", "--format", "text", "--models", "gpt3.5"}, "text", []string{"gpt3.5"}, "o200k_base"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cli, err := parse(t, config, tt.args...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cli.Count.Format != tt.format {
+				t.Errorf("format = %q, want %q", cli.Count.Format, tt.format)
+			}
+			if strings.Join(cli.Count.Models, ",") != strings.Join(tt.models, ",") {
+				t.Errorf("models = %v, want %v", cli.Count.Models, tt.models)
+			}
+			if cli.Count.Encoding != tt.encoding {
+				t.Errorf("encoding = %q, want %q", cli.Count.Encoding, tt.encoding)
+			}
+		})
+	}
+
+	// The top-level value applies to commands without their own section
+	cli, err := parse(t, config, "visualize")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cli.Visualize.Format != "plain" {
+		t.Errorf("visualize format = %q, want plain", cli.Visualize.Format)
+	}
+}
+
+func TestConfigMerge(t *testing.T) {
+	user := writeConfig(t, `
+format: plain
+encoding: o200k_base
+count:
+  format: json
+  encoding: p50k_base
+aliases:
+  fast: gpt5-nano
+`)
+	project := writeConfig(t, `
+format: csv
+count:
+  format: markdown
+aliases:
+  small: gpt5-mini
+`)
+	config, err := loadConfig(user, project)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cli, err := parse(t, config, "count")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cli.Count.Format != "markdown" {
+		t.Errorf("format = %q, want the project's markdown", cli.Count.Format)
+	}
+	if cli.Count.Encoding != "p50k_base" {
+		t.Errorf("encoding = %q, want the user's p50k_base", cli.Count.Encoding)
+	}
+	if config.aliases["fast"] != "gpt5-nano" || config.aliases["small"] != "gpt5-mini" {
+		t.Errorf("aliases = %v, want both files' aliases", config.aliases)
+	}
+
+	// Only specs from the project file are marked
+	if !config.projectSpecs["gpt5-mini"] || config.projectSpecs["gpt5-nano"] {
+		t.Errorf("projectSpecs = %v, want gpt5-mini only", config.projectSpecs)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		err     string
+	}{
+		{"valid", "format: plain\ncount:\n  models: [gpt5]\n", ""},
+		{"unknown flag", "colour: never\n", "unknown flag or command colour"},
+		{"unknown command flag", "visualize:\n  models: [gpt5]\n", "visualize has no flag models"},
+		{"command without section", "count: true\n", "count must be a section of count flags"},
+		{"unknown flag in profile", "profiles:\n  prod:\n    colour: never\n", "unknown flag or command colour in profile prod"},
+		{"profile choosing a profile", "profiles:\n  prod:\n    profile: dev\n", "profile prod can't choose another profile"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := loadConfig("", writeConfig(t, tt.content))
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = parse(t, config, "count")
+			if tt.err == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("error = %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestConfigCheckProfile(t *testing.T) {
+	config, err := loadConfig("", writeConfig(t, "profiles:\n  prod:\n    format: csv\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := config.checkProfile("prod"); err != nil {
+		t.Errorf("checkProfile(prod) = %v", err)
+	}
+	if err := config.checkProfile("dev"); err == nil || !strings.Contains(err.Error(), "configured: prod") {
+		t.Errorf("checkProfile(dev) = %v, want an unknown profile error", err)
+	}
+}
+
+func TestConfigAliases(t *testing.T) {
+	tests := []struct {
+		name  string
+		alias string
+		err   bool
+	}{
+		{"new name", "l3", false},
+		{"built-in model", "gpt4", true},
+		{"built-in GPT-5 model", "gpt5-mini", true},
+		{"claude prefix", "claude:sonnet", true},
+		{"remote prefix", "remote:local", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loadConfig("", writeConfig(t, "aliases:\n  \""+tt.alias+"\": remote:http://localhost:8080\n"))
+			if (err != nil) != tt.err {
+				t.Errorf("error = %v, want error: %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestConfigPaths(t *testing.T) {
+	path := writeConfig(t, `
+theme: ./theme.yaml
+visualize:
+  model: llama3:./models/tokenizer.json
+count:
+  models: [gpt4, "llama:models/llama.json"]
+profiles:
+  dark:
+    theme: dark
+aliases:
+  l3: llama3:models/tokenizer.json
+  local: remote:http://localhost:8080
+`)
+	dir := filepath.Dir(path)
+	config, err := loadConfig("", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tokenizer := filepath.Join(dir, "models", "tokenizer.json")
+	tests := []struct {
+		name string
+		got  any
+		want string
+	}{
+		{"theme file", config.flags["theme"], filepath.Join(dir, "theme.yaml")},
+		{"built-in theme", config.profiles["dark"]["theme"], "dark"},
+		{"llama3 model", config.flags["visualize"].(map[string]any)["model"], "llama3:" + tokenizer},
+		{"llama model in list", config.flags["count"].(map[string]any)["models"].([]any)[1], "llama:" + filepath.Join(dir, "models", "llama.json")},
+		{"built-in model in list", config.flags["count"].(map[string]any)["models"].([]any)[0], "gpt4"},
+		{"alias", config.aliases["l3"], "llama3:" + tokenizer},
+		{"remote alias", config.aliases["local"], "remote:http://localhost:8080"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("got %v, want %v", tt.got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spandigital/token-visualizer/internal/diff"
+	"github.com/spandigital/token-visualizer/internal/output"
+)
+
+type DiffCmd struct {
+	Old               string   `arg:"" help:"Old version of the text" type:"existingfile"`
+	New               string   `arg:"" help:"New version of the text" type:"existingfile"`
+	Models            []string `help:"Models to diff: gpt4, gpt3.5, gpt5, gpt5-mini, gpt5-nano, claude:model-name, llama:path, llama3:path, remote:url" default:"gpt4"`
+	Format            string   `help:"Output format: ${formats}" default:"terminal" enum:"${formats}"`
+	ShowIDs           bool     `help:"Show IDs of changed tokens" short:"i" name:"show-ids"`
+	VisibleWhitespace bool     `help:"Show spaces (·), tabs (→), line breaks (↵), no-break spaces (⍽) and invisible characters (<U+200B>)" short:"w" name:"visible-whitespace"`
+	Encoding          string   `help:"Tiktoken encoding (for GPT models)" default:"cl100k_base"`
+	NoCache           bool     `help:"Disable caching for Claude API and remote tokenizers" short:"n"`
+}
+
+func (d *DiffCmd) Run(theme *output.Theme) error {
+	renderer, err := output.New(d.Format, output.Options{ShowIDs: d.ShowIDs, VisibleWhitespace: d.VisibleWhitespace, Theme: theme})
+	if err != nil {
+		return err
+	}
+
+	diffRenderer, err := output.As[output.DiffRenderer](renderer, d.Format, "diffs")
+	if err != nil {
+		return err
+	}
+
+	oldText, err := os.ReadFile(d.Old)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", d.Old, err)
+	}
+
+	newText, err := os.ReadFile(d.New)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", d.New, err)
+	}
+
+	ctx := context.Background()
+	results := make([]*diff.Result, 0, len(d.Models))
+
+	// Process each model
+	for _, model := range d.Models {
+		tokenizer, err := createTokenizer(model, d.Encoding, !d.NoCache)
+		if err != nil {
+			return err
+		}
+
+		oldResult, err := tokenizer.Encode(ctx, string(oldText))
+		if err != nil {
+			return fmt.Errorf("tokenization failed for %s: %w", model, err)
+		}
+
+		newResult, err := tokenizer.Encode(ctx, string(newText))
+		if err != nil {
+			return fmt.Errorf("tokenization failed for %s: %w", model, err)
+		}
+
+		results = append(results, diff.Compute(newResult.Model, oldResult, newResult))
+	}
+
+	// Render output
+	return diffRenderer.Diff(os.Stdout, results)
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// readInputIDs reads token IDs from stdin as a JSON array, a list separated by
+// whitespace or commas, or little-endian uint32 values. With format "auto" the
+// encoding is guessed from the data.
+func readInputIDs(format string) ([]int, error) {
+	data, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return nil, err
+	}
+	return parseInputIDs(data, format)
+}
+
+// parseInputIDs decodes token IDs in the given format
+func parseInputIDs(data []byte, format string) ([]int, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("no input provided (stdin is empty)")
+	}
+
+	if format == "auto" {
+		format = detectIDsFormat(data)
+	}
+
+	// Whitespace is only empty input in the text formats; in binary it's IDs
+	if format != "uint32" && len(bytes.TrimSpace(data)) == 0 {
+		return nil, fmt.Errorf("no input provided (stdin is empty)")
+	}
+
+	switch format {
+	case "json":
+		var ids []int
+		if err := json.Unmarshal(data, &ids); err != nil {
+			return nil, fmt.Errorf("invalid JSON token IDs: %w", err)
+		}
+		return ids, nil
+	case "text":
+		fields := strings.FieldsFunc(string(data), func(r rune) bool {
+			return unicode.IsSpace(r) || r == ','
+		})
+		ids := make([]int, len(fields))
+		for i, field := range fields {
+			id, err := strconv.Atoi(field)
+			if err != nil {
+				return nil, fmt.Errorf("invalid token ID %q", field)
+			}
+			ids[i] = id
+		}
+		return ids, nil
+	case "uint32":
+		if len(data)%4 != 0 {
+			return nil, fmt.Errorf("binary input is %d bytes, not a multiple of 4", len(data))
+		}
+		ids := make([]int, len(data)/4)
+		for i := range ids {
+			ids[i] = int(binary.LittleEndian.Uint32(data[i*4:]))
+		}
+		return ids, nil
+	}
+
+	return nil, fmt.Errorf("unknown token ID format: %s", format)
+}
+
+// detectIDsFormat guesses the encoding of token IDs. Input that is valid UTF-8
+// without NUL bytes is JSON if it starts with a bracket and text otherwise;
+// anything else is binary, where IDs below 2^24 always have a NUL byte.
+func detectIDsFormat(data []byte) string {
+	if !utf8.Valid(data) || bytes.IndexByte(data, 0) >= 0 {
+		return "uint32"
+	}
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		return "json"
+	}
+	return "text"
+}
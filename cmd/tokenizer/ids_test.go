@@ -0,0 +1,70 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseInputIDs(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   string
+		format string
+		want   []int
+		err    string
+	}{
+		{name: "JSON", data: "[1, 2, 3]\n", format: "auto", want: []int{1, 2, 3}},
+		{name: "text with commas", data: "1,2, 3\n", format: "auto", want: []int{1, 2, 3}},
+		{name: "text with whitespace", data: "1\t2\r\n3", format: "auto", want: []int{1, 2, 3}},
+		{name: "binary", data: "\x01\x00\x00\x00\x02\x01\x00\x00", format: "auto", want: []int{1, 258}},
+		{name: "binary starting with a bracket", data: "\x5b\x00\x00\x00\x07\x00\x00\x00", format: "auto", want: []int{91, 7}},
+		{name: "binary whitespace", data: "\x20\x00\x00\x00\x0a\x00\x00\x00", format: "auto", want: []int{32, 10}},
+		{name: "binary without NUL bytes", data: "\x20\x20\x20\x20", format: "uint32", want: []int{0x20202020}},
+		{name: "invalid UTF-8 binary", data: "\xff\xfe\xfd\xfc", format: "auto", want: []int{0xfcfdfeff}},
+		{name: "truncated binary", data: "\x01\x00\x00", format: "auto", err: "not a multiple of 4"},
+		{name: "empty", data: "", format: "auto", err: "stdin is empty"},
+		{name: "whitespace", data: " \n\t", format: "auto", err: "stdin is empty"},
+		{name: "words", data: "one two", format: "auto", err: `invalid token ID "one"`},
+		{name: "invalid JSON", data: "[1, 2", format: "auto", err: "invalid JSON token IDs"},
+		{name: "JSON format forced", data: "[1]", format: "json", want: []int{1}},
+		{name: "unknown format", data: "1", format: "csv", err: "unknown token ID format"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ids, err := parseInputIDs([]byte(tt.data), tt.format)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("error = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(ids, tt.want) {
+				t.Errorf("ids = %v, want %v", ids, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectIDsFormat(t *testing.T) {
+	tests := []struct {
+		data string
+		want string
+	}{
+		{"[1,2]", "json"},
+		{"  \n[1]", "json"},
+		{"1 2 3", "text"},
+		{"hello", "text"},
+		{"[\x00\x00\x00", "uint32"},
+		{"\n\x00\x00\x00", "uint32"},
+		{"\xc3\x28\x01\x02", "uint32"},
+	}
+	for _, tt := range tests {
+		if got := detectIDsFormat([]byte(tt.data)); got != tt.want {
+			t.Errorf("detectIDsFormat(%q) = %s, want %s", tt.data, got, tt.want)
+		}
+	}
+}
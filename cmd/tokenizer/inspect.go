@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spandigital/token-visualizer/internal/output"
+	"github.com/spandigital/token-visualizer/internal/tokenizers"
+)
+
+type InspectCmd struct {
+	Text     string `arg:"" help:"Word or short text to inspect"`
+	Model    string `help:"Model to use: gpt4, gpt3.5, gpt5, gpt5-mini, gpt5-nano, llama3:path" default:"gpt4"`
+	Format   string `help:"Output format: ${formats}" default:"terminal" enum:"${formats}"`
+	Encoding string `help:"Tiktoken encoding (for GPT models)" default:"cl100k_base"`
+}
+
+func (i *InspectCmd) Run(theme *output.Theme) error {
+	renderer, err := output.New(i.Format, output.Options{Theme: theme})
+	if err != nil {
+		return err
+	}
+
+	traceRenderer, err := output.As[output.MergeTraceRenderer](renderer, i.Format, "merge traces")
+	if err != nil {
+		return err
+	}
+
+	tokenizer, err := createTokenizer(i.Model, i.Encoding, false)
+	if err != nil {
+		return err
+	}
+
+	tracer, ok := tokenizer.(tokenizers.MergeTracer)
+	if !ok {
+		return fmt.Errorf("%s does not support merge traces (only tiktoken and HuggingFace BPE models do)", tokenizer.Name())
+	}
+
+	traces, err := tracer.TraceMerges(context.Background(), i.Text)
+	if err != nil {
+		return fmt.Errorf("failed to trace merges: %w", err)
+	}
+
+	// Render output
+	return traceRenderer.MergeTrace(os.Stdout, tokenizer.Name(), traces)
+}
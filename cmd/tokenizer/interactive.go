@@ -0,0 +1,8 @@
+package main
+
+type InteractiveCmd struct {
+	Models   []string `help:"Models to switch between: gpt4, gpt3.5, gpt5, gpt5-mini, gpt5-nano, claude:model-name, llama:path, llama3:path, remote:url" default:"gpt4"`
+	File     string   `help:"File to load into the editor" short:"f" type:"existingfile"`
+	Encoding string   `help:"Tiktoken encoding (for GPT models)" default:"cl100k_base"`
+	NoCache  bool     `help:"Disable caching for Claude API and remote tokenizers" short:"n"`
+}
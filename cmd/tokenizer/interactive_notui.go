@@ -0,0 +1,13 @@
+//go:build notui
+
+package main
+
+import (
+	"errors"
+
+	"github.com/spandigital/token-visualizer/internal/output"
+)
+
+func (c *InteractiveCmd) Run(theme *output.Theme) error {
+	return errors.New("the interactive command is not available in this build (built with the notui tag)")
+}
@@ -0,0 +1,35 @@
+//go:build !notui
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spandigital/token-visualizer/internal/output"
+	"github.com/spandigital/token-visualizer/internal/tui"
+)
+
+// Builds with the notui tag leave out the terminal UI and its dependencies
+
+func (c *InteractiveCmd) Run(theme *output.Theme) error {
+	models := make([]tui.Model, 0, len(c.Models))
+	for _, model := range c.Models {
+		tokenizer, err := createTokenizer(model, c.Encoding, !c.NoCache)
+		if err != nil {
+			return err
+		}
+		models = append(models, tui.Model{Name: model, Tokenizer: tokenizer})
+	}
+
+	var text string
+	if c.File != "" {
+		data, err := os.ReadFile(c.File)
+		if err != nil {
+			return fmt.Errorf("failed to read file: %w", err)
+		}
+		text = string(data)
+	}
+
+	return tui.Run(models, text, theme)
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spandigital/token-visualizer/internal/lsp"
+)
+
+type LspCmd struct {
+	Model     string `help:"Model to use: gpt4, gpt3.5, gpt5, gpt5-mini, gpt5-nano, claude:model-name, llama:path, llama3:path, remote:url" default:"gpt4"`
+	Budget    int    `help:"Token budget per document; a warning is shown when it is exceeded (0 disables)" default:"0"`
+	HintScope string `help:"Inlay hint granularity: paragraph, section" default:"paragraph" enum:"paragraph,section"`
+	Encoding  string `help:"Tiktoken encoding (for GPT models)" default:"cl100k_base"`
+	NoCache   bool   `help:"Disable caching for Claude API and remote tokenizers" short:"n"`
+}
+
+func (l *LspCmd) Run() error {
+	// Load the tokenizer once and keep it warm for the whole editor session
+	tokenizer, err := createTokenizer(l.Model, l.Encoding, !l.NoCache)
+	if err != nil {
+		return err
+	}
+
+	srv := lsp.New(lsp.Config{
+		Model:     l.Model,
+		Tokenizer: tokenizer,
+		Budget:    l.Budget,
+		HintScope: l.HintScope,
+	})
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	return srv.Serve(ctx, os.Stdin, os.Stdout)
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
@@ -8,79 +9,177 @@ import (
 	"strings"
 
 	"github.com/alecthomas/kong"
+	"github.com/spandigital/token-visualizer/internal/align"
+	"github.com/spandigital/token-visualizer/internal/metrics"
+	"github.com/spandigital/token-visualizer/internal/outline"
 	"github.com/spandigital/token-visualizer/internal/output"
 	"github.com/spandigital/token-visualizer/internal/tokenizers"
 )
 
 var CLI struct {
-	Visualize VisualizeCmd `cmd:"" help:"Visualize tokens with colorized output (default command)" default:"withargs"`
-	Count     CountCmd     `cmd:"" help:"Show only token counts"`
-	Compare   CompareCmd   `cmd:"" help:"Compare tokenization across multiple models"`
+	Visualize   VisualizeCmd   `cmd:"" help:"Visualize tokens with colorized output (default command)" default:"withargs"`
+	Count       CountCmd       `cmd:"" help:"Show only token counts"`
+	Compare     CompareCmd     `cmd:"" help:"Compare tokenization across multiple models"`
+	Diff        DiffCmd        `cmd:"" help:"Show the token-level diff between two versions of a text"`
+	Vocab       VocabCmd       `cmd:"" help:"Browse and search a tokenizer vocabulary"`
+	Inspect     InspectCmd     `cmd:"" help:"Show the BPE merges that turn a word into tokens"`
+	Serve       ServeCmd       `cmd:"" help:"Serve tokenizers over an HTTP JSON API"`
+	Interactive InteractiveCmd `cmd:"" help:"Edit text in a full-screen terminal UI with live tokenization"`
+	Lsp         LspCmd         `cmd:"" help:"Run a Language Server Protocol server over stdio showing token counts in editors"`
+
+	Theme   string `help:"Color theme: ${themes}, or the path of a YAML theme file (default: dark, or monochrome without color)"`
+	Color   string `help:"When to color terminal output: auto (unless NO_COLOR is set or output is piped), always, never" default:"auto" enum:"auto,always,never"`
+	Profile string `help:"Config file profile to apply on top of the configured defaults"`
 }
 
 type VisualizeCmd struct {
-	Model          string `help:"Model to use: gpt4, gpt3.5, gpt5, gpt5-mini, gpt5-nano, claude:model-name, llama:path, llama3:path" default:"gpt4"`
-	Format         string `help:"Output format: terminal, markdown, html" default:"terminal" enum:"terminal,markdown,html"`
-	ShowIDs        bool   `help:"Show token IDs" short:"i" name:"show-ids"`
-	ShowBoundaries bool   `help:"Show token boundaries" short:"b"`
-	Encoding       string `help:"Tiktoken encoding (for GPT models)" default:"cl100k_base"`
-	NoCache        bool   `help:"Disable caching for Claude API" short:"n"`
+	Model             string `help:"Model to use: gpt4, gpt3.5, gpt5, gpt5-mini, gpt5-nano, claude:model-name, llama:path, llama3:path, remote:url" default:"gpt4"`
+	Format            string `help:"Output format: ${formats}" default:"terminal" enum:"${formats}"`
+	ShowIDs           bool   `help:"Show token IDs" short:"i" name:"show-ids"`
+	ShowBoundaries    bool   `help:"Show token boundaries" short:"b"`
+	ColorBy           string `help:"Token coloring: cycle, rank (token ID), length (characters), bytes, word (whole word vs fragment)" default:"cycle" enum:"cycle,rank,length,bytes,word"`
+	VisibleWhitespace bool   `help:"Show spaces (·), tabs (→), line breaks (↵), no-break spaces (⍽) and invisible characters (<U+200B>)" short:"w" name:"visible-whitespace"`
+	Columns           int    `help:"Wrap svg and png output at this many characters (0 for 80)"`
+	Numbered          bool   `help:"List one numbered token per line (plain format)"`
+	Stages            bool   `help:"Show the normalized text and pre-tokenized chunks as layers above the tokens (GPT and llama3 models)" xor:"view,source"`
+	Bytes             bool   `help:"Show the raw bytes of each token in hex, grouping tokens that only form whole characters together" xor:"view"`
+	InputIDs          bool   `help:"Read token IDs instead of text from stdin and show what they decode to" name:"input-ids" xor:"source"`
+	IDsFormat         string `help:"Format of --input-ids: auto, json (array), text (whitespace or comma separated), uint32 (little-endian binary)" name:"ids-format" default:"auto" enum:"auto,json,text,uint32"`
+	Encoding          string `help:"Tiktoken encoding (for GPT models)" default:"cl100k_base"`
+	NoCache           bool   `help:"Disable caching for Claude API and remote tokenizers" short:"n"`
 }
 
 type CountCmd struct {
-	Models   []string `help:"Models to count: gpt4, gpt3.5, gpt5, gpt5-mini, gpt5-nano, claude:model-name, llama:path, llama3:path" default:"gpt4"`
-	Encoding string   `help:"Tiktoken encoding (for GPT models)" default:"cl100k_base"`
-	NoCache  bool     `help:"Disable caching for Claude API" short:"n"`
+	Models     []string `help:"Models to count: gpt4, gpt3.5, gpt5, gpt5-mini, gpt5-nano, claude:model-name, llama:path, llama3:path, remote:url" default:"gpt4"`
+	Format     string   `help:"Output format: ${formats}" default:"terminal" enum:"${formats}"`
+	Efficiency bool     `help:"Report characters, bytes and words per token, overall and by script and character class" short:"e" xor:"view"`
+	Sections   bool     `help:"Break the count down by the sections, code blocks, tables and lists of a markdown document" short:"s" xor:"view"`
+	Encoding   string   `help:"Tiktoken encoding (for GPT models)" default:"cl100k_base"`
+	NoCache    bool     `help:"Disable caching for Claude API and remote tokenizers" short:"n"`
 }
 
 type CompareCmd struct {
-	Models         []string `help:"Models to compare: gpt4, gpt3.5, gpt5, gpt5-mini, gpt5-nano, claude:model-name, llama:path, llama3:path" required:""`
-	Format         string   `help:"Output format: terminal, markdown, html" default:"terminal" enum:"terminal,markdown,html"`
-	ShowIDs        bool     `help:"Show token IDs" short:"i" name:"show-ids"`
-	ShowBoundaries bool     `help:"Show token boundaries" short:"b"`
-	Encoding       string   `help:"Tiktoken encoding (for GPT models)" default:"cl100k_base"`
-	NoCache        bool     `help:"Disable caching for Claude API" short:"n"`
+	Models            []string `help:"Models to compare: gpt4, gpt3.5, gpt5, gpt5-mini, gpt5-nano, claude:model-name, llama:path, llama3:path, remote:url" required:""`
+	Format            string   `help:"Output format: ${formats}" default:"terminal" enum:"${formats}"`
+	ShowIDs           bool     `help:"Show token IDs" short:"i" name:"show-ids"`
+	ShowBoundaries    bool     `help:"Show token boundaries" short:"b"`
+	ColorBy           string   `help:"Token coloring: cycle, rank (token ID), length (characters), bytes, word (whole word vs fragment)" default:"cycle" enum:"cycle,rank,length,bytes,word"`
+	VisibleWhitespace bool     `help:"Show spaces (·), tabs (→), line breaks (↵), no-break spaces (⍽) and invisible characters (<U+200B>)" short:"w" name:"visible-whitespace"`
+	Columns           int      `help:"Width to lay out columns for, and to wrap svg and png output at (0 for the terminal width, or 80 for images)"`
+	Numbered          bool     `help:"List one numbered token per line (plain format)"`
+	NoPager           bool     `help:"Don't pipe long terminal output through $PAGER" name:"no-pager"`
+	Align             bool     `help:"Line up shared token boundaries across models and mark divergent spans" short:"a" xor:"view"`
+	Efficiency        bool     `help:"Report characters, bytes and words per token, overall and by script and character class" short:"e" xor:"view"`
+	Encoding          string   `help:"Tiktoken encoding (for GPT models)" default:"cl100k_base"`
+	NoCache           bool     `help:"Disable caching for Claude API and remote tokenizers" short:"n"`
 }
 
-func (v *VisualizeCmd) Run() error {
-	// Read from stdin
-	input, err := readInput()
+func (v *VisualizeCmd) Run(theme *output.Theme) error {
+	// Create tokenizer
+	tokenizer, err := createTokenizer(v.Model, v.Encoding, !v.NoCache)
 	if err != nil {
-		return fmt.Errorf("failed to read input: %w", err)
+		return err
 	}
 
-	// Create tokenizer
-	tokenizer, err := createTokenizer(v.Model, v.Encoding, !v.NoCache)
+	coloring, err := output.NewColoring(v.ColorBy)
 	if err != nil {
 		return err
 	}
 
-	// Tokenize
+	renderer, err := output.New(v.Format, output.Options{
+		ShowIDs:           v.ShowIDs,
+		ShowBoundaries:    v.ShowBoundaries,
+		VisibleWhitespace: v.VisibleWhitespace,
+		Numbered:          v.Numbered,
+		Coloring:          coloring,
+		Theme:             theme,
+		Columns:           v.Columns,
+	})
+	if err != nil {
+		return err
+	}
+	if err := checkStdout(renderer); err != nil {
+		return err
+	}
+
 	ctx := context.Background()
-	result, err := tokenizer.Encode(ctx, input)
+
+	var result *tokenizers.TokenizationResult
+	if v.InputIDs {
+		// Decode token IDs from stdin
+		ids, err := readInputIDs(v.IDsFormat)
+		if err != nil {
+			return fmt.Errorf("failed to read token IDs: %w", err)
+		}
+
+		result, err = tokenizers.DecodeIDs(ctx, tokenizer, ids)
+		if err != nil {
+			return err
+		}
+	} else {
+		// Read from stdin
+		input, err := readInput()
+		if err != nil {
+			return fmt.Errorf("failed to read input: %w", err)
+		}
+
+		if v.Stages {
+			return v.renderStages(ctx, renderer, tokenizer, input)
+		}
+
+		// Tokenize
+		result, err = tokenizer.Encode(ctx, input)
+		if err != nil {
+			return fmt.Errorf("tokenization failed: %w", err)
+		}
+	}
+
+	if v.Bytes {
+		return v.renderBytes(renderer, result)
+	}
+
+	// Render output
+	return renderer.Single(os.Stdout, result)
+}
+
+// renderStages renders the normalizer and pre-tokenizer layers along with the tokens
+func (v *VisualizeCmd) renderStages(ctx context.Context, renderer output.Renderer, tokenizer tokenizers.Tokenizer, input string) error {
+	inspector, ok := tokenizer.(tokenizers.StageInspector)
+	if !ok {
+		return fmt.Errorf("%s does not expose its pre-tokenizer (--stages needs a GPT or llama3 model)", tokenizer.Name())
+	}
+
+	stages, err := inspector.Stages(ctx, input)
 	if err != nil {
 		return fmt.Errorf("tokenization failed: %w", err)
 	}
 
 	// Render output
-	var outputStr string
-	switch v.Format {
-	case "terminal":
-		renderer := output.NewTerminalRenderer(v.ShowIDs, v.ShowBoundaries)
-		outputStr = renderer.RenderSingle(result)
-	case "markdown":
-		renderer := output.NewMarkdownRenderer(v.ShowIDs)
-		outputStr = renderer.RenderSingle(result)
-	case "html":
-		renderer := output.NewHTMLInlineRenderer(v.ShowIDs, v.ShowBoundaries)
-		outputStr = renderer.RenderSingle(result)
-	}
-
-	fmt.Print(outputStr)
-	return nil
+	stagesRenderer, err := output.As[output.StagesRenderer](renderer, v.Format, "--stages")
+	if err != nil {
+		return err
+	}
+	return stagesRenderer.Stages(os.Stdout, stages)
+}
+
+// renderBytes renders the raw bytes of every token
+func (v *VisualizeCmd) renderBytes(renderer output.Renderer, result *tokenizers.TokenizationResult) error {
+	bytesRenderer, err := output.As[output.BytesRenderer](renderer, v.Format, "--bytes")
+	if err != nil {
+		return err
+	}
+	return bytesRenderer.Bytes(os.Stdout, result)
 }
 
-func (c *CountCmd) Run() error {
+func (c *CountCmd) Run(theme *output.Theme) error {
+	renderer, err := output.New(c.Format, output.Options{Theme: theme})
+	if err != nil {
+		return err
+	}
+	if err := checkStdout(renderer); err != nil {
+		return err
+	}
+
 	// Read from stdin
 	input, err := readInput()
 	if err != nil {
@@ -106,13 +205,47 @@ func (c *CountCmd) Run() error {
 	}
 
 	// Render
-	renderer := output.NewTerminalRenderer(false, false)
-	fmt.Print(renderer.RenderCountOnly(results))
-
-	return nil
+	switch {
+	case c.Efficiency:
+		return renderEfficiency(os.Stdout, renderer, c.Format, results)
+	case c.Sections:
+		sectionsRenderer, err := output.As[output.SectionsRenderer](renderer, c.Format, "--sections")
+		if err != nil {
+			return err
+		}
+		return sectionsRenderer.Sections(os.Stdout, outline.Compute(results))
+	}
+	return renderer.Counts(os.Stdout, results)
 }
 
-func (c *CompareCmd) Run() error {
+func (c *CompareCmd) Run(theme *output.Theme) error {
+	coloring, err := output.NewColoring(c.ColorBy)
+	if err != nil {
+		return err
+	}
+
+	// Lay out terminal columns for the width of the terminal
+	columns := c.Columns
+	if columns == 0 && c.Format == "terminal" {
+		columns, _ = terminalSize()
+	}
+
+	renderer, err := output.New(c.Format, output.Options{
+		ShowIDs:           c.ShowIDs,
+		ShowBoundaries:    c.ShowBoundaries,
+		VisibleWhitespace: c.VisibleWhitespace,
+		Numbered:          c.Numbered,
+		Coloring:          coloring,
+		Theme:             theme,
+		Columns:           columns,
+	})
+	if err != nil {
+		return err
+	}
+	if err := checkStdout(renderer); err != nil {
+		return err
+	}
+
 	// Read from stdin
 	input, err := readInput()
 	if err != nil {
@@ -136,23 +269,60 @@ func (c *CompareCmd) Run() error {
 
 		results = append(results, result)
 	}
+	labelDuplicates(results, c.Models)
+
+	// Render output, paging it if it's taller than the terminal
+	var out bytes.Buffer
+	switch {
+	case c.Efficiency:
+		err = renderEfficiency(&out, renderer, c.Format, results)
+	case c.Align:
+		var alignedRenderer output.AlignedRenderer
+		alignedRenderer, err = output.As[output.AlignedRenderer](renderer, c.Format, "--align")
+		if err == nil {
+			err = alignedRenderer.Aligned(&out, align.Compute(results))
+		}
+	default:
+		err = renderer.Comparison(&out, results)
+	}
+	if err != nil {
+		return err
+	}
 
-	// Render output
-	var outputStr string
-	switch c.Format {
-	case "terminal":
-		renderer := output.NewTerminalRenderer(c.ShowIDs, c.ShowBoundaries)
-		outputStr = renderer.RenderComparison(results)
-	case "markdown":
-		renderer := output.NewMarkdownRenderer(c.ShowIDs)
-		outputStr = renderer.RenderComparison(results)
-	case "html":
-		renderer := output.NewHTMLInlineRenderer(c.ShowIDs, c.ShowBoundaries)
-		outputStr = renderer.RenderComparison(results)
-	}
-
-	fmt.Print(outputStr)
-	return nil
+	return writePaged(out.Bytes(), c.Format == "terminal" && !c.NoPager)
+}
+
+// labelDuplicates labels results that share a tokenizer, such as gpt4 and
+// gpt3.5, with the model they were requested as, so they can be told apart.
+// Remote options are left out of the label, since they can hold credentials.
+func labelDuplicates(results []*tokenizers.TokenizationResult, models []string) {
+	counts := make(map[string]int, len(results))
+	for _, result := range results {
+		counts[result.Model]++
+	}
+	for i, result := range results {
+		if counts[result.Model] > 1 {
+			result.Model, _, _ = strings.Cut(models[i], "#")
+		}
+	}
+}
+
+// renderEfficiency renders the efficiency report of each result
+func renderEfficiency(w io.Writer, renderer output.Renderer, format string, results []*tokenizers.TokenizationResult) error {
+	efficiencyRenderer, err := output.As[output.EfficiencyRenderer](renderer, format, "--efficiency")
+	if err != nil {
+		return err
+	}
+	return efficiencyRenderer.Efficiency(w, efficiencyReports(results))
+}
+
+// efficiencyReports measures the tokenization efficiency of each result
+func efficiencyReports(results []*tokenizers.TokenizationResult) []*metrics.Report {
+	reports := make([]*metrics.Report, len(results))
+	for i, result := range results {
+		reports[i] = metrics.Compute(result)
+	}
+	return reports
 }
 
 func readInput() (string, error) {
@@ -169,7 +339,12 @@ func readInput() (string, error) {
 	return input, nil
 }
 
+// builtinModels are the model names that need no path or URL; config aliases can't replace them
+var builtinModels = []string{"gpt4", "gpt3.5", "gpt5", "gpt5-mini", "gpt5-nano"}
+
 func createTokenizer(model, encoding string, useCache bool) (tokenizers.Tokenizer, error) {
+	model = resolveAlias(model)
+
 	// Check if model contains a colon (model:specification)
 	parts := strings.SplitN(model, ":", 2)
 	modelType := parts[0]
@@ -200,18 +375,47 @@ func createTokenizer(model, encoding string, useCache bool) (tokenizers.Tokenize
 			return nil, fmt.Errorf("llama3 model requires format: llama3:/path/to/tokenizer.json")
 		}
 		return tokenizers.NewLLaMA3Tokenizer(parts[1])
+	case "remote":
+		// Require remote:url format
+		if len(parts) != 2 || parts[1] == "" {
+			return nil, fmt.Errorf("remote model requires format: remote:http://host:port/tokenize")
+		}
+		// Environment variables are only expanded in specs the user wrote
+		cfg, err := tokenizers.ParseRemoteSpec(parts[1], !projectSpecs[model])
+		if err != nil {
+			return nil, err
+		}
+		return tokenizers.NewRemoteTokenizer(cfg, useCache)
 	default:
 		return nil, fmt.Errorf("unknown model: %s", model)
 	}
 }
 
 func main() {
+	config, err := loadConfig(configPaths())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "token-visualizer: error: %v\n", err)
+		os.Exit(1)
+	}
+	modelAliases = config.aliases
+	projectSpecs = config.projectSpecs
+
 	ctx := kong.Parse(&CLI,
 		kong.Name("token-visualizer"),
 		kong.Description("Visualize and analyze tokens from various LLM tokenizers"),
 		kong.UsageOnError(),
+		kong.Vars{
+			"formats": strings.Join(output.Formats(), ", "),
+			"themes":  strings.Join(output.Themes(), ", "),
+		},
+		kong.Resolvers(config),
 	)
+	ctx.FatalIfErrorf(config.checkProfile(CLI.Profile))
+
+	theme, err := setupColor(CLI.Color, CLI.Theme)
+	ctx.FatalIfErrorf(err)
+	ctx.Bind(theme)
 
-	err := ctx.Run()
+	err = ctx.Run()
 	ctx.FatalIfErrorf(err)
 }
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/kong"
+	"github.com/spandigital/token-visualizer/internal/output"
+)
+
+func TestCompareViews(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		err  bool
+	}{
+		{"align", []string{"--align"}, false},
+		{"efficiency", []string{"--efficiency"}, false},
+		{"align and efficiency", []string{"--align", "--efficiency"}, true},
+		{"short flags", []string{"-a", "-e"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cli struct {
+				Compare CompareCmd `cmd:""`
+			}
+			parser, err := kong.New(&cli, kong.Exit(func(int) {}), kong.Vars{
+				"formats": strings.Join(output.Formats(), ", "),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = parser.Parse(append([]string{"compare", "--models", "gpt4,gpt5"}, tt.args...))
+			if (err != nil) != tt.err {
+				t.Errorf("error = %v, want error: %v", err, tt.err)
+			}
+		})
+	}
+}
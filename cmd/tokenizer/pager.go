@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/spandigital/token-visualizer/internal/output"
+)
+
+// terminalSize returns the size of the terminal on stdout. If stdout is not a
+// terminal, the width comes from $COLUMNS and the height is 0.
+func terminalSize() (width, height int) {
+	if term.IsTerminal(os.Stdout.Fd()) {
+		if width, height, err := term.GetSize(os.Stdout.Fd()); err == nil {
+			return width, height
+		}
+	}
+	width, _ = strconv.Atoi(os.Getenv("COLUMNS"))
+	return width, 0
+}
+
+// writePaged writes output to stdout, piping it through $PAGER (less by
+// default) if stdout is a terminal and the output doesn't fit on one screen.
+// Output is written directly if the pager can't be started.
+func writePaged(output []byte, page bool) error {
+	_, height := terminalSize()
+	if !page || height == 0 || bytes.Count(output, []byte("\n")) < height {
+		_, err := os.Stdout.Write(output)
+		return err
+	}
+
+	pager := strings.Fields(os.Getenv("PAGER"))
+	if len(pager) == 0 {
+		pager = []string{"less"}
+	}
+
+	cmd := exec.Command(pager[0], pager[1:]...)
+	cmd.Stdin = bytes.NewReader(output)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	// Let less pass colors through and quit if the output fits after all
+	if os.Getenv("LESS") == "" {
+		cmd.Env = append(os.Environ(), "LESS=FRX")
+	}
+
+	if err := cmd.Start(); err != nil {
+		_, err := os.Stdout.Write(output)
+		return err
+	}
+	return cmd.Wait()
+}
+
+// checkStdout refuses to write binary output to a terminal, where it would
+// garble the screen
+func checkStdout(renderer output.Renderer) error {
+	return checkOutput(renderer, term.IsTerminal(os.Stdout.Fd()))
+}
+
+// checkOutput returns an error if the renderer writes binary output and
+// stdout is a terminal
+func checkOutput(renderer output.Renderer, terminal bool) error {
+	if terminal && renderer.ContentType() == "image/png" {
+		return fmt.Errorf("refusing to write PNG to a terminal; redirect stdout to a file, e.g. > tokens.png")
+	}
+	return nil
+}
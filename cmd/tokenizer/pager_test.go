@@ -0,0 +1,29 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/spandigital/token-visualizer/internal/output"
+)
+
+func TestCheckOutput(t *testing.T) {
+	tests := []struct {
+		format   string
+		terminal bool
+		err      bool
+	}{
+		{"png", true, true},
+		{"png", false, false},
+		{"svg", true, false},
+		{"plain", true, false},
+	}
+	for _, tt := range tests {
+		renderer, err := output.New(tt.format, output.Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := checkOutput(renderer, tt.terminal); (err != nil) != tt.err {
+			t.Errorf("checkOutput(%s, terminal: %v) = %v, want error: %v", tt.format, tt.terminal, err, tt.err)
+		}
+	}
+}
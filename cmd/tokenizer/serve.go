@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spandigital/token-visualizer/internal/output"
+	"github.com/spandigital/token-visualizer/internal/server"
+	"github.com/spandigital/token-visualizer/internal/tokenizers"
+)
+
+type ServeCmd struct {
+	Addr            string        `help:"Address to listen on" default:"localhost:8080"`
+	Models          []string      `help:"Models to keep loaded: gpt4, gpt3.5, gpt5, gpt5-mini, gpt5-nano, claude:model-name, llama:path, llama3:path, remote:url" default:"gpt4"`
+	Encoding        string        `help:"Tiktoken encoding (for GPT models)" default:"cl100k_base"`
+	NoCache         bool          `help:"Disable caching for Claude API and remote tokenizers" short:"n"`
+	MaxBodyBytes    int64         `help:"Maximum request body size in bytes" default:"1048576"`
+	ShutdownTimeout time.Duration `help:"Time to wait for in-flight requests on shutdown" default:"10s"`
+}
+
+func (s *ServeCmd) Run(theme *output.Theme) error {
+	// Load every configured tokenizer up front so requests never pay the load cost
+	loaded := make(map[string]tokenizers.Tokenizer, len(s.Models))
+	for _, model := range s.Models {
+		tokenizer, err := createTokenizer(model, s.Encoding, !s.NoCache)
+		if err != nil {
+			return err
+		}
+		loaded[model] = tokenizer
+	}
+
+	srv := server.New(server.Config{
+		Models:       s.Models,
+		Tokenizers:   loaded,
+		MaxBodyBytes: s.MaxBodyBytes,
+		Theme:        theme,
+	})
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	fmt.Fprintf(os.Stderr, "token-visualizer listening on http://%s\n", s.Addr)
+	return srv.ListenAndServe(ctx, s.Addr, s.ShutdownTimeout)
+}
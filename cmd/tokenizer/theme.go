@@ -0,0 +1,62 @@
+package main
+
+import (
+	"os"
+	"slices"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/term"
+	"github.com/muesli/termenv"
+	"github.com/spandigital/token-visualizer/internal/output"
+)
+
+// setupColor applies --color and NO_COLOR to terminal output and loads the
+// theme. Without color the default theme is monochrome, which still shows
+// token boundaries with underlines on a terminal when NO_COLOR is set.
+func setupColor(mode, themeName string) (*output.Theme, error) {
+	color := mode == "always" || (mode == "auto" && !termenv.EnvNoColor())
+	if themeName == "" && !color {
+		themeName = output.ThemeMonochrome
+	}
+
+	theme, err := loadTheme(themeName)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case mode == "always" && lipgloss.ColorProfile() == termenv.Ascii:
+		// Color output that is piped, using what the terminal would support
+		profile := termenv.NewOutput(os.Stdout, termenv.WithTTY(true)).ColorProfile()
+		if profile == termenv.Ascii {
+			profile = termenv.ANSI256
+		}
+		lipgloss.SetColorProfile(profile)
+	case !color:
+		lipgloss.SetColorProfile(noColorProfile(mode, theme, term.IsTerminal(os.Stdout.Fd())))
+	}
+
+	return theme, nil
+}
+
+// noColorProfile returns the color profile for output without color.
+// --color=never turns off all escape codes, while NO_COLOR only rules out
+// colors, so a monochrome theme keeps its text attributes on a terminal.
+func noColorProfile(mode string, theme *output.Theme, terminal bool) termenv.Profile {
+	if mode == "auto" && terminal && !theme.HasColor() {
+		return termenv.ANSI
+	}
+	return termenv.Ascii
+}
+
+// loadTheme returns the built-in theme with the given name, or reads the
+// theme file at that path
+func loadTheme(name string) (*output.Theme, error) {
+	if name == "" || slices.Contains(output.Themes(), name) {
+		return output.NewTheme(name)
+	}
+	if _, err := os.Stat(name); err == nil {
+		return output.LoadTheme(name)
+	}
+	return output.NewTheme(name)
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/muesli/termenv"
+	"github.com/spandigital/token-visualizer/internal/output"
+)
+
+func TestNoColorProfile(t *testing.T) {
+	monochrome, err := output.NewTheme(output.ThemeMonochrome)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dark, err := output.NewTheme(output.ThemeDark)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		mode     string
+		theme    *output.Theme
+		terminal bool
+		want     termenv.Profile
+	}{
+		{"NO_COLOR on a terminal", "auto", monochrome, true, termenv.ANSI},
+		{"NO_COLOR piped", "auto", monochrome, false, termenv.Ascii},
+		{"NO_COLOR with a color theme", "auto", dark, true, termenv.Ascii},
+		{"never on a terminal", "never", monochrome, true, termenv.Ascii},
+		{"never piped", "never", monochrome, false, termenv.Ascii},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := noColorProfile(tt.mode, tt.theme, tt.terminal); got != tt.want {
+				t.Errorf("profile = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
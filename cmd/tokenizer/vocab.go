@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+
+	"github.com/spandigital/token-visualizer/internal/output"
+	"github.com/spandigital/token-visualizer/internal/tokenizers"
+	"github.com/spandigital/token-visualizer/internal/vocab"
+)
+
+type VocabCmd struct {
+	Model    string `help:"Model to use: gpt4, gpt3.5, gpt5, gpt5-mini, gpt5-nano, llama:path, llama3:path, remote:url" default:"gpt4"`
+	Regex    string `help:"Only list tokens matching this regular expression" short:"r"`
+	Exact    string `help:"Check whether a string is a single token, with and without a leading space" short:"x"`
+	From     int    `help:"First token ID to list" default:"0"`
+	To       int    `help:"Last token ID to list (-1 for the end of the vocabulary)" default:"-1"`
+	Limit    int    `help:"Maximum number of tokens to show in the table (0 for no limit)" default:"100"`
+	Format   string `help:"Output format: ${formats}" default:"terminal" enum:"${formats}"`
+	JSONL    bool   `help:"Write matching tokens as JSON lines (id, text, hex) instead of a table" name:"jsonl"`
+	Encoding string `help:"Tiktoken encoding (for GPT models)" default:"cl100k_base"`
+	NoCache  bool   `help:"Disable caching for remote tokenizers" short:"n"`
+}
+
+// vocabLine is a vocabulary entry in the JSONL dump
+type vocabLine struct {
+	ID   int    `json:"id"`
+	Text string `json:"text"`
+	Hex  string `json:"hex"`
+}
+
+func (v *VocabCmd) Run(theme *output.Theme) error {
+	tokenizer, err := createTokenizer(v.Model, v.Encoding, !v.NoCache)
+	if err != nil {
+		return err
+	}
+
+	renderer, err := output.New(v.Format, output.Options{Theme: theme})
+	if err != nil {
+		return err
+	}
+
+	vocabRenderer, err := output.As[output.VocabRenderer](renderer, v.Format, "vocabulary listings")
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+
+	modelName := tokenizer.Name()
+	if size := tokenizers.VocabSize(tokenizer); size > 0 {
+		modelName = fmt.Sprintf("%s (%d tokens)", modelName, size)
+	}
+
+	if v.Exact != "" {
+		matches, err := vocab.Lookup(ctx, tokenizer, v.Exact)
+		if err != nil {
+			return err
+		}
+		return vocabRenderer.Lookup(os.Stdout, modelName, matches)
+	}
+
+	query := vocab.Query{From: v.From, To: v.To}
+	if v.Regex != "" {
+		query.Regex, err = regexp.Compile(v.Regex)
+		if err != nil {
+			return fmt.Errorf("invalid regex: %w", err)
+		}
+	}
+
+	if v.JSONL {
+		w := bufio.NewWriter(os.Stdout)
+		encoder := json.NewEncoder(w)
+		encoder.SetEscapeHTML(false)
+
+		err := vocab.Scan(ctx, tokenizer, query, func(entry vocab.Entry) error {
+			return encoder.Encode(vocabLine{ID: entry.ID, Text: entry.Text(), Hex: hex.EncodeToString(entry.Bytes)})
+		})
+		if err != nil {
+			return err
+		}
+		return w.Flush()
+	}
+
+	var entries []vocab.Entry
+	matched := 0
+	err = vocab.Scan(ctx, tokenizer, query, func(entry vocab.Entry) error {
+		matched++
+		if v.Limit <= 0 || len(entries) < v.Limit {
+			entries = append(entries, entry)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return vocabRenderer.Vocab(os.Stdout, modelName, entries, matched)
+}
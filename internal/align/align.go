@@ -0,0 +1,141 @@
+package align
+
+import (
+	"sort"
+	"unicode/utf8"
+
+	"github.com/spandigital/token-visualizer/internal/tokenizers"
+)
+
+// Piece is a run of input bytes covered by one token, or by several tokens
+// that each encode part of the same character
+type Piece struct {
+	Start  int
+	End    int
+	Tokens []tokenizers.Token
+}
+
+// Segment is the text between two boundaries shared by every model
+type Segment struct {
+	Start     int
+	End       int
+	Pieces    [][]Piece // Pieces of each model, in the order of Result.Models
+	Divergent bool      // True if any model splits the segment into more than one piece
+}
+
+// Result lines up the tokenizations of several models on their shared boundaries
+type Result struct {
+	Text      string
+	Models    []string
+	Indices   []int    // Position of each of Models in the results given to Compute
+	Skipped   []string // Models without individual tokens (e.g. Claude), left out of the alignment
+	Segments  []Segment
+	Shared    int // Number of shared boundaries inside the text
+	Divergent int // Number of divergent segments
+}
+
+// Compute aligns the results on the byte offsets where every model has a token boundary.
+// All results must be tokenizations of the same text. Tokens that cover no input bytes,
+// such as BOS markers, are left out. Models are told apart by their position, since
+// two models can use the same tokenizer.
+func Compute(results []*tokenizers.TokenizationResult) *Result {
+	aligned := &Result{}
+	if len(results) == 0 {
+		return aligned
+	}
+	aligned.Text = results[0].Text
+	text := aligned.Text
+
+	var pieces [][]Piece
+	for i, result := range results {
+		if len(result.Tokens) > 0 && result.Tokens[0].ID < 0 {
+			aligned.Skipped = append(aligned.Skipped, result.Model)
+			continue
+		}
+		aligned.Models = append(aligned.Models, result.Model)
+		aligned.Indices = append(aligned.Indices, i)
+		pieces = append(pieces, splitPieces(text, result.Tokens))
+	}
+
+	if len(pieces) == 0 || text == "" {
+		return aligned
+	}
+
+	// A boundary is shared if every model has a piece starting there
+	counts := make(map[int]int)
+	for _, modelPieces := range pieces {
+		for _, piece := range modelPieces {
+			if piece.Start > 0 {
+				counts[piece.Start]++
+			}
+		}
+	}
+
+	boundaries := []int{0}
+	for offset, count := range counts {
+		if count == len(pieces) {
+			boundaries = append(boundaries, offset)
+		}
+	}
+	sort.Ints(boundaries)
+	boundaries = append(boundaries, len(text))
+	aligned.Shared = len(boundaries) - 2
+
+	// Walk the boundaries, handing each model's pieces to the segment they fall in
+	next := make([]int, len(pieces))
+	for b := 1; b < len(boundaries); b++ {
+		segment := Segment{
+			Start:  boundaries[b-1],
+			End:    boundaries[b],
+			Pieces: make([][]Piece, len(pieces)),
+		}
+
+		for m, modelPieces := range pieces {
+			for next[m] < len(modelPieces) && modelPieces[next[m]].Start < segment.End {
+				segment.Pieces[m] = append(segment.Pieces[m], modelPieces[next[m]])
+				next[m]++
+			}
+			if len(segment.Pieces[m]) != 1 {
+				segment.Divergent = true
+			}
+		}
+
+		if segment.Divergent {
+			aligned.Divergent++
+		}
+		aligned.Segments = append(aligned.Segments, segment)
+	}
+
+	return aligned
+}
+
+// splitPieces groups tokens into pieces that start on character boundaries
+func splitPieces(text string, tokens []tokenizers.Token) []Piece {
+	var pieces []Piece
+
+	for _, token := range tokens {
+		start := min(token.Start, len(text))
+		end := min(token.End, len(text))
+		if end <= start {
+			continue
+		}
+
+		if n := len(pieces); n > 0 {
+			last := &pieces[n-1]
+			// Merge tokens that overlap the previous piece or start inside a character
+			if start < last.End || (start < len(text) && !utf8.RuneStart(text[start])) {
+				last.End = max(last.End, end)
+				last.Tokens = append(last.Tokens, token)
+				continue
+			}
+		}
+
+		pieces = append(pieces, Piece{
+			Start:  start,
+			End:    end,
+			Tokens: []tokenizers.Token{token},
+		})
+	}
+
+	return pieces
+}
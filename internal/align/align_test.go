@@ -0,0 +1,137 @@
+package align
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/spandigital/token-visualizer/internal/tokenizers"
+)
+
+// result builds a tokenization of the concatenated pieces, with IDs counting up from 1
+func result(model string, pieces ...string) *tokenizers.TokenizationResult {
+	r := &tokenizers.TokenizationResult{Model: model}
+	for i, piece := range pieces {
+		start := len(r.Text)
+		r.Text += piece
+		r.Tokens = append(r.Tokens, tokenizers.Token{Text: piece, ID: i + 1, Start: start, End: len(r.Text)})
+	}
+	r.TotalCount = len(r.Tokens)
+	return r
+}
+
+// span is the byte range of a segment, and whether it diverges
+type span struct {
+	start, end int
+	divergent  bool
+}
+
+func TestCompute(t *testing.T) {
+	// Claude results have a single token with a negative ID
+	claude := &tokenizers.TokenizationResult{Model: "claude", Text: "hello world", Tokens: []tokenizers.Token{{ID: -1, Text: "hello world", End: 11}}}
+
+	// A BOS token covers no input bytes
+	bos := result("llama", "hello", " world")
+	bos.Tokens = append([]tokenizers.Token{{Text: "<s>", ID: 1}}, bos.Tokens...)
+
+	// Two tokens with the halves of "é", which start inside a character
+	split := result("bytes", "caf", "\xc3", "\xa9")
+
+	tests := []struct {
+		name     string
+		results  []*tokenizers.TokenizationResult
+		models   []string
+		indices  []int
+		skipped  []string
+		segments []span
+		shared   int
+	}{
+		{
+			name:     "identical",
+			results:  []*tokenizers.TokenizationResult{result("a", "hello", " world"), result("b", "hello", " world")},
+			models:   []string{"a", "b"},
+			indices:  []int{0, 1},
+			segments: []span{{0, 5, false}, {5, 11, false}},
+			shared:   1,
+		},
+		{
+			name:     "divergent",
+			results:  []*tokenizers.TokenizationResult{result("a", "hello", " world"), result("b", "hel", "lo", " world")},
+			models:   []string{"a", "b"},
+			indices:  []int{0, 1},
+			segments: []span{{0, 5, true}, {5, 11, false}},
+			shared:   1,
+		},
+		{
+			name:     "no shared boundaries",
+			results:  []*tokenizers.TokenizationResult{result("a", "ab", "cd"), result("b", "a", "bcd")},
+			models:   []string{"a", "b"},
+			indices:  []int{0, 1},
+			segments: []span{{0, 4, true}},
+		},
+		{
+			name:     "same tokenizer twice",
+			results:  []*tokenizers.TokenizationResult{result("cl100k_base", "hello"), claude, result("cl100k_base", "hel", "lo")},
+			models:   []string{"cl100k_base", "cl100k_base"},
+			indices:  []int{0, 2},
+			skipped:  []string{"claude"},
+			segments: []span{{0, 5, true}},
+		},
+		{
+			name:     "BOS token",
+			results:  []*tokenizers.TokenizationResult{bos, result("a", "hello", " world")},
+			models:   []string{"llama", "a"},
+			indices:  []int{0, 1},
+			segments: []span{{0, 5, false}, {5, 11, false}},
+			shared:   1,
+		},
+		{
+			name:     "partial characters",
+			results:  []*tokenizers.TokenizationResult{split, result("a", "caf", "é")},
+			models:   []string{"bytes", "a"},
+			indices:  []int{0, 1},
+			segments: []span{{0, 3, false}, {3, 5, false}},
+			shared:   1,
+		},
+		{
+			name:    "only Claude",
+			results: []*tokenizers.TokenizationResult{claude},
+			skipped: []string{"claude"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			aligned := Compute(tt.results)
+
+			if !slices.Equal(aligned.Models, tt.models) {
+				t.Errorf("models = %q, want %q", aligned.Models, tt.models)
+			}
+			if !slices.Equal(aligned.Indices, tt.indices) {
+				t.Errorf("indices = %v, want %v", aligned.Indices, tt.indices)
+			}
+			if !slices.Equal(aligned.Skipped, tt.skipped) {
+				t.Errorf("skipped = %q, want %q", aligned.Skipped, tt.skipped)
+			}
+			if aligned.Shared != tt.shared {
+				t.Errorf("shared = %d, want %d", aligned.Shared, tt.shared)
+			}
+
+			var segments []span
+			divergent := 0
+			for _, segment := range aligned.Segments {
+				segments = append(segments, span{segment.Start, segment.End, segment.Divergent})
+				if segment.Divergent {
+					divergent++
+				}
+				if len(segment.Pieces) != len(tt.models) {
+					t.Errorf("segment %d-%d has pieces for %d models, want %d", segment.Start, segment.End, len(segment.Pieces), len(tt.models))
+				}
+			}
+			if !slices.Equal(segments, tt.segments) {
+				t.Errorf("segments = %v, want %v", segments, tt.segments)
+			}
+			if aligned.Divergent != divergent {
+				t.Errorf("divergent = %d, want %d", aligned.Divergent, divergent)
+			}
+		})
+	}
+}
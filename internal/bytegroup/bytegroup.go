@@ -0,0 +1,64 @@
+package bytegroup
+
+import (
+	"unicode/utf8"
+
+	"github.com/spandigital/token-visualizer/internal/tokenizers"
+)
+
+// Group is a run of tokens whose bytes only form whole characters together.
+// Most groups hold a single token; byte-level BPE splits rare characters such
+// as emoji and CJK ideographs over several tokens that are partial UTF-8 on
+// their own.
+type Group struct {
+	Tokens []tokenizers.Token
+	Bytes  []byte // Raw bytes of all tokens in the group
+}
+
+// Text returns the bytes of the group as a string
+func (g Group) Text() string {
+	return string(g.Bytes)
+}
+
+// Partial reports whether the group spans several tokens
+func (g Group) Partial() bool {
+	return len(g.Tokens) > 1
+}
+
+// Valid reports whether the group decodes to valid UTF-8
+func (g Group) Valid() bool {
+	return utf8.Valid(g.Bytes)
+}
+
+// Compute groups consecutive tokens until their bytes no longer end in the
+// middle of a character. A token that is valid UTF-8 on its own is a group by itself.
+func Compute(tokens []tokenizers.Token) []Group {
+	var groups []Group
+
+	for i := 0; i < len(tokens); {
+		group := Group{}
+		for i < len(tokens) {
+			group.Tokens = append(group.Tokens, tokens[i])
+			group.Bytes = append(group.Bytes, tokens[i].Text...)
+			i++
+			if !incompleteTail(group.Bytes) {
+				break
+			}
+		}
+		groups = append(groups, group)
+	}
+
+	return groups
+}
+
+// incompleteTail reports whether b ends with the start of a multi-byte
+// character that is missing its continuation bytes
+func incompleteTail(b []byte) bool {
+	// Find the start of the last character within the longest possible encoding
+	for i := len(b) - 1; i >= 0 && i >= len(b)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(b[i]) {
+			return !utf8.FullRune(b[i:])
+		}
+	}
+	return false
+}
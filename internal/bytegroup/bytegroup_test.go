@@ -0,0 +1,94 @@
+package bytegroup
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/spandigital/token-visualizer/internal/tokenizers"
+)
+
+// tokens makes one token per piece
+func tokens(pieces ...string) []tokenizers.Token {
+	result := make([]tokenizers.Token, len(pieces))
+	offset := 0
+	for i, piece := range pieces {
+		result[i] = tokenizers.Token{Text: piece, ID: i, Start: offset, End: offset + len(piece)}
+		offset += len(piece)
+	}
+	return result
+}
+
+func TestCompute(t *testing.T) {
+	tests := []struct {
+		name   string
+		pieces []string
+		want   []string // Pieces of each group, joined by "|"
+	}{
+		{"whole characters", []string{"a", "é", "€"}, []string{"a", "é", "€"}},
+		{"two-byte character in two tokens", []string{"\xc3", "\xa9"}, []string{"\xc3|\xa9"}},
+		{"three-byte character after its first byte", []string{"\xe2", "\x82\xac"}, []string{"\xe2|\x82\xac"}},
+		{"three-byte character after two bytes", []string{"\xe2\x82", "\xac"}, []string{"\xe2\x82|\xac"}},
+		{"three-byte character in three tokens", []string{"\xe2", "\x82", "\xac"}, []string{"\xe2|\x82|\xac"}},
+		{"four-byte character in four tokens", []string{"\xf0", "\x9f", "\x98", "\x80"}, []string{"\xf0|\x9f|\x98|\x80"}},
+		{"four-byte character in two tokens", []string{"\xf0\x9f", "\x98\x80"}, []string{"\xf0\x9f|\x98\x80"}},
+		{"four-byte character after three bytes", []string{"\xf0\x9f\x98", "\x80"}, []string{"\xf0\x9f\x98|\x80"}},
+		{
+			// The tails of both characters are in the same token as the
+			// surrounding text, which ends each group
+			name:   "characters sharing tokens with text",
+			pieces: []string{" a\xc3", "\xa9 \xe2\x82", "\xac!", "b"},
+			want:   []string{" a\xc3|\xa9 \xe2\x82|\xac!", "b"},
+		},
+		{"truncated at the end", []string{"a", "\xe2\x82"}, []string{"a", "\xe2\x82"}},
+		{"stray continuation byte", []string{"\x80", "a"}, []string{"\x80", "a"}},
+		{"invalid byte", []string{"\xff", "\xfe"}, []string{"\xff", "\xfe"}},
+		{"no tokens", nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, group := range Compute(tokens(tt.pieces...)) {
+				var pieces []string
+				for _, token := range group.Tokens {
+					pieces = append(pieces, token.Text)
+				}
+				if group.Text() != strings.Join(pieces, "") {
+					t.Errorf("group bytes %q, want the tokens %q", group.Text(), pieces)
+				}
+				if group.Partial() != (len(pieces) > 1) {
+					t.Errorf("group %q partial = %v", pieces, group.Partial())
+				}
+				got = append(got, strings.Join(pieces, "|"))
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("groups = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIncompleteTail(t *testing.T) {
+	tests := []struct {
+		b    string
+		want bool
+	}{
+		{"", false},
+		{"abc", false},
+		{"a\xc3", true},
+		{"a\xc3\xa9", false},
+		{"\xe2", true},
+		{"\xe2\x82", true},
+		{"\xe2\x82\xac", false},
+		{"\xf0\x9f\x98", true},
+		{"\xf0\x9f\x98\x80", false},
+		{"\x80", false},
+		{"\x80\x80\x80\x80\x80", false},
+		{"\xff", false},
+	}
+	for _, tt := range tests {
+		if got := incompleteTail([]byte(tt.b)); got != tt.want {
+			t.Errorf("incompleteTail(%q) = %v, want %v", tt.b, got, tt.want)
+		}
+	}
+}
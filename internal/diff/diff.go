@@ -0,0 +1,208 @@
+package diff
+
+import (
+	"github.com/spandigital/token-visualizer/internal/tokenizers"
+)
+
+// Op is the kind of change applied to a token
+type Op int
+
+const (
+	// Equal means the token appears in both versions
+	Equal Op = iota
+	// Insert means the token only appears in the new version
+	Insert
+	// Delete means the token only appears in the old version
+	Delete
+)
+
+// Edit is a single token in the aligned token sequence
+type Edit struct {
+	Op    Op
+	Token tokenizers.Token
+}
+
+// Result is the token-level diff between two tokenizations of the same model
+type Result struct {
+	Model    string
+	Old      *tokenizers.TokenizationResult
+	New      *tokenizers.TokenizationResult
+	Edits    []Edit
+	Inserted int // Number of inserted tokens
+	Deleted  int // Number of deleted tokens
+}
+
+// Delta returns the change in total token count from old to new
+func (r *Result) Delta() int {
+	return r.New.TotalCount - r.Old.TotalCount
+}
+
+// Hunk is a run of consecutive deletions and insertions
+type Hunk struct {
+	Deleted  []tokenizers.Token
+	Inserted []tokenizers.Token
+}
+
+// Hunks groups the edits into runs of changes, skipping unchanged tokens
+func (r *Result) Hunks() []Hunk {
+	var hunks []Hunk
+	var current *Hunk
+
+	for _, edit := range r.Edits {
+		if edit.Op == Equal {
+			current = nil
+			continue
+		}
+
+		if current == nil {
+			hunks = append(hunks, Hunk{})
+			current = &hunks[len(hunks)-1]
+		}
+
+		if edit.Op == Delete {
+			current.Deleted = append(current.Deleted, edit.Token)
+		} else {
+			current.Inserted = append(current.Inserted, edit.Token)
+		}
+	}
+
+	return hunks
+}
+
+// Compute aligns the tokens of two tokenizations with the Myers diff algorithm
+func Compute(model string, oldResult, newResult *tokenizers.TokenizationResult) *Result {
+	a := oldResult.Tokens
+	b := newResult.Tokens
+
+	// Strip the common prefix and suffix so the diff only works on the changed middle
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && sameToken(a[prefix], b[prefix]) {
+		prefix++
+	}
+
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && sameToken(a[len(a)-1-suffix], b[len(b)-1-suffix]) {
+		suffix++
+	}
+
+	edits := make([]Edit, 0, len(a)+len(b))
+	for _, token := range b[:prefix] {
+		edits = append(edits, Edit{Op: Equal, Token: token})
+	}
+	edits = append(edits, myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, token := range b[len(b)-suffix:] {
+		edits = append(edits, Edit{Op: Equal, Token: token})
+	}
+
+	result := &Result{
+		Model: model,
+		Old:   oldResult,
+		New:   newResult,
+		Edits: edits,
+	}
+
+	for _, edit := range edits {
+		switch edit.Op {
+		case Insert:
+			result.Inserted++
+		case Delete:
+			result.Deleted++
+		}
+	}
+
+	return result
+}
+
+// myers returns the shortest edit script turning a into b
+func myers(a, b []tokenizers.Token) []Edit {
+	n, m := len(a), len(b)
+	maxD := n + m
+	offset := maxD
+
+	// v[k+offset] is the furthest x reached on diagonal k; trace keeps the
+	// diagonals -d..d of v at the start of each step d for backtracking
+	v := make([]int, 2*maxD+2)
+	var trace [][]int
+
+	for d := 0; d <= maxD; d++ {
+		trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[k-1+offset] < v[k+1+offset]) {
+				x = v[k+1+offset]
+			} else {
+				x = v[k-1+offset] + 1
+			}
+			y := x - k
+
+			for x < n && y < m && sameToken(a[x], b[y]) {
+				x++
+				y++
+			}
+
+			v[k+offset] = x
+			if x >= n && y >= m {
+				return backtrack(a, b, trace, d)
+			}
+		}
+	}
+
+	return nil
+}
+
+// backtrack walks the saved traces from the end to recover the edit script
+func backtrack(a, b []tokenizers.Token, trace [][]int, d int) []Edit {
+	edits := make([]Edit, 0, len(a)+len(b))
+	x, y := len(a), len(b)
+
+	for ; d > 0; d-- {
+		k := x - y
+		prev := trace[d]
+
+		var prevK int
+		if k == -d || (k != d && prev[k-1+d] < prev[k+1+d]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+
+		prevX := prev[prevK+d]
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			x--
+			y--
+			edits = append(edits, Edit{Op: Equal, Token: b[y]})
+		}
+
+		if x == prevX {
+			y--
+			edits = append(edits, Edit{Op: Insert, Token: b[y]})
+		} else {
+			x--
+			edits = append(edits, Edit{Op: Delete, Token: a[x]})
+		}
+	}
+
+	for x > 0 && y > 0 {
+		x--
+		y--
+		edits = append(edits, Edit{Op: Equal, Token: b[y]})
+	}
+
+	// Reverse into forward order
+	for i, j := 0, len(edits)-1; i < j; i, j = i+1, j-1 {
+		edits[i], edits[j] = edits[j], edits[i]
+	}
+
+	return edits
+}
+
+// sameToken reports whether two tokens are the same, by ID when available
+func sameToken(a, b tokenizers.Token) bool {
+	if a.ID >= 0 && b.ID >= 0 {
+		return a.ID == b.ID
+	}
+	return a.Text == b.Text
+}
@@ -0,0 +1,132 @@
+package diff
+
+import (
+	"math/rand"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/spandigital/token-visualizer/internal/tokenizers"
+)
+
+// tokenize makes a result with one token per letter of s, with the letter as its ID
+func tokenize(s string) *tokenizers.TokenizationResult {
+	r := &tokenizers.TokenizationResult{Text: s, TotalCount: len(s)}
+	for i := range len(s) {
+		r.Tokens = append(r.Tokens, tokenizers.Token{Text: s[i : i+1], ID: int(s[i]), Start: i, End: i + 1})
+	}
+	return r
+}
+
+// lcs returns the length of the longest common subsequence of a and b
+func lcs(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for i := range len(a) {
+		next := make([]int, len(b)+1)
+		for j := range len(b) {
+			if a[i] == b[j] {
+				next[j+1] = prev[j] + 1
+			} else {
+				next[j+1] = max(prev[j+1], next[j])
+			}
+		}
+		prev = next
+	}
+	return prev[len(b)]
+}
+
+// checkEdits checks that the edits turn before into after and that there are no
+// more of them than the shortest edit script needs
+func checkEdits(t *testing.T, before, after string) {
+	t.Helper()
+	result := Compute("m", tokenize(before), tokenize(after))
+
+	var gotOld, gotNew strings.Builder
+	inserted, deleted := 0, 0
+	for _, edit := range result.Edits {
+		switch edit.Op {
+		case Equal:
+			gotOld.WriteString(edit.Token.Text)
+			gotNew.WriteString(edit.Token.Text)
+		case Delete:
+			gotOld.WriteString(edit.Token.Text)
+			deleted++
+		case Insert:
+			gotNew.WriteString(edit.Token.Text)
+			inserted++
+		}
+	}
+	if gotOld.String() != before || gotNew.String() != after {
+		t.Fatalf("edits of %q -> %q rebuild %q -> %q", before, after, gotOld.String(), gotNew.String())
+	}
+	if inserted != result.Inserted || deleted != result.Deleted {
+		t.Errorf("counted %d inserted and %d deleted, edits have %d and %d", result.Inserted, result.Deleted, inserted, deleted)
+	}
+
+	common := lcs(before, after)
+	if want := len(before) + len(after) - 2*common; inserted+deleted != want {
+		t.Errorf("%q -> %q takes %d edits, want the minimum %d", before, after, inserted+deleted, want)
+	}
+}
+
+func TestComputeMinimal(t *testing.T) {
+	tests := []struct {
+		name          string
+		before, after string
+	}{
+		{"identical", "abc", "abc"},
+		{"both empty", "", ""},
+		{"from empty", "", "abc"},
+		{"to empty", "abc", ""},
+		{"insert in the middle", "abcd", "abxcd"},
+		{"delete in the middle", "abxcd", "abcd"},
+		{"replace", "abc", "axc"},
+		{"shared prefix and suffix", "aaaXbbb", "aaaYYbbb"},
+		{"repeated tokens", "aaaa", "aa"},
+		{"moved block", "abcdef", "defabc"},
+		{"reversed", "abcde", "edcba"},
+		{"classic example", "abcabba", "cbabac"},
+		{"nothing in common", "abc", "xyz"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkEdits(t, tt.before, tt.after)
+		})
+	}
+
+	t.Run("random", func(t *testing.T) {
+		random := rand.New(rand.NewSource(1))
+		text := func() string {
+			b := make([]byte, random.Intn(12))
+			for i := range b {
+				b[i] = "abc"[random.Intn(3)]
+			}
+			return string(b)
+		}
+		for range 500 {
+			checkEdits(t, text(), text())
+		}
+	})
+}
+
+func TestHunks(t *testing.T) {
+	result := Compute("m", tokenize("abcdef"), tokenize("aXcdYf"))
+
+	var hunks []string
+	for _, hunk := range result.Hunks() {
+		var deleted, inserted []string
+		for _, token := range hunk.Deleted {
+			deleted = append(deleted, token.Text)
+		}
+		for _, token := range hunk.Inserted {
+			inserted = append(inserted, token.Text)
+		}
+		hunks = append(hunks, strings.Join(deleted, "")+">"+strings.Join(inserted, ""))
+	}
+	if want := []string{"b>X", "e>Y"}; !slices.Equal(hunks, want) {
+		t.Errorf("hunks = %q, want %q", hunks, want)
+	}
+	if result.Delta() != 0 {
+		t.Errorf("delta = %d, want 0", result.Delta())
+	}
+}
@@ -0,0 +1,173 @@
+package lsp
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/spandigital/token-visualizer/internal/tokenizers"
+)
+
+// Hint scopes
+const (
+	ScopeParagraph = "paragraph"
+	ScopeSection   = "section"
+)
+
+// block is a paragraph or section of a document, as byte offsets
+type block struct {
+	start int
+	end   int
+	count int
+}
+
+// document is an open text document and its tokenization
+type document struct {
+	uri        string
+	version    int
+	text       string
+	lineStarts []int
+	result     *tokenizers.TokenizationResult
+	blocks     []block
+}
+
+// newDocument creates a document and indexes its line starts
+func newDocument(uri string, version int, text string) *document {
+	d := &document{
+		uri:     uri,
+		version: version,
+		text:    text,
+	}
+
+	d.lineStarts = []int{0}
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\n' {
+			d.lineStarts = append(d.lineStarts, i+1)
+		}
+	}
+
+	return d
+}
+
+// position converts a byte offset into an LSP position (UTF-16 code units)
+func (d *document) position(offset int) position {
+	offset = max(0, min(offset, len(d.text)))
+
+	line := sort.Search(len(d.lineStarts), func(i int) bool {
+		return d.lineStarts[i] > offset
+	}) - 1
+
+	character := 0
+	for _, r := range d.text[d.lineStarts[line]:offset] {
+		character += utf16Len(r)
+	}
+
+	return position{Line: line, Character: character}
+}
+
+// offset converts an LSP position (UTF-16 code units) into a byte offset
+func (d *document) offset(pos position) int {
+	if pos.Line < 0 {
+		return 0
+	}
+	if pos.Line >= len(d.lineStarts) {
+		return len(d.text)
+	}
+
+	offset := d.lineStarts[pos.Line]
+	character := 0
+	for character < pos.Character && offset < len(d.text) {
+		r, size := utf8.DecodeRuneInString(d.text[offset:])
+		if r == '\n' {
+			break
+		}
+		character += utf16Len(r)
+		offset += size
+	}
+
+	return offset
+}
+
+// lineEnd returns the byte offset of the end of the line containing offset, excluding the newline
+func (d *document) lineEnd(offset int) int {
+	if i := strings.IndexByte(d.text[offset:], '\n'); i >= 0 {
+		return offset + i
+	}
+	return len(d.text)
+}
+
+// splitBlocks splits the document into paragraphs (runs of non-blank lines) or
+// sections (text between markdown headings)
+func (d *document) splitBlocks(scope string) []block {
+	var blocks []block
+	start, end := -1, 0
+	inFence := false
+
+	flush := func() {
+		if start >= 0 {
+			blocks = append(blocks, block{start: start, end: end})
+			start = -1
+		}
+	}
+
+	for _, lineStart := range d.lineStarts {
+		lineEnd := d.lineEnd(lineStart)
+		line := d.text[lineStart:lineEnd]
+
+		if strings.TrimSpace(line) == "" {
+			if scope != ScopeSection {
+				flush()
+			}
+			continue
+		}
+
+		if isFence(line) {
+			inFence = !inFence
+		} else if scope == ScopeSection && !inFence && isHeading(line) {
+			flush()
+		}
+
+		if start < 0 {
+			start = lineStart
+		}
+		end = lineEnd
+	}
+	flush()
+
+	return blocks
+}
+
+// tokensInRange returns the indices of tokens overlapping the byte range [start, end)
+func (d *document) tokensInRange(start, end int) (int, int) {
+	tokens := d.result.Tokens
+	first := sort.Search(len(tokens), func(i int) bool {
+		return tokens[i].End > start
+	})
+	last := first
+	for last < len(tokens) && tokens[last].Start < end {
+		last++
+	}
+	return first, last
+}
+
+// isHeading reports whether a line is a markdown ATX heading
+func isHeading(line string) bool {
+	trimmed := strings.TrimLeft(line, "#")
+	level := len(line) - len(trimmed)
+	return level >= 1 && level <= 6 && (trimmed == "" || trimmed[0] == ' ' || trimmed[0] == '\t')
+}
+
+// isFence reports whether a line opens or closes a markdown fenced code block
+func isFence(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~")
+}
+
+// utf16Len returns the number of UTF-16 code units needed for r
+func utf16Len(r rune) int {
+	if n := utf16.RuneLen(r); n > 0 {
+		return n
+	}
+	return 1
+}
@@ -0,0 +1,482 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spandigital/token-visualizer/internal/tokenizers"
+)
+
+// Config holds the settings for a Server
+type Config struct {
+	Model     string               // Model name shown to the user
+	Tokenizer tokenizers.Tokenizer // Warm tokenizer used for every document
+	Budget    int                  // Token budget per document (0 disables the diagnostic)
+	HintScope string               // Inlay hint granularity: paragraph or section
+}
+
+// Server is a Language Server Protocol server that reports token counts for open documents
+type Server struct {
+	model     string
+	tokenizer tokenizers.Tokenizer
+	budget    int
+	hintScope string
+	documents map[string]*document
+	conn      *conn
+	shutdown  bool
+}
+
+// errExit is returned by handle when the client sends the exit notification
+var errExit = errors.New("exit")
+
+// New creates a new language server
+func New(cfg Config) *Server {
+	hintScope := cfg.HintScope
+	if hintScope == "" {
+		hintScope = ScopeParagraph
+	}
+
+	return &Server{
+		model:     cfg.Model,
+		tokenizer: cfg.Tokenizer,
+		budget:    cfg.Budget,
+		hintScope: hintScope,
+		documents: make(map[string]*document),
+	}
+}
+
+// readResult is a message read from the client, or the error reading it
+type readResult struct {
+	msg *message
+	err error
+}
+
+// Serve reads requests from r and writes responses to w until the client
+// exits or ctx is canceled
+func (s *Server) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	s.conn = newConn(r, w)
+
+	// Reads block until the client sends something, so they run on their own
+	// to let cancellation stop the server in between
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	reads := make(chan readResult)
+	go func() {
+		for {
+			msg, err := s.conn.read()
+			select {
+			case reads <- readResult{msg: msg, err: err}:
+			case <-ctx.Done():
+				return
+			}
+			var parseErr *parseError
+			if err != nil && !errors.As(err, &parseErr) {
+				return
+			}
+		}
+	}()
+
+	for {
+		var read readResult
+		select {
+		case <-ctx.Done():
+			return nil
+		case read = <-reads:
+		}
+
+		msg, err := read.msg, read.err
+		if err != nil {
+			var parseErr *parseError
+			if errors.As(err, &parseErr) {
+				if err := s.conn.replyError(nil, codeParseError, err.Error()); err != nil {
+					return err
+				}
+				continue
+			}
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+
+		if err := s.handle(ctx, msg); err != nil {
+			if errors.Is(err, errExit) {
+				if !s.shutdown {
+					return fmt.Errorf("exit received before shutdown")
+				}
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// handle dispatches a single message
+func (s *Server) handle(ctx context.Context, msg *message) error {
+	var result any
+	var err error
+
+	switch msg.Method {
+	case "initialize":
+		result, err = s.initialize(msg.Params)
+	case "initialized":
+		return nil
+	case "shutdown":
+		s.shutdown = true
+	case "exit":
+		return errExit
+	case "textDocument/didOpen":
+		return s.didOpen(ctx, msg.Params)
+	case "textDocument/didChange":
+		return s.didChange(ctx, msg.Params)
+	case "textDocument/didClose":
+		return s.didClose(msg.Params)
+	case "textDocument/hover":
+		result, err = s.hover(msg.Params)
+	case "textDocument/inlayHint":
+		result, err = s.inlayHints(msg.Params)
+	case "textDocument/codeLens":
+		result, err = s.codeLenses(msg.Params)
+	default:
+		if msg.ID == nil {
+			// Unknown notifications are ignored
+			return nil
+		}
+		return s.conn.replyError(msg.ID, codeMethodNotFound, fmt.Sprintf("method not found: %s", msg.Method))
+	}
+
+	if msg.ID == nil {
+		return err
+	}
+
+	if err != nil {
+		var paramsErr *invalidParamsError
+		if errors.As(err, &paramsErr) {
+			return s.conn.replyError(msg.ID, codeInvalidParams, err.Error())
+		}
+		return s.conn.replyError(msg.ID, codeInternalError, err.Error())
+	}
+
+	return s.conn.reply(msg.ID, result)
+}
+
+func (s *Server) initialize(params json.RawMessage) (any, error) {
+	var p initializeParams
+	if err := unmarshalParams(params, &p); err != nil {
+		return nil, err
+	}
+
+	if len(p.InitializationOptions) > 0 && string(p.InitializationOptions) != "null" {
+		var options initializationOptions
+		if err := json.Unmarshal(p.InitializationOptions, &options); err != nil {
+			return nil, &invalidParamsError{err: err}
+		}
+		if options.Budget != nil {
+			s.budget = *options.Budget
+		}
+		if options.HintScope == ScopeParagraph || options.HintScope == ScopeSection {
+			s.hintScope = options.HintScope
+		}
+	}
+
+	return initializeResult{
+		Capabilities: serverCapabilities{
+			TextDocumentSync:  syncFull,
+			HoverProvider:     true,
+			InlayHintProvider: true,
+			CodeLensProvider:  codeLensOptions{ResolveProvider: false},
+		},
+		ServerInfo: serverInfo{Name: "token-visualizer"},
+	}, nil
+}
+
+func (s *Server) didOpen(ctx context.Context, params json.RawMessage) error {
+	var p didOpenParams
+	if err := unmarshalParams(params, &p); err != nil {
+		return nil
+	}
+
+	return s.update(ctx, newDocument(p.TextDocument.URI, p.TextDocument.Version, p.TextDocument.Text))
+}
+
+func (s *Server) didChange(ctx context.Context, params json.RawMessage) error {
+	var p didChangeParams
+	if err := unmarshalParams(params, &p); err != nil || len(p.ContentChanges) == 0 {
+		return nil
+	}
+
+	// Full sync: the last change holds the complete text
+	text := p.ContentChanges[len(p.ContentChanges)-1].Text
+	return s.update(ctx, newDocument(p.TextDocument.URI, p.TextDocument.Version, text))
+}
+
+func (s *Server) didClose(params json.RawMessage) error {
+	var p didCloseParams
+	if err := unmarshalParams(params, &p); err != nil {
+		return nil
+	}
+
+	delete(s.documents, p.TextDocument.URI)
+	return s.conn.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{
+		URI:         p.TextDocument.URI,
+		Diagnostics: []diagnostic{},
+	})
+}
+
+// update tokenizes a document, stores it and publishes its status and
+// diagnostics. A document that fails to tokenize is stored without a result,
+// so requests don't answer with the tokens of an older version.
+func (s *Server) update(ctx context.Context, doc *document) error {
+	s.documents[doc.uri] = doc
+
+	result, err := s.tokenizer.Encode(ctx, doc.text)
+	if err != nil {
+		return s.logError(doc, fmt.Errorf("tokenization failed for %s: %w", doc.uri, err))
+	}
+
+	blocks := doc.splitBlocks(s.hintScope)
+	for i := range blocks {
+		b := &blocks[i]
+		if s.tokenizer.SupportsTokenIDs() {
+			b.count = countTokens(result.Tokens, b.start, b.end)
+			continue
+		}
+		// Without individual tokens, blocks are counted on their own
+		count, err := s.tokenizer.CountTokens(ctx, doc.text[b.start:b.end])
+		if err != nil {
+			return s.logError(doc, fmt.Errorf("token counting failed for %s: %w", doc.uri, err))
+		}
+		b.count = count
+	}
+
+	doc.result = result
+	doc.blocks = blocks
+
+	if err := s.conn.notify("tokenVisualizer/status", statusParams{
+		URI:    doc.uri,
+		Model:  s.model,
+		Tokens: result.TotalCount,
+		Budget: s.budget,
+	}); err != nil {
+		return err
+	}
+
+	return s.conn.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{
+		URI:         doc.uri,
+		Version:     doc.version,
+		Diagnostics: s.diagnostics(doc),
+	})
+}
+
+// logError logs an error that left a document without tokens, and clears its diagnostics
+func (s *Server) logError(doc *document, err error) error {
+	if err := s.conn.notify("window/logMessage", map[string]any{
+		"type":    1,
+		"message": err.Error(),
+	}); err != nil {
+		return err
+	}
+
+	return s.conn.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{
+		URI:         doc.uri,
+		Version:     doc.version,
+		Diagnostics: []diagnostic{},
+	})
+}
+
+// countTokens returns the number of tokens starting in the byte range [start,
+// end), leaving out tokens that cover no text
+func countTokens(tokens []tokenizers.Token, start, end int) int {
+	first := sort.Search(len(tokens), func(i int) bool {
+		return tokens[i].Start >= start
+	})
+
+	count := 0
+	for i := first; i < len(tokens) && tokens[i].Start < end; i++ {
+		if tokens[i].End > tokens[i].Start {
+			count++
+		}
+	}
+	return count
+}
+
+// diagnostics reports a warning from the first token over budget to the end of the document
+func (s *Server) diagnostics(doc *document) []diagnostic {
+	total := doc.result.TotalCount
+	if s.budget <= 0 || total <= s.budget {
+		return []diagnostic{}
+	}
+
+	start := 0
+	if len(doc.result.Tokens) == total {
+		start = doc.result.Tokens[s.budget].Start
+	}
+
+	return []diagnostic{{
+		Range: lspRange{
+			Start: doc.position(start),
+			End:   doc.position(len(doc.text)),
+		},
+		Severity: severityWarning,
+		Source:   "token-visualizer",
+		Message: fmt.Sprintf("Document uses %d tokens (%s), exceeding the budget of %d by %d",
+			total, s.model, s.budget, total-s.budget),
+	}}
+}
+
+func (s *Server) hover(params json.RawMessage) (any, error) {
+	var p textDocumentPositionParams
+	if err := unmarshalParams(params, &p); err != nil {
+		return nil, err
+	}
+
+	doc, ok := s.documents[p.TextDocument.URI]
+	if !ok || doc.result == nil || !s.tokenizer.SupportsTokenIDs() {
+		return nil, nil
+	}
+
+	if p.Position.Line < 0 || p.Position.Line >= len(doc.lineStarts) {
+		return nil, nil
+	}
+
+	offset := doc.offset(p.Position)
+	lineStart := doc.lineStarts[p.Position.Line]
+	first, last := doc.tokensInRange(lineStart, max(doc.lineEnd(lineStart), lineStart+1))
+	if first >= last {
+		return nil, nil
+	}
+
+	var content strings.Builder
+	content.WriteString(fmt.Sprintf("**%s** — %d tokens on this line\n\n", s.model, last-first))
+
+	current := -1
+	for i := first; i < last; i++ {
+		token := doc.result.Tokens[i]
+		if token.Start <= offset && offset < token.End {
+			current = i
+		}
+	}
+
+	var line strings.Builder
+	for i := first; i < last; i++ {
+		text := strings.ReplaceAll(doc.result.Tokens[i].Text, "\n", "\\n")
+		if i == current {
+			line.WriteString("[" + text + "]")
+		} else {
+			line.WriteString(text)
+		}
+		if i < last-1 {
+			line.WriteString("|")
+		}
+	}
+	fence := backticks(line.String(), 3)
+	content.WriteString(fence + "text\n" + line.String() + "\n" + fence + "\n")
+
+	var hoverRange *lspRange
+	if current >= 0 {
+		token := doc.result.Tokens[current]
+		quoted := strconv.Quote(token.Text)
+		code := backticks(quoted, 1)
+		content.WriteString(fmt.Sprintf("\nToken %d of %d: %s%s%s ID %d, bytes %d-%d\n",
+			current+1, doc.result.TotalCount, code, quoted, code, token.ID, token.Start, token.End))
+		hoverRange = &lspRange{
+			Start: doc.position(token.Start),
+			End:   doc.position(token.End),
+		}
+	}
+
+	return hover{
+		Contents: markupContent{Kind: "markdown", Value: content.String()},
+		Range:    hoverRange,
+	}, nil
+}
+
+// backticks returns a run of at least n backticks that is longer than any run
+// in text, so text can be put in a code block or span delimited by it
+func backticks(text string, n int) string {
+	longest, run := 0, 0
+	for _, r := range text {
+		if r == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	return strings.Repeat("`", max(n, longest+1))
+}
+
+func (s *Server) inlayHints(params json.RawMessage) (any, error) {
+	var p inlayHintParams
+	if err := unmarshalParams(params, &p); err != nil {
+		return nil, err
+	}
+
+	hints := []inlayHint{}
+	doc, ok := s.documents[p.TextDocument.URI]
+	if !ok {
+		return hints, nil
+	}
+
+	for _, b := range doc.blocks {
+		pos := doc.position(b.end)
+		if pos.Line < p.Range.Start.Line || pos.Line > p.Range.End.Line {
+			continue
+		}
+		hints = append(hints, inlayHint{
+			Position:    pos,
+			Label:       fmt.Sprintf("%d tokens", b.count),
+			PaddingLeft: true,
+		})
+	}
+
+	return hints, nil
+}
+
+func (s *Server) codeLenses(params json.RawMessage) (any, error) {
+	var p codeLensParams
+	if err := unmarshalParams(params, &p); err != nil {
+		return nil, err
+	}
+
+	doc, ok := s.documents[p.TextDocument.URI]
+	if !ok || doc.result == nil {
+		return []codeLens{}, nil
+	}
+
+	title := fmt.Sprintf("%d tokens (%s)", doc.result.TotalCount, s.model)
+	if s.budget > 0 {
+		title = fmt.Sprintf("%d / %d tokens (%s)", doc.result.TotalCount, s.budget, s.model)
+	}
+
+	return []codeLens{{
+		Range:   lspRange{Start: position{}, End: position{}},
+		Command: command{Title: title},
+	}}, nil
+}
+
+// unmarshalParams decodes request parameters
+func unmarshalParams(params json.RawMessage, v any) error {
+	if len(params) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(params, v); err != nil {
+		return &invalidParamsError{err: err}
+	}
+	return nil
+}
+
+// invalidParamsError reports request parameters that could not be decoded
+type invalidParamsError struct {
+	err error
+}
+
+func (e *invalidParamsError) Error() string {
+	return fmt.Sprintf("invalid params: %v", e.err)
+}
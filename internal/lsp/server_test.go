@@ -0,0 +1,162 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spandigital/token-visualizer/internal/tokenizers"
+)
+
+// byteTokenizer makes every byte a token, counts the calls to CountTokens and
+// fails to encode while failing is set
+type byteTokenizer struct {
+	counts  int
+	failing bool
+}
+
+func (t *byteTokenizer) Name() string { return "bytes" }
+
+func (t *byteTokenizer) Encode(ctx context.Context, text string) (*tokenizers.TokenizationResult, error) {
+	if t.failing {
+		return nil, errors.New("tokenizer unavailable")
+	}
+	tokens := make([]tokenizers.Token, len(text))
+	for i := range len(text) {
+		tokens[i] = tokenizers.Token{Text: text[i : i+1], ID: int(text[i]), Start: i, End: i + 1}
+	}
+	return &tokenizers.TokenizationResult{Tokens: tokens, TotalCount: len(tokens), Text: text, Model: "bytes"}, nil
+}
+
+func (t *byteTokenizer) CountTokens(ctx context.Context, text string) (int, error) {
+	t.counts++
+	return len(text), nil
+}
+
+func (t *byteTokenizer) SupportsTokenIDs() bool { return true }
+
+func (t *byteTokenizer) SupportsDecoding() bool { return false }
+
+func (t *byteTokenizer) Decode(ctx context.Context, ids []int) (string, error) {
+	return "", errors.New("decoding not supported")
+}
+
+// newTestServer returns a server using tokenizer, with its notifications discarded
+func newTestServer(tokenizer tokenizers.Tokenizer) *Server {
+	s := New(Config{Model: "bytes", Tokenizer: tokenizer})
+	s.conn = newConn(nil, io.Discard)
+	return s
+}
+
+func TestUpdateBlockCounts(t *testing.T) {
+	tokenizer := &byteTokenizer{}
+	s := newTestServer(tokenizer)
+
+	if err := s.update(context.Background(), newDocument("file:///a.md", 1, "one\n\ntwo two\nend\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	blocks := s.documents["file:///a.md"].blocks
+	want := []block{{start: 0, end: 3, count: 3}, {start: 5, end: 16, count: 11}}
+	if len(blocks) != len(want) {
+		t.Fatalf("blocks = %+v, want %+v", blocks, want)
+	}
+	for i := range want {
+		if blocks[i] != want[i] {
+			t.Errorf("block %d = %+v, want %+v", i, blocks[i], want[i])
+		}
+	}
+	if tokenizer.counts != 0 {
+		t.Errorf("CountTokens called %d times, want the counts taken from the tokens", tokenizer.counts)
+	}
+}
+
+func TestUpdateEncodeError(t *testing.T) {
+	tokenizer := &byteTokenizer{}
+	s := newTestServer(tokenizer)
+	ctx := context.Background()
+
+	if err := s.update(ctx, newDocument("file:///a.md", 1, "old")); err != nil {
+		t.Fatal(err)
+	}
+	tokenizer.failing = true
+	if err := s.update(ctx, newDocument("file:///a.md", 2, "new text")); err != nil {
+		t.Fatal(err)
+	}
+
+	doc := s.documents["file:///a.md"]
+	if doc.text != "new text" || doc.version != 2 {
+		t.Errorf("stored version %d %q, want version 2 with the new text", doc.version, doc.text)
+	}
+	if doc.result != nil || doc.blocks != nil {
+		t.Errorf("stored tokens %+v and blocks %+v, want none", doc.result, doc.blocks)
+	}
+}
+
+func TestServeCanceled(t *testing.T) {
+	// A client that never sends anything
+	r, w := io.Pipe()
+	t.Cleanup(func() { _ = w.Close() })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- New(Config{Tokenizer: &byteTokenizer{}}).Serve(ctx, r, io.Discard)
+	}()
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Serve = %v, want nil", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Serve didn't return after the context was canceled")
+	}
+}
+
+// wholeTokenizer makes each text a single token
+type wholeTokenizer struct{ byteTokenizer }
+
+func (t *wholeTokenizer) Encode(ctx context.Context, text string) (*tokenizers.TokenizationResult, error) {
+	tokens := []tokenizers.Token{{Text: text, ID: 1, Start: 0, End: len(text)}}
+	return &tokenizers.TokenizationResult{Tokens: tokens, TotalCount: 1, Text: text, Model: "whole"}, nil
+}
+
+func TestHoverBackticks(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		fence string
+		code  string
+	}{
+		{"no backticks", "plain", "```", "`"},
+		{"single backtick", "a`b", "```", "``"},
+		{"code fence", "```go", "````", "````"},
+		{"long run", "`````", "``````", "``````"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(&wholeTokenizer{})
+			if err := s.update(context.Background(), newDocument("file:///a.md", 1, tt.text)); err != nil {
+				t.Fatal(err)
+			}
+			result, err := s.hover(json.RawMessage(`{"textDocument":{"uri":"file:///a.md"},"position":{"line":0,"character":0}}`))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := result.(hover).Contents.Value
+			block := tt.fence + "text\n[" + tt.text + "]\n" + tt.fence + "\n"
+			span := tt.code + strconv.Quote(tt.text) + tt.code + " ID 1"
+			if !strings.Contains(got, block) || !strings.Contains(got, span) {
+				t.Errorf("hover = %q, want the block %q and the span %q", got, block, span)
+			}
+		})
+	}
+}
@@ -0,0 +1,258 @@
+package metrics
+
+import (
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/spandigital/token-visualizer/internal/tokenizers"
+)
+
+// Character classes
+const (
+	ClassLetters     = "Letters"
+	ClassDigits      = "Digits"
+	ClassWhitespace  = "Whitespace"
+	ClassPunctuation = "Punctuation"
+	ClassSymbols     = "Symbols"
+	ClassOther       = "Other"
+)
+
+// Script names that are not Unicode scripts
+const (
+	ScriptEmoji  = "Emoji"
+	ScriptCommon = "Common"
+	ScriptOther  = "Other"
+)
+
+// scripts are the Unicode scripts reported individually, in display order
+var scripts = []string{
+	"Latin", "Cyrillic", "Greek", "Armenian", "Georgian", "Hebrew", "Arabic",
+	"Devanagari", "Bengali", "Tamil", "Telugu", "Thai", "Ethiopic",
+	"Han", "Hiragana", "Katakana", "Hangul",
+}
+
+// classes are the character classes in display order
+var classes = []string{ClassLetters, ClassDigits, ClassWhitespace, ClassPunctuation, ClassSymbols, ClassOther}
+
+// Stats holds the size of a piece of text and the tokens spent on it
+type Stats struct {
+	Chars  int
+	Bytes  int
+	Words  int
+	Tokens float64 // Tokens attributed to this text; tokens spanning several groups are shared
+}
+
+// CharsPerToken returns the average number of characters per token
+func (s Stats) CharsPerToken() float64 {
+	return ratio(float64(s.Chars), s.Tokens)
+}
+
+// BytesPerToken returns the average number of UTF-8 bytes per token
+func (s Stats) BytesPerToken() float64 {
+	return ratio(float64(s.Bytes), s.Tokens)
+}
+
+// WordsPerToken returns the average number of words per token
+func (s Stats) WordsPerToken() float64 {
+	return ratio(float64(s.Words), s.Tokens)
+}
+
+// Group is the stats for one script or character class
+type Group struct {
+	Name string
+	Stats
+}
+
+// Report is the tokenization efficiency of one model on a text
+type Report struct {
+	Model   string
+	Overall Stats
+	Scripts []Group // Empty if the model has no per-token offsets (e.g. Claude)
+	Classes []Group // Empty if the model has no per-token offsets (e.g. Claude)
+}
+
+// char is a single character of the text with its groups
+type char struct {
+	start  int
+	size   int
+	script string
+	class  string
+	word   bool // True for the first character of a word
+	tokens float64
+}
+
+// Compute measures how efficiently a result encodes its text, overall and per
+// script and character class. Words are runs of letters, marks and digits.
+// Each token is shared evenly among the characters it overlaps, so a token
+// spanning a space and a word counts half towards whitespace and half towards
+// letters.
+func Compute(result *tokenizers.TokenizationResult) *Report {
+	chars := splitChars(result.Text)
+
+	report := &Report{Model: result.Model}
+	report.Overall.Tokens = float64(result.TotalCount)
+	report.Overall.Bytes = len(result.Text)
+	report.Overall.Chars = len(chars)
+	for _, c := range chars {
+		if c.word {
+			report.Overall.Words++
+		}
+	}
+
+	if len(result.Tokens) == 0 || result.Tokens[0].ID < 0 || len(chars) == 0 {
+		return report
+	}
+
+	// charAt maps each byte offset to the index of the character containing it
+	charAt := make([]int, len(result.Text)+1)
+	for i, c := range chars {
+		for b := c.start; b < c.start+c.size; b++ {
+			charAt[b] = i
+		}
+	}
+	charAt[len(result.Text)] = len(chars)
+
+	for _, token := range result.Tokens {
+		start := min(token.Start, len(result.Text))
+		end := min(token.End, len(result.Text))
+		if end <= start {
+			continue
+		}
+
+		first, last := charAt[start], charAt[end-1]
+		share := 1 / float64(last-first+1)
+		for i := first; i <= last; i++ {
+			chars[i].tokens += share
+		}
+	}
+
+	scriptStats := make(map[string]*Stats)
+	classStats := make(map[string]*Stats)
+	for _, c := range chars {
+		for _, stats := range []*Stats{statsFor(scriptStats, c.script), statsFor(classStats, c.class)} {
+			stats.Chars++
+			stats.Bytes += c.size
+			stats.Tokens += c.tokens
+		}
+		if c.word {
+			scriptStats[c.script].Words++
+			classStats[c.class].Words++
+		}
+	}
+
+	report.Scripts = groups(scriptStats, append(append([]string{}, scripts...), ScriptEmoji, ScriptCommon, ScriptOther))
+	report.Classes = groups(classStats, classes)
+
+	return report
+}
+
+// splitChars classifies every character of the text
+func splitChars(text string) []char {
+	var chars []char
+	inWord := false
+	previousScript := ScriptCommon
+
+	for offset := 0; offset < len(text); {
+		r, size := utf8.DecodeRuneInString(text[offset:])
+		c := char{
+			start:  offset,
+			size:   size,
+			script: scriptOf(r),
+			class:  classOf(r),
+		}
+		offset += size
+
+		// Combining marks take the script of the character they modify
+		if c.script == "Inherited" {
+			c.script = previousScript
+		}
+		previousScript = c.script
+
+		wordChar := unicode.IsLetter(r) || unicode.IsMark(r) || unicode.IsDigit(r)
+		c.word = wordChar && !inWord
+		inWord = wordChar
+
+		chars = append(chars, c)
+	}
+
+	return chars
+}
+
+// scriptOf returns the reported script of a character
+func scriptOf(r rune) string {
+	if isEmoji(r) {
+		return ScriptEmoji
+	}
+	for _, name := range scripts {
+		if unicode.Is(unicode.Scripts[name], r) {
+			return name
+		}
+	}
+	if unicode.Is(unicode.Inherited, r) {
+		return "Inherited"
+	}
+	if unicode.Is(unicode.Common, r) {
+		return ScriptCommon
+	}
+	return ScriptOther
+}
+
+// classOf returns the character class of a character
+func classOf(r rune) string {
+	switch {
+	case unicode.IsLetter(r) || unicode.IsMark(r):
+		return ClassLetters
+	case unicode.IsDigit(r):
+		return ClassDigits
+	case unicode.IsSpace(r):
+		return ClassWhitespace
+	case unicode.IsPunct(r):
+		return ClassPunctuation
+	case unicode.IsSymbol(r) || isEmoji(r):
+		return ClassSymbols
+	default:
+		return ClassOther
+	}
+}
+
+// isEmoji reports whether a character is an emoji or part of an emoji sequence
+func isEmoji(r rune) bool {
+	switch {
+	case r >= 0x1F000 && r <= 0x1FAFF: // Pictographs, emoticons, flags and skin tones
+		return true
+	case r >= 0x2600 && r <= 0x27BF: // Miscellaneous symbols and dingbats
+		return true
+	case r == 0x200D || r == 0xFE0F || (r >= 0xE0020 && r <= 0xE007F): // Joiner, variation selector and tags
+		return true
+	}
+	return false
+}
+
+// statsFor returns the stats for a group, creating them if needed
+func statsFor(stats map[string]*Stats, name string) *Stats {
+	if s, ok := stats[name]; ok {
+		return s
+	}
+	s := &Stats{}
+	stats[name] = s
+	return s
+}
+
+// groups returns the non-empty groups in the given order
+func groups(stats map[string]*Stats, order []string) []Group {
+	var result []Group
+	for _, name := range order {
+		if s, ok := stats[name]; ok {
+			result = append(result, Group{Name: name, Stats: *s})
+		}
+	}
+	return result
+}
+
+// ratio divides a by b, returning 0 when b is 0
+func ratio(a, b float64) float64 {
+	if b == 0 {
+		return 0
+	}
+	return a / b
+}
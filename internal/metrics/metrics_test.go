@@ -0,0 +1,154 @@
+package metrics
+
+import (
+	"math"
+	"testing"
+
+	"github.com/spandigital/token-visualizer/internal/tokenizers"
+)
+
+// tokenize makes a result with one token per piece, with IDs counting up from 1
+func tokenize(pieces ...string) *tokenizers.TokenizationResult {
+	r := &tokenizers.TokenizationResult{Model: "m"}
+	for i, piece := range pieces {
+		start := len(r.Text)
+		r.Text += piece
+		r.Tokens = append(r.Tokens, tokenizers.Token{Text: piece, ID: i + 1, Start: start, End: len(r.Text)})
+	}
+	r.TotalCount = len(r.Tokens)
+	return r
+}
+
+// checkGroups compares groups with want, allowing for rounding in the token shares
+func checkGroups(t *testing.T, kind string, got, want []Group) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("%s = %+v, want %+v", kind, got, want)
+	}
+	for i := range want {
+		g, w := got[i], want[i]
+		if g.Name != w.Name || g.Chars != w.Chars || g.Bytes != w.Bytes || g.Words != w.Words || math.Abs(g.Tokens-w.Tokens) > 1e-9 {
+			t.Errorf("%s %d = %+v, want %+v", kind, i, g, w)
+		}
+	}
+}
+
+func TestScriptAndClass(t *testing.T) {
+	tests := []struct {
+		r      rune
+		script string
+		class  string
+	}{
+		{'a', "Latin", ClassLetters},
+		{'я', "Cyrillic", ClassLetters},
+		{'中', "Han", ClassLetters},
+		{'ア', "Katakana", ClassLetters},
+		{'\u0301', "Inherited", ClassLetters},
+		{'5', ScriptCommon, ClassDigits},
+		{'٣', "Arabic", ClassDigits},
+		{' ', ScriptCommon, ClassWhitespace},
+		{'\n', ScriptCommon, ClassWhitespace},
+		{',', ScriptCommon, ClassPunctuation},
+		{'+', ScriptCommon, ClassSymbols},
+		{'😀', ScriptEmoji, ClassSymbols},
+		{'\u200d', ScriptEmoji, ClassSymbols},
+		{'\x00', ScriptCommon, ClassOther},
+		{'ᚠ', ScriptOther, ClassLetters},
+	}
+	for _, tt := range tests {
+		if script, class := scriptOf(tt.r), classOf(tt.r); script != tt.script || class != tt.class {
+			t.Errorf("%q is %s %s, want %s %s", tt.r, script, class, tt.script, tt.class)
+		}
+	}
+}
+
+func TestCompute(t *testing.T) {
+	tests := []struct {
+		name    string
+		result  *tokenizers.TokenizationResult
+		overall Stats
+		scripts []Group
+		classes []Group
+	}{
+		{
+			// Tokens spanning a space and a word are shared among their characters
+			name:    "mixed scripts",
+			result:  tokenize("ab", " мир", " 12", "!"),
+			overall: Stats{Chars: 10, Bytes: 13, Words: 3, Tokens: 4},
+			scripts: []Group{
+				{"Latin", Stats{Chars: 2, Bytes: 2, Words: 1, Tokens: 1}},
+				{"Cyrillic", Stats{Chars: 3, Bytes: 6, Words: 1, Tokens: 0.75}},
+				{ScriptCommon, Stats{Chars: 5, Bytes: 5, Words: 1, Tokens: 0.25 + 1 + 1}},
+			},
+			classes: []Group{
+				{ClassLetters, Stats{Chars: 5, Bytes: 8, Words: 2, Tokens: 1.75}},
+				{ClassDigits, Stats{Chars: 2, Bytes: 2, Words: 1, Tokens: 2.0 / 3}},
+				{ClassWhitespace, Stats{Chars: 2, Bytes: 2, Tokens: 0.25 + 1.0/3}},
+				{ClassPunctuation, Stats{Chars: 1, Bytes: 1, Tokens: 1}},
+			},
+		},
+		{
+			// The combining accent takes the script of the "e" and stays in its word
+			name:    "combining mark and emoji",
+			result:  tokenize("e\u0301", "😀"),
+			overall: Stats{Chars: 3, Bytes: 7, Words: 1, Tokens: 2},
+			scripts: []Group{
+				{"Latin", Stats{Chars: 2, Bytes: 3, Words: 1, Tokens: 1}},
+				{ScriptEmoji, Stats{Chars: 1, Bytes: 4, Tokens: 1}},
+			},
+			classes: []Group{
+				{ClassLetters, Stats{Chars: 2, Bytes: 3, Words: 1, Tokens: 1}},
+				{ClassSymbols, Stats{Chars: 1, Bytes: 4, Tokens: 1}},
+			},
+		},
+		{
+			name:   "empty text",
+			result: tokenize(),
+		},
+		{
+			// Without token offsets only the overall stats are known
+			name: "no offsets",
+			result: &tokenizers.TokenizationResult{Model: "claude", Text: "hi there", TotalCount: 2,
+				Tokens: []tokenizers.Token{{ID: -1, Text: "hi there", End: 8}}},
+			overall: Stats{Chars: 8, Bytes: 8, Words: 2, Tokens: 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := Compute(tt.result)
+			if report.Overall != tt.overall {
+				t.Errorf("overall = %+v, want %+v", report.Overall, tt.overall)
+			}
+			checkGroups(t, "scripts", report.Scripts, tt.scripts)
+			checkGroups(t, "classes", report.Classes, tt.classes)
+		})
+	}
+}
+
+func TestRatios(t *testing.T) {
+	tests := []struct {
+		name  string
+		stats Stats
+		chars float64
+		bytes float64
+		words float64
+	}{
+		{"tokens", Stats{Chars: 10, Bytes: 12, Words: 2, Tokens: 4}, 2.5, 3, 0.5},
+		{"shared tokens", Stats{Chars: 3, Bytes: 3, Words: 1, Tokens: 1.5}, 2, 2, 1.0 / 1.5},
+		{"no tokens", Stats{Chars: 5, Bytes: 5, Words: 1}, 0, 0, 0},
+		{"empty", Stats{}, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.stats.CharsPerToken(); got != tt.chars {
+				t.Errorf("CharsPerToken = %v, want %v", got, tt.chars)
+			}
+			if got := tt.stats.BytesPerToken(); got != tt.bytes {
+				t.Errorf("BytesPerToken = %v, want %v", got, tt.bytes)
+			}
+			if got := tt.stats.WordsPerToken(); got != tt.words {
+				t.Errorf("WordsPerToken = %v, want %v", got, tt.words)
+			}
+		})
+	}
+}
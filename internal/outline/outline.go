@@ -0,0 +1,283 @@
+package outline
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spandigital/token-visualizer/internal/tokenizers"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	east "github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+// Kind is the kind of markdown structure a node covers
+type Kind int
+
+// Node kinds
+const (
+	KindDocument Kind = iota
+	KindSection
+	KindCodeBlock
+	KindTable
+	KindList
+)
+
+// Node is a part of a markdown document in its outline. A section runs from
+// its heading to the next heading of the same or a higher level, so sections
+// nest like the document outline, and code blocks, tables and lists are
+// children of the section they appear in.
+type Node struct {
+	Kind     Kind
+	Title    string // Heading text of a section, or language of a code block
+	Level    int    // Heading level of a section
+	Size     int    // Lines of a code block, rows of a table, or items of a list
+	Start    int    // Byte offset of the first byte
+	End      int    // Byte offset after the last byte
+	Tokens   []int  // Tokens starting in the node, for each model in Report.Models
+	Children []*Node
+}
+
+// Label describes the node the way it appears in a report
+func (n *Node) Label() string {
+	switch n.Kind {
+	case KindSection:
+		return strings.Repeat("#", n.Level) + " " + n.Title
+	case KindCodeBlock:
+		if n.Title != "" {
+			return fmt.Sprintf("Code block (%s, %s)", n.Title, pluralize(n.Size, "line"))
+		}
+		return fmt.Sprintf("Code block (%s)", pluralize(n.Size, "line"))
+	case KindTable:
+		return fmt.Sprintf("Table (%s)", pluralize(n.Size, "row"))
+	case KindList:
+		return fmt.Sprintf("List (%s)", pluralize(n.Size, "item"))
+	default:
+		return "Document"
+	}
+}
+
+// Report is the token budget of a markdown document, broken down by its outline
+type Report struct {
+	Models  []string
+	Skipped []string // Models without individual tokens (e.g. Claude), left out of the report
+	Root    *Node
+}
+
+// Compute breaks the tokens of each result down by the outline of the
+// markdown text. All results must be tokenizations of the same text. Each
+// token counts towards the nodes its first byte is in, and tokens that cover
+// no input bytes, such as BOS markers, are left out.
+func Compute(results []*tokenizers.TokenizationResult) *Report {
+	report := &Report{}
+	if len(results) == 0 {
+		return report
+	}
+	report.Root = Parse(results[0].Text)
+
+	for _, result := range results {
+		if len(result.Tokens) > 0 && result.Tokens[0].ID < 0 {
+			report.Skipped = append(report.Skipped, result.Model)
+			continue
+		}
+		report.Models = append(report.Models, result.Model)
+
+		starts := make([]int, 0, len(result.Tokens))
+		for _, token := range result.Tokens {
+			if token.End > token.Start {
+				starts = append(starts, token.Start)
+			}
+		}
+		sort.Ints(starts)
+		count(report.Root, starts)
+	}
+
+	return report
+}
+
+// count adds the number of token starts inside each node of the tree
+func count(node *Node, starts []int) {
+	first := sort.SearchInts(starts, node.Start)
+	last := sort.SearchInts(starts, node.End)
+	node.Tokens = append(node.Tokens, last-first)
+
+	for _, child := range node.Children {
+		count(child, starts)
+	}
+}
+
+// Parse builds the outline of a markdown text. Code blocks, tables and lists
+// are only broken out at the top level; one inside a list or block quote is
+// part of the enclosing node.
+func Parse(input string) *Node {
+	source := []byte(input)
+	md := goldmark.New(goldmark.WithExtensions(extension.GFM))
+	doc := md.Parser().Parse(text.NewReader(source))
+
+	root := &Node{Kind: KindDocument, End: len(source)}
+	open := []*Node{root}
+
+	// from is the start of the line after the previous block, where a block
+	// without lines is looked for
+	from := 0
+	for child := doc.FirstChild(); child != nil; child = child.NextSibling() {
+		node := block(child, source, from)
+		if node != nil && node.Kind != KindSection {
+			from = node.End + 1
+		} else if _, last, ok := span(child); ok {
+			from = lineEnd(source, last-1) + 1
+		}
+		if node == nil {
+			continue
+		}
+
+		if node.Kind == KindSection {
+			// Close the sections this heading ends
+			for len(open) > 1 && open[len(open)-1].Level >= node.Level {
+				open[len(open)-1].End = node.Start
+				open = open[:len(open)-1]
+			}
+			open[len(open)-1].Children = append(open[len(open)-1].Children, node)
+			open = append(open, node)
+			continue
+		}
+
+		open[len(open)-1].Children = append(open[len(open)-1].Children, node)
+	}
+
+	for _, section := range open[1:] {
+		section.End = len(source)
+	}
+
+	return root
+}
+
+// block returns the node for a top-level block, or nil if it's not broken
+// out in the outline or has no position in the source. An empty fenced code
+// block has no lines, so its fences are found after from.
+func block(child ast.Node, source []byte, from int) *Node {
+	first, last, ok := span(child)
+	if !ok {
+		if _, isCode := child.(*ast.FencedCodeBlock); isCode {
+			if start, end, found := fences(source, from); found {
+				return &Node{Kind: KindCodeBlock, Start: start, End: end}
+			}
+		}
+		return nil
+	}
+	start := lineStart(source, first)
+	end := lineEnd(source, last-1)
+
+	switch n := child.(type) {
+	case *ast.Heading:
+		var title strings.Builder
+		for i := 0; i < n.Lines().Len(); i++ {
+			line := n.Lines().At(i)
+			title.Write(line.Value(source))
+		}
+		return &Node{Kind: KindSection, Title: strings.Join(strings.Fields(title.String()), " "), Level: n.Level, Start: start}
+	case *ast.FencedCodeBlock:
+		// Take in the fences, which are outside the info and content lines
+		if n.Info == nil {
+			start = lineStart(source, start-1)
+		}
+		if end < len(source) && isFence(source[end+1:lineEnd(source, end+1)]) {
+			end = lineEnd(source, end+1)
+		}
+		return &Node{Kind: KindCodeBlock, Title: string(n.Language(source)), Size: n.Lines().Len(), Start: start, End: end}
+	case *ast.CodeBlock:
+		return &Node{Kind: KindCodeBlock, Size: n.Lines().Len(), Start: start, End: end}
+	case *east.Table:
+		return &Node{Kind: KindTable, Size: n.ChildCount() - 1, Start: start, End: end}
+	case *ast.List:
+		return &Node{Kind: KindList, Size: n.ChildCount(), Start: start, End: end}
+	default:
+		return nil
+	}
+}
+
+// span returns the byte range covered by the lines of a block and its
+// descendants, or false if it has none
+func span(node ast.Node) (first, last int, ok bool) {
+	_ = ast.Walk(node, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering || n.Type() != ast.TypeBlock {
+			return ast.WalkContinue, nil
+		}
+
+		segments := []text.Segment{}
+		for i := 0; i < n.Lines().Len(); i++ {
+			segments = append(segments, n.Lines().At(i))
+		}
+		if code, isCode := n.(*ast.FencedCodeBlock); isCode && code.Info != nil {
+			segments = append(segments, code.Info.Segment)
+		}
+
+		for _, segment := range segments {
+			if segment.Stop <= segment.Start {
+				continue
+			}
+			if !ok || segment.Start < first {
+				first = segment.Start
+			}
+			if !ok || segment.Stop > last {
+				last = segment.Stop
+			}
+			ok = true
+		}
+		return ast.WalkContinue, nil
+	})
+	return first, last, ok
+}
+
+// lineStart returns the offset of the start of the line containing offset
+func lineStart(source []byte, offset int) int {
+	if offset <= 0 {
+		return 0
+	}
+	return bytes.LastIndexByte(source[:offset], '\n') + 1
+}
+
+// lineEnd returns the offset of the line break ending the line containing
+// offset, or the length of the source on the last line
+func lineEnd(source []byte, offset int) int {
+	if offset >= len(source) {
+		return len(source)
+	}
+	if i := bytes.IndexByte(source[offset:], '\n'); i >= 0 {
+		return offset + i
+	}
+	return len(source)
+}
+
+// fences returns the range of the first fence line starting at or after the
+// line start from, and of the fence closing it on the next line if there is one
+func fences(source []byte, from int) (start, end int, ok bool) {
+	for start = from; start < len(source); start = end + 1 {
+		end = lineEnd(source, start)
+		if !isFence(source[start:end]) {
+			continue
+		}
+		if end < len(source) && isFence(source[end+1:lineEnd(source, end+1)]) {
+			end = lineEnd(source, end+1)
+		}
+		return start, end, true
+	}
+	return 0, 0, false
+}
+
+// isFence reports whether a line closes a fenced code block
+func isFence(line []byte) bool {
+	trimmed := strings.TrimSpace(string(line))
+	return len(trimmed) >= 3 && (strings.Trim(trimmed, "`") == "" || strings.Trim(trimmed, "~") == "")
+}
+
+// pluralize formats a count with a noun, adding an s unless the count is one
+func pluralize(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
@@ -0,0 +1,118 @@
+package outline
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/spandigital/token-visualizer/internal/tokenizers"
+)
+
+// tree lists the nodes under root one per line, indented by depth, with the
+// text each covers
+func tree(root *Node, source string) string {
+	var b strings.Builder
+	var walk func(node *Node, depth int)
+	walk = func(node *Node, depth int) {
+		for _, child := range node.Children {
+			fmt.Fprintf(&b, "%s%s %q\n", strings.Repeat("  ", depth), child.Label(), source[child.Start:child.End])
+			walk(child, depth+1)
+		}
+	}
+	walk(root, 0)
+	return b.String()
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "nested sections",
+			input: "# A\na\n## B\nb\n### C\nc\n## D\nd\n# E\n",
+			want: `# A "# A\na\n## B\nb\n### C\nc\n## D\nd\n"
+  ## B "## B\nb\n### C\nc\n"
+    ### C "### C\nc\n"
+  ## D "## D\nd\n"
+# E "# E\n"
+`,
+		},
+		{
+			name:  "skipped level",
+			input: "## A\n#### B\n### C\n# D",
+			want: `## A "## A\n#### B\n### C\n"
+  #### B "#### B\n"
+  ### C "### C\n"
+# D "# D"
+`,
+		},
+		{
+			name:  "blocks in sections",
+			input: "intro\n\n- one\n- two\n\n# A\n\n| x | y |\n|---|---|\n| 1 | 2 |\n\n```go\nfmt.Println()\n```\n",
+			want: `List (2 items) "- one\n- two"
+# A "# A\n\n| x | y |\n|---|---|\n| 1 | 2 |\n\n` + "```go\\nfmt.Println()\\n```" + `\n"
+  Table (1 row) "| x | y |\n|---|---|\n| 1 | 2 |"
+  Code block (go, 1 line) "` + "```go\\nfmt.Println()\\n```" + `"
+`,
+		},
+		{
+			name:  "heading in a code block",
+			input: "# A\n```\n# not a heading\n```\n",
+			want: `# A "# A\n` + "```\\n# not a heading\\n```" + `\n"
+  Code block (1 line) "` + "```\\n# not a heading\\n```" + `"
+`,
+		},
+		{
+			name:  "empty code blocks",
+			input: "# A\n```\n```\ntext\n\n~~~\n~~~\n```go\n```\n",
+			want: `# A "# A\n` + "```\\n```\\ntext\\n\\n~~~\\n~~~\\n```go\\n```" + `\n"
+  Code block (0 lines) "` + "```\\n```" + `"
+  Code block (0 lines) "~~~\n~~~"
+  Code block (go, 0 lines) "` + "```go\\n```" + `"
+`,
+		},
+		{
+			name:  "empty code block after a code block",
+			input: "```\nx\n```\n```\n```",
+			want: `Code block (1 line) "` + "```\\nx\\n```" + `"
+Code block (0 lines) "` + "```\\n```" + `"
+`,
+		},
+		{
+			name:  "unclosed empty code block",
+			input: "text\n```\n",
+			want: `Code block (0 lines) "` + "```" + `"
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tree(Parse(tt.input), tt.input); got != tt.want {
+				t.Errorf("outline:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompute(t *testing.T) {
+	input := "# A\naa\n# B\nb\n"
+	result := &tokenizers.TokenizationResult{Model: "m", Text: input}
+	for i := range len(input) {
+		result.Tokens = append(result.Tokens, tokenizers.Token{Text: input[i : i+1], ID: i, Start: i, End: i + 1})
+	}
+	claude := &tokenizers.TokenizationResult{Model: "claude", Text: input, Tokens: []tokenizers.Token{{ID: -1, Text: input, End: len(input)}}}
+
+	report := Compute([]*tokenizers.TokenizationResult{result, claude})
+	if len(report.Models) != 1 || len(report.Skipped) != 1 {
+		t.Fatalf("models = %v, skipped = %v, want one of each", report.Models, report.Skipped)
+	}
+
+	// Sections add up to the document
+	want := []int{len(input), 7, 6}
+	got := []int{report.Root.Tokens[0], report.Root.Children[0].Tokens[0], report.Root.Children[1].Tokens[0]}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("tokens = %v, want %v", got, want)
+	}
+}
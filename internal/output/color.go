@@ -0,0 +1,285 @@
+package output
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spandigital/token-visualizer/internal/tokenizers"
+)
+
+// swatch is a palette color for the terminal and HTML renderers. Swatches
+// without color set text attributes instead.
+type swatch struct {
+	terminal lipgloss.TerminalColor
+	html     string
+	attrs    swatchAttrs
+}
+
+// swatchAttrs are text attributes that a swatch applies along with its color
+type swatchAttrs uint8
+
+const (
+	attrUnderline swatchAttrs = 1 << iota
+	attrBold
+	attrFaint
+	attrReverse
+)
+
+// style returns a terminal style with the color and attributes of the swatch
+func (s swatch) style() lipgloss.Style {
+	style := lipgloss.NewStyle()
+	if s.terminal != nil {
+		style = style.Foreground(s.terminal)
+	}
+	if s.attrs&attrUnderline != 0 {
+		style = style.Underline(true)
+	}
+	if s.attrs&attrBold != 0 {
+		style = style.Bold(true)
+	}
+	if s.attrs&attrFaint != 0 {
+		style = style.Faint(true)
+	}
+	if s.attrs&attrReverse != 0 {
+		style = style.Reverse(true)
+	}
+	return style
+}
+
+// Coloring names
+const (
+	ColorByCycle  = "cycle"
+	ColorByRank   = "rank"
+	ColorByLength = "length"
+	ColorByBytes  = "bytes"
+	ColorByWord   = "word"
+)
+
+// Coloring is a strategy for choosing the color of each token. The default
+// cycles through the palette to show boundaries; the others are heatmaps.
+type Coloring struct {
+	name    string
+	palette func(theme *Theme) []swatch
+	legend  []string // Label of each palette entry; empty for the cycle
+	bucket  func(result *tokenizers.TokenizationResult, i int) int
+}
+
+// heatPalette colors a heatmap with the theme heat colors
+func heatPalette(theme *Theme) []swatch {
+	return theme.heat
+}
+
+// NewColoring returns the coloring strategy with the given name
+func NewColoring(name string) (*Coloring, error) {
+	switch name {
+	case "", ColorByCycle:
+		return cycleColoring, nil
+	case ColorByRank:
+		return &Coloring{
+			name:    ColorByRank,
+			palette: heatPalette,
+			legend:  []string{"ID < 256", "256-999", "1k-4.9k", "5k-19.9k", "20k-49.9k", "≥ 50k"},
+			bucket: func(result *tokenizers.TokenizationResult, i int) int {
+				return thresholdBucket(result.Tokens[i].ID, []int{256, 1000, 5000, 20000, 50000})
+			},
+		}, nil
+	case ColorByLength:
+		return &Coloring{
+			name:    ColorByLength,
+			palette: heatPalette,
+			legend:  []string{"1 char", "2 chars", "3 chars", "4-5 chars", "6-8 chars", "9+ chars"},
+			bucket: func(result *tokenizers.TokenizationResult, i int) int {
+				return thresholdBucket(utf8.RuneCountInString(sourceText(result, i)), []int{2, 3, 4, 6, 9})
+			},
+		}, nil
+	case ColorByBytes:
+		return &Coloring{
+			name:    ColorByBytes,
+			palette: heatPalette,
+			legend:  []string{"1 byte", "2 bytes", "3 bytes", "4-5 bytes", "6-8 bytes", "9+ bytes"},
+			bucket: func(result *tokenizers.TokenizationResult, i int) int {
+				return thresholdBucket(len(sourceText(result, i)), []int{2, 3, 4, 6, 9})
+			},
+		}, nil
+	case ColorByWord:
+		return &Coloring{
+			name: ColorByWord,
+			palette: func(theme *Theme) []swatch {
+				return []swatch{theme.heat[2], theme.heat[4], theme.neutral}
+			},
+			legend: []string{"whole word", "word fragment", "no letters"},
+			bucket: wordBucket,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("unknown coloring %q (expected cycle, rank, length, bytes or word)", name)
+}
+
+// cycleColoring is the default coloring, rotating through the theme token colors
+var cycleColoring = &Coloring{
+	name: ColorByCycle,
+	palette: func(theme *Theme) []swatch {
+		return theme.tokens
+	},
+	bucket: func(result *tokenizers.TokenizationResult, i int) int {
+		return i
+	},
+}
+
+// Name returns the name of the strategy
+func (c *Coloring) Name() string {
+	return c.name
+}
+
+// HasLegend reports whether the strategy colors carry meaning worth a legend
+func (c *Coloring) HasLegend() bool {
+	return len(c.legend) > 0
+}
+
+// Bucket returns the palette index of the token at index i, or -1 if it has
+// no bucket. The cycle has no fixed palette size and returns i itself.
+func (c *Coloring) Bucket(result *tokenizers.TokenizationResult, i int) int {
+	return c.bucket(result, i)
+}
+
+// paletteIndex returns the index in the theme palette of the token at index i, or -1
+func (c *Coloring) paletteIndex(theme *Theme, result *tokenizers.TokenizationResult, i int) int {
+	bucket := c.bucket(result, i)
+	palette := c.palette(theme)
+	switch {
+	case bucket < 0:
+		return -1
+	case c == cycleColoring:
+		return bucket % len(palette)
+	case bucket >= len(palette):
+		return -1
+	}
+	return bucket
+}
+
+// Label returns the legend label of the token at index i
+func (c *Coloring) Label(result *tokenizers.TokenizationResult, i int) string {
+	bucket := c.bucket(result, i)
+	if bucket < 0 || bucket >= len(c.legend) {
+		return ""
+	}
+	return c.legend[bucket]
+}
+
+// swatch returns the color of the token at index i
+func (c *Coloring) swatch(theme *Theme, result *tokenizers.TokenizationResult, i int) swatch {
+	index := c.paletteIndex(theme, result, i)
+	if index < 0 {
+		return theme.neutral
+	}
+	return c.palette(theme)[index]
+}
+
+// htmlClass returns the CSS class of the token at index i
+func (c *Coloring) htmlClass(theme *Theme, result *tokenizers.TokenizationResult, i int) string {
+	index := c.paletteIndex(theme, result, i)
+	switch {
+	case index < 0:
+		return "color-none"
+	case c == cycleColoring:
+		return fmt.Sprintf("token-%d", index)
+	default:
+		return fmt.Sprintf("color-%d", index)
+	}
+}
+
+// css returns the CSS classes for the strategy palette
+func (c *Coloring) css(theme *Theme) string {
+	var css strings.Builder
+	css.WriteString(fmt.Sprintf(".color-none { %s }\n", theme.cssDeclarations(theme.neutral)))
+	for i, s := range c.palette(theme) {
+		css.WriteString(fmt.Sprintf(".color-%d { %s }\n", i, theme.cssDeclarations(s)))
+	}
+	return css.String()
+}
+
+// terminalLegend renders the legend as colored swatches on one line
+func (c *Coloring) terminalLegend(theme *Theme) string {
+	palette := c.palette(theme)
+	parts := make([]string, len(c.legend))
+	for i, label := range c.legend {
+		parts[i] = palette[i].style().Render("■ " + label)
+	}
+	return theme.muted.style().Italic(true).Render(fmt.Sprintf("Colored by %s: ", c.name)) + strings.Join(parts, "  ")
+}
+
+// htmlLegend renders the legend as colored swatches
+func (c *Coloring) htmlLegend() string {
+	var legend strings.Builder
+	legend.WriteString(fmt.Sprintf("<div class=\"legend\">Colored by %s:", c.name))
+	for i, label := range c.legend {
+		legend.WriteString(fmt.Sprintf(" <span class=\"legend-item color-%d\">■ %s</span>", i, escapeHTML(label)))
+	}
+	legend.WriteString("</div>\n")
+	return legend.String()
+}
+
+// markdownLegend renders the legend as a list of labels
+func (c *Coloring) markdownLegend() string {
+	var legend strings.Builder
+	legend.WriteString(fmt.Sprintf("**Token %s:** ", c.name))
+	legend.WriteString(strings.Join(c.legend, " · "))
+	legend.WriteString("\n\n")
+	return legend.String()
+}
+
+// thresholdBucket returns the number of thresholds that value reaches, or -1 for negative values
+func thresholdBucket(value int, thresholds []int) int {
+	if value < 0 {
+		return -1
+	}
+	bucket := 0
+	for _, threshold := range thresholds {
+		if value >= threshold {
+			bucket++
+		}
+	}
+	return bucket
+}
+
+// sourceText returns the input text covered by a token, falling back to the
+// token text when the token has no offsets
+func sourceText(result *tokenizers.TokenizationResult, i int) string {
+	token := result.Tokens[i]
+	if token.Start >= 0 && token.Start < token.End && token.End <= len(result.Text) {
+		return result.Text[token.Start:token.End]
+	}
+	return token.Text
+}
+
+// wordBucket classifies a token as a whole word (0), a word fragment (1) or
+// text without letters (2), using the characters around it in the input
+func wordBucket(result *tokenizers.TokenizationResult, i int) int {
+	token := result.Tokens[i]
+	text := strings.TrimSpace(sourceText(result, i))
+	if !strings.ContainsFunc(text, unicode.IsLetter) {
+		return 2
+	}
+	if token.End <= token.Start || token.End > len(result.Text) {
+		return -1
+	}
+
+	before, _ := utf8.DecodeLastRuneInString(result.Text[:token.Start])
+	after, _ := utf8.DecodeRuneInString(result.Text[token.End:])
+	first, _ := utf8.DecodeRuneInString(result.Text[token.Start:token.End])
+	last, _ := utf8.DecodeLastRuneInString(result.Text[token.Start:token.End])
+
+	// A token continues a word if it touches a letter across either edge
+	if (isWordRune(before) && isWordRune(first)) || (isWordRune(last) && isWordRune(after)) {
+		return 1
+	}
+	return 0
+}
+
+// isWordRune reports whether r can be part of a word
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsMark(r) || unicode.IsDigit(r)
+}
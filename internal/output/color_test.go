@@ -0,0 +1,103 @@
+package output
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/spandigital/token-visualizer/internal/tokenizers"
+)
+
+func TestColoringBuckets(t *testing.T) {
+	tests := []struct {
+		coloring string
+		result   *tokenizers.TokenizationResult
+		want     []int
+	}{
+		{
+			// Each threshold starts a new bucket; tokens without an ID have none
+			coloring: ColorByRank,
+			result: withIDs(pieces("m", "a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k", "l"),
+				0, 255, 256, 999, 1000, 4999, 5000, 19999, 20000, 49999, 50000, -1),
+			want: []int{0, 0, 1, 1, 2, 2, 3, 3, 4, 4, 5, -1},
+		},
+		{
+			// Characters, not bytes
+			coloring: ColorByLength,
+			result:   pieces("m", "a", "éé", "abc", "abcd", "abcde", "abcdef", "abcdefgh", "abcdefghi", "日本語"),
+			want:     []int{0, 1, 2, 3, 3, 4, 4, 5, 2},
+		},
+		{
+			coloring: ColorByBytes,
+			result:   pieces("m", "a", "é", "abc", "€", "abcde", "abcdef", "abcdefgh", "abcdefghi", "日本語"),
+			want:     []int{0, 1, 2, 2, 3, 4, 4, 5, 5},
+		},
+		{
+			// Whole words, fragments of "world", and tokens without letters
+			coloring: ColorByWord,
+			result:   pieces("m", "hello", " wor", "ld", "!", " 42"),
+			want:     []int{0, 1, 1, 2, 2},
+		},
+		{
+			coloring: ColorByCycle,
+			result:   pieces("m", "a", "b", "c"),
+			want:     []int{0, 1, 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.coloring, func(t *testing.T) {
+			coloring, err := NewColoring(tt.coloring)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make([]int, len(tt.result.Tokens))
+			for i := range tt.result.Tokens {
+				got[i] = coloring.Bucket(tt.result, i)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("buckets = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestColoringLabels(t *testing.T) {
+	rank, err := NewColoring(ColorByRank)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := withIDs(pieces("m", "a", "b", "c", "d"), 255, 256, 50000, -1)
+
+	want := []string{"ID < 256", "256-999", "≥ 50k", ""}
+	for i := range result.Tokens {
+		if got := rank.Label(result, i); got != want[i] {
+			t.Errorf("label of ID %d = %q, want %q", result.Tokens[i].ID, got, want[i])
+		}
+	}
+}
+
+func TestColoringHasLegend(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"", false},
+		{ColorByCycle, false},
+		{ColorByRank, true},
+		{ColorByLength, true},
+		{ColorByBytes, true},
+		{ColorByWord, true},
+	}
+	for _, tt := range tests {
+		coloring, err := NewColoring(tt.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if coloring.HasLegend() != tt.want {
+			t.Errorf("%q HasLegend = %v, want %v", tt.name, coloring.HasLegend(), tt.want)
+		}
+	}
+
+	if _, err := NewColoring("rainbow"); err == nil {
+		t.Error("NewColoring(rainbow) succeeded, want an error")
+	}
+}
@@ -3,15 +3,25 @@ package output
 import (
 	"fmt"
 	"html"
+	"strconv"
 	"strings"
 
+	"github.com/spandigital/token-visualizer/internal/align"
+	"github.com/spandigital/token-visualizer/internal/bytegroup"
+	"github.com/spandigital/token-visualizer/internal/diff"
+	"github.com/spandigital/token-visualizer/internal/metrics"
+	"github.com/spandigital/token-visualizer/internal/outline"
 	"github.com/spandigital/token-visualizer/internal/tokenizers"
+	"github.com/spandigital/token-visualizer/internal/vocab"
 )
 
 // HTMLInlineRenderer generates inline HTML output with colored tokens similar to terminal output
 type HTMLInlineRenderer struct {
-	showIDs        bool
-	showBoundaries bool
+	showIDs           bool
+	showBoundaries    bool
+	visibleWhitespace bool
+	coloring          *Coloring
+	theme             *Theme
 }
 
 // NewHTMLInlineRenderer creates a new HTML inline renderer
@@ -19,30 +29,47 @@ func NewHTMLInlineRenderer(showIDs, showBoundaries bool) *HTMLInlineRenderer {
 	return &HTMLInlineRenderer{
 		showIDs:        showIDs,
 		showBoundaries: showBoundaries,
+		coloring:       cycleColoring,
+		theme:          builtinThemes[DefaultTheme],
 	}
 }
 
-// tokenColors matches the terminal output color palette
-var htmlTokenColors = []string{
-	"#ff5faf", // Pink
-	"#af87ff", // Purple
-	"#5fffff", // Cyan
-	"#ffff87", // Yellow
-	"#87ff00", // Green
-	"#ff87ff", // Magenta
-	"#87d7ff", // Light Blue
-	"#ffd7af", // Peach
+// WithTheme sets the colors of the output
+func (r *HTMLInlineRenderer) WithTheme(theme *Theme) *HTMLInlineRenderer {
+	r.theme = theme
+	return r
+}
+
+// WithColoring sets the strategy used to color tokens
+func (r *HTMLInlineRenderer) WithColoring(coloring *Coloring) *HTMLInlineRenderer {
+	r.coloring = coloring
+	return r
+}
+
+// WithVisibleWhitespace shows spaces, tabs, line breaks and invisible characters as markers
+func (r *HTMLInlineRenderer) WithVisibleWhitespace(visible bool) *HTMLInlineRenderer {
+	r.visibleWhitespace = visible
+	return r
+}
+
+// tokenText returns the escaped text of a token as it should be displayed
+func (r *HTMLInlineRenderer) tokenText(s string) string {
+	if r.visibleWhitespace {
+		s = visibleText(s, true)
+	}
+	return escapeHTML(s)
 }
 
 // generateCSS generates the CSS styles for the HTML output
 func (r *HTMLInlineRenderer) generateCSS() string {
 	var css strings.Builder
-	css.WriteString(`<style>
-body {
+	css.WriteString("<style>\n")
+	css.WriteString(r.theme.cssVariables())
+	css.WriteString(`body {
     font-family: 'SF Mono', 'Monaco', 'Inconsolata', 'Fira Code', 'Consolas', monospace;
     padding: 20px;
-    background-color: #1e1e1e;
-    color: #d4d4d4;
+    background-color: var(--background);
+    color: var(--text);
     line-height: 1.6;
 }
 .container {
@@ -53,14 +80,14 @@ body {
     font-size: 1.2em;
     font-weight: bold;
     margin-bottom: 10px;
-    color: #569cd6;
+    color: var(--accent);
 }
 .token-count {
-    color: #9cdcfe;
+    color: var(--label);
     margin-bottom: 15px;
 }
 .tokens {
-    background-color: #252526;
+    background-color: var(--panel);
     padding: 15px;
     border-radius: 5px;
     margin-bottom: 20px;
@@ -72,13 +99,116 @@ body {
 }
 .token-id {
     font-size: 0.8em;
-    color: #6a6a6a;
+    color: var(--ids);
     font-weight: normal;
 }
 .boundary {
-    color: #6a6a6a;
+    color: var(--ids);
     font-weight: normal;
 }
+.token-equal {
+    color: var(--neutral);
+}
+.token-insert {
+    color: var(--insert);
+    background-color: color-mix(in srgb, var(--insert) 15%, transparent);
+    text-decoration: underline;
+}
+.token-delete {
+    color: var(--delete);
+    background-color: color-mix(in srgb, var(--delete) 15%, transparent);
+    text-decoration: line-through;
+}
+.aligned {
+    display: flex;
+    flex-wrap: wrap;
+    align-items: stretch;
+    background-color: var(--panel);
+    padding: 15px;
+    border-radius: 5px;
+    margin-bottom: 20px;
+}
+.segment {
+    display: inline-flex;
+    flex-direction: column;
+    border-right: 1px solid var(--border);
+    margin-bottom: 8px;
+}
+.segment-row {
+    white-space: pre;
+    padding: 0 2px;
+    min-height: 1.6em;
+}
+.segment-row:nth-child(odd) {
+    background-color: color-mix(in srgb, var(--text) 3%, transparent);
+}
+.segment-equal {
+    color: var(--neutral);
+}
+.segment.divergent {
+    background-color: color-mix(in srgb, var(--delete) 15%, transparent);
+    border-bottom: 2px solid var(--delete);
+}
+.aligned-legend {
+    color: var(--label);
+    margin-bottom: 15px;
+}
+.stats-table {
+    border-collapse: collapse;
+    margin-bottom: 20px;
+    background-color: var(--panel);
+}
+.stats-table th, .stats-table td {
+    padding: 4px 12px;
+    text-align: right;
+    border-bottom: 1px solid var(--border);
+}
+.stats-table th {
+    color: var(--accent);
+}
+.stats-table th:first-child, .stats-table td:first-child {
+    text-align: left;
+}
+.merge-table {
+    border-collapse: collapse;
+    margin-bottom: 20px;
+    background-color: var(--panel);
+}
+.merge-table td {
+    padding: 4px 12px;
+    border-bottom: 1px solid var(--border);
+    white-space: pre;
+}
+.merge-table th {
+    color: var(--accent);
+    text-align: left;
+    padding: 4px 12px;
+}
+.byte-partial {
+    background-color: color-mix(in srgb, var(--highlight) 10%, transparent);
+}
+.merge-label {
+    color: var(--label);
+}
+.merge-part {
+    color: var(--neutral);
+    border: 1px solid var(--border);
+    border-radius: 3px;
+    padding: 0 3px;
+    margin-right: 2px;
+}
+.merge-new {
+    color: var(--insert);
+    border-color: var(--insert);
+    font-weight: bold;
+}
+.legend {
+    color: var(--label);
+    margin-bottom: 15px;
+}
+.legend-item {
+    margin-left: 10px;
+}
 .comparison-container {
     display: flex;
     gap: 20px;
@@ -90,15 +220,20 @@ body {
 }
 `)
 
-	// Generate color classes for each token rotation
-	for i, color := range htmlTokenColors {
-		css.WriteString(fmt.Sprintf(".token-%d { color: %s; }\n", i, color))
-	}
+	css.WriteString(r.coloring.css(r.theme))
 
 	css.WriteString("</style>\n")
 	return css.String()
 }
 
+// columnText returns the escaped text of a token for layouts that keep each token on one line
+func (r *HTMLInlineRenderer) columnText(s string) string {
+	if r.visibleWhitespace {
+		return escapeHTML(visibleText(s, false))
+	}
+	return escapeHTML(escapeControl(s))
+}
+
 // RenderSingle renders a single tokenization result as inline HTML
 func (r *HTMLInlineRenderer) RenderSingle(result *tokenizers.TokenizationResult) string {
 	var html strings.Builder
@@ -114,17 +249,18 @@ func (r *HTMLInlineRenderer) RenderSingle(result *tokenizers.TokenizationResult)
 	// Model header
 	html.WriteString(fmt.Sprintf("<div class=\"model-header\">%s</div>\n", escapeHTML(result.Model)))
 	html.WriteString(fmt.Sprintf("<div class=\"token-count\">Total tokens: %d</div>\n", result.TotalCount))
+	if r.coloring.HasLegend() {
+		html.WriteString(r.coloring.htmlLegend())
+	}
 
 	// Tokens
 	html.WriteString("<div class=\"tokens\">\n")
 	for i, token := range result.Tokens {
-		colorIdx := i % len(htmlTokenColors)
-
 		if r.showBoundaries && i > 0 {
 			html.WriteString("<span class=\"boundary\">|</span>")
 		}
 
-		html.WriteString(fmt.Sprintf("<span class=\"token token-%d\">%s</span>", colorIdx, escapeHTML(token.Text)))
+		html.WriteString(fmt.Sprintf("<span class=\"token %s\">%s</span>", r.coloring.htmlClass(r.theme, result, i), r.tokenText(token.Text)))
 
 		if r.showIDs {
 			html.WriteString(fmt.Sprintf("<span class=\"token-id\">[%d]</span>", token.ID))
@@ -150,6 +286,10 @@ func (r *HTMLInlineRenderer) RenderComparison(results []*tokenizers.Tokenization
 	html.WriteString(r.generateCSS())
 	html.WriteString("</head>\n<body>\n<div class=\"container\">\n")
 
+	if r.coloring.HasLegend() {
+		html.WriteString(r.coloring.htmlLegend())
+	}
+
 	// Comparison container with side-by-side models
 	html.WriteString("<div class=\"comparison-container\">\n")
 
@@ -163,13 +303,11 @@ func (r *HTMLInlineRenderer) RenderComparison(results []*tokenizers.Tokenization
 		// Tokens
 		html.WriteString("<div class=\"tokens\">\n")
 		for i, token := range result.Tokens {
-			colorIdx := i % len(htmlTokenColors)
-
 			if r.showBoundaries && i > 0 {
 				html.WriteString("<span class=\"boundary\">|</span>")
 			}
 
-			html.WriteString(fmt.Sprintf("<span class=\"token token-%d\">%s</span>", colorIdx, escapeHTML(token.Text)))
+			html.WriteString(fmt.Sprintf("<span class=\"token %s\">%s</span>", r.coloring.htmlClass(r.theme, result, i), r.tokenText(token.Text)))
 
 			if r.showIDs {
 				html.WriteString(fmt.Sprintf("<span class=\"token-id\">[%d]</span>", token.ID))
@@ -197,29 +335,29 @@ func (r *HTMLInlineRenderer) RenderCountOnly(results []*tokenizers.TokenizationR
 	html.WriteString("<meta charset=\"UTF-8\">\n")
 	html.WriteString("<meta name=\"viewport\" content=\"width=device-width, initial-scale=1.0\">\n")
 	html.WriteString("<title>Token Counts</title>\n")
-	html.WriteString(`<style>
-body {
+	html.WriteString("<style>\n" + r.theme.cssVariables())
+	html.WriteString(`body {
     font-family: 'SF Mono', 'Monaco', 'Inconsolata', 'Fira Code', 'Consolas', monospace;
     padding: 20px;
-    background-color: #1e1e1e;
-    color: #d4d4d4;
+    background-color: var(--background);
+    color: var(--text);
 }
 .container {
     max-width: 800px;
     margin: 0 auto;
 }
 .count-item {
-    background-color: #252526;
+    background-color: var(--panel);
     padding: 15px;
     border-radius: 5px;
     margin-bottom: 10px;
 }
 .model-name {
-    color: #569cd6;
+    color: var(--accent);
     font-weight: bold;
 }
 .token-count {
-    color: #9cdcfe;
+    color: var(--label);
     margin-left: 10px;
 }
 </style>
@@ -238,6 +376,447 @@ body {
 	return html.String()
 }
 
+// RenderDiff renders token-level diffs as inline HTML
+func (r *HTMLInlineRenderer) RenderDiff(results []*diff.Result) string {
+	var html strings.Builder
+
+	// HTML header with CSS
+	html.WriteString("<!DOCTYPE html>\n<html>\n<head>\n")
+	html.WriteString("<meta charset=\"UTF-8\">\n")
+	html.WriteString("<meta name=\"viewport\" content=\"width=device-width, initial-scale=1.0\">\n")
+	html.WriteString("<title>Token Diff</title>\n")
+	html.WriteString(r.generateCSS())
+	html.WriteString("</head>\n<body>\n<div class=\"container\">\n")
+
+	for _, result := range results {
+		// Model header
+		html.WriteString(fmt.Sprintf("<div class=\"model-header\">%s</div>\n", escapeHTML(result.Model)))
+		html.WriteString(fmt.Sprintf("<div class=\"token-count\">%d → %d tokens (%s) · %d inserted · %d deleted</div>\n",
+			result.Old.TotalCount, result.New.TotalCount, formatDelta(result.Delta()), result.Inserted, result.Deleted))
+
+		if len(result.New.Tokens) > 0 && result.New.Tokens[0].ID < 0 {
+			continue
+		}
+
+		// Tokens
+		html.WriteString("<div class=\"tokens\">\n")
+		for _, edit := range result.Edits {
+			class := "token-equal"
+			switch edit.Op {
+			case diff.Insert:
+				class = "token-insert"
+			case diff.Delete:
+				class = "token-delete"
+			}
+
+			html.WriteString(fmt.Sprintf("<span class=\"token %s\" title=\"ID %d\">%s</span>", class, edit.Token.ID, r.tokenText(edit.Token.Text)))
+
+			if r.showIDs && edit.Op != diff.Equal {
+				html.WriteString(fmt.Sprintf("<span class=\"token-id\">[%d]</span>", edit.Token.ID))
+			}
+		}
+		html.WriteString("\n</div>\n")
+	}
+
+	// Footer
+	html.WriteString("</div>\n</body>\n</html>\n")
+
+	return html.String()
+}
+
+// RenderAligned renders the models lined up on their shared token boundaries as inline HTML.
+// Each segment stacks one row per model, and divergent segments are highlighted.
+func (r *HTMLInlineRenderer) RenderAligned(result *align.Result) string {
+	var html strings.Builder
+
+	// HTML header with CSS
+	html.WriteString("<!DOCTYPE html>\n<html>\n<head>\n")
+	html.WriteString("<meta charset=\"UTF-8\">\n")
+	html.WriteString("<meta name=\"viewport\" content=\"width=device-width, initial-scale=1.0\">\n")
+	html.WriteString("<title>Aligned Token Comparison</title>\n")
+	html.WriteString(r.generateCSS())
+	html.WriteString("</head>\n<body>\n<div class=\"container\">\n")
+
+	html.WriteString("<div class=\"model-header\">Aligned Comparison</div>\n")
+	html.WriteString(fmt.Sprintf("<div class=\"token-count\">%s · %s</div>\n",
+		pluralize(result.Shared, "shared boundary"), pluralize(result.Divergent, "divergent span")))
+	for _, model := range result.Skipped {
+		html.WriteString(fmt.Sprintf("<div class=\"token-count\">%s has no individual tokens and is not aligned</div>\n", escapeHTML(model)))
+	}
+
+	// Legend with the row order
+	html.WriteString("<div class=\"aligned-legend\">Rows: ")
+	for i, model := range result.Models {
+		if i > 0 {
+			html.WriteString(" · ")
+		}
+		html.WriteString(fmt.Sprintf("%d. %s", i+1, escapeHTML(model)))
+	}
+	html.WriteString("</div>\n")
+
+	html.WriteString("<div class=\"aligned\">\n")
+	for _, segment := range result.Segments {
+		if segment.Divergent {
+			html.WriteString("<div class=\"segment divergent\">")
+		} else {
+			html.WriteString("<div class=\"segment\">")
+		}
+
+		for m, model := range result.Models {
+			html.WriteString(fmt.Sprintf("<div class=\"segment-row\" title=\"%s\">", escapeHTML(model)))
+
+			for i, piece := range segment.Pieces[m] {
+				class := "segment-equal"
+				if segment.Divergent {
+					class = fmt.Sprintf("token-%d", i%len(r.theme.tokens))
+				}
+
+				if r.showBoundaries && i > 0 {
+					html.WriteString("<span class=\"boundary\">|</span>")
+				}
+
+				html.WriteString(fmt.Sprintf("<span class=\"token %s\" title=\"%s: ID %s\">%s</span>",
+					class, escapeHTML(model), pieceIDs(piece), r.columnText(alignedPieceText(result.Text, segment, m, i))))
+
+				if r.showIDs {
+					html.WriteString(fmt.Sprintf("<span class=\"token-id\">[%s]</span>", pieceIDs(piece)))
+				}
+			}
+
+			html.WriteString("</div>")
+		}
+
+		html.WriteString("</div>\n")
+	}
+	html.WriteString("</div>\n")
+
+	// Footer
+	html.WriteString("</div>\n</body>\n</html>\n")
+
+	return html.String()
+}
+
+// RenderEfficiency renders characters, bytes and words per token as HTML tables
+func (r *HTMLInlineRenderer) RenderEfficiency(reports []*metrics.Report) string {
+	var html strings.Builder
+
+	// HTML header with CSS
+	html.WriteString("<!DOCTYPE html>\n<html>\n<head>\n")
+	html.WriteString("<meta charset=\"UTF-8\">\n")
+	html.WriteString("<meta name=\"viewport\" content=\"width=device-width, initial-scale=1.0\">\n")
+	html.WriteString("<title>Tokenization Efficiency</title>\n")
+	html.WriteString(r.generateCSS())
+	html.WriteString("</head>\n<body>\n<div class=\"container\">\n")
+
+	html.WriteString("<div class=\"model-header\">Tokenization Efficiency</div>\n")
+
+	rows := make([][]string, len(reports))
+	for i, report := range reports {
+		rows[i] = append([]string{report.Model}, efficiencyCells(report.Overall)...)
+	}
+	html.WriteString(htmlTable(append([]string{"Model"}, efficiencyHeaders...), rows))
+
+	for _, report := range reports {
+		if len(report.Scripts) == 0 {
+			continue
+		}
+
+		html.WriteString(fmt.Sprintf("<div class=\"model-header\">%s</div>\n", escapeHTML(report.Model)))
+		html.WriteString(htmlTable(append([]string{"Script"}, efficiencyHeaders...), groupRows(report.Scripts)))
+		html.WriteString(htmlTable(append([]string{"Class"}, efficiencyHeaders...), groupRows(report.Classes)))
+	}
+
+	// Footer
+	html.WriteString("</div>\n</body>\n</html>\n")
+
+	return html.String()
+}
+
+// RenderSections renders the tokens of each part of a markdown document as an
+// HTML table, indented like the document outline
+func (r *HTMLInlineRenderer) RenderSections(report *outline.Report) string {
+	var html strings.Builder
+
+	// HTML header with CSS
+	html.WriteString("<!DOCTYPE html>\n<html>\n<head>\n")
+	html.WriteString("<meta charset=\"UTF-8\">\n")
+	html.WriteString("<meta name=\"viewport\" content=\"width=device-width, initial-scale=1.0\">\n")
+	html.WriteString("<title>Section Budget</title>\n")
+	html.WriteString(r.generateCSS())
+	html.WriteString("</head>\n<body>\n<div class=\"container\">\n")
+
+	html.WriteString("<div class=\"model-header\">Section Budget</div>\n")
+	for _, model := range report.Skipped {
+		html.WriteString(fmt.Sprintf("<div class=\"token-count\">%s has no individual tokens and is not broken down</div>\n", escapeHTML(model)))
+	}
+
+	if len(report.Models) > 0 {
+		html.WriteString(htmlTable(sectionHeaders(report), sectionRows(report, "\u00a0\u00a0\u00a0\u00a0")))
+	}
+
+	// Footer
+	html.WriteString("</div>\n</body>\n</html>\n")
+
+	return html.String()
+}
+
+// RenderStages renders the layers of the tokenization pipeline as inline HTML
+func (r *HTMLInlineRenderer) RenderStages(stages *tokenizers.Stages) string {
+	var html strings.Builder
+	result := stages.Result
+
+	// HTML header with CSS
+	html.WriteString("<!DOCTYPE html>\n<html>\n<head>\n")
+	html.WriteString("<meta charset=\"UTF-8\">\n")
+	html.WriteString("<meta name=\"viewport\" content=\"width=device-width, initial-scale=1.0\">\n")
+	html.WriteString("<title>Tokenization Stages</title>\n")
+	html.WriteString(r.generateCSS())
+	html.WriteString("</head>\n<body>\n<div class=\"container\">\n")
+
+	html.WriteString(fmt.Sprintf("<div class=\"model-header\">%s</div>\n", escapeHTML(result.Model)))
+
+	unchanged := ""
+	if stages.Normalized == result.Text {
+		unchanged = " (unchanged)"
+	}
+	html.WriteString(fmt.Sprintf("<div class=\"token-count\">1. Normalized text%s</div>\n", unchanged))
+	html.WriteString(fmt.Sprintf("<div class=\"tokens\"><span class=\"token token-equal\">%s</span></div>\n", escapeHTML(stages.Normalized)))
+
+	html.WriteString(fmt.Sprintf("<div class=\"token-count\">2. Pre-tokenized chunks (%d)</div>\n", len(stages.Chunks)))
+	html.WriteString("<div class=\"tokens\">\n")
+	for i, chunk := range stages.Chunks {
+		if i > 0 {
+			html.WriteString("<span class=\"boundary\">|</span>")
+		}
+		html.WriteString(fmt.Sprintf("<span class=\"token token-%d\">%s</span>", i%len(r.theme.tokens), r.tokenText(chunk.Text)))
+	}
+	html.WriteString("\n</div>\n")
+
+	html.WriteString(fmt.Sprintf("<div class=\"token-count\">3. Tokens (%d)</div>\n", result.TotalCount))
+	if r.coloring.HasLegend() {
+		html.WriteString(r.coloring.htmlLegend())
+	}
+	html.WriteString("<div class=\"tokens\">\n")
+	for i, token := range result.Tokens {
+		if r.showBoundaries && i > 0 {
+			html.WriteString("<span class=\"boundary\">|</span>")
+		}
+		html.WriteString(fmt.Sprintf("<span class=\"token %s\">%s</span>", r.coloring.htmlClass(r.theme, result, i), r.tokenText(token.Text)))
+		if r.showIDs {
+			html.WriteString(fmt.Sprintf("<span class=\"token-id\">[%d]</span>", token.ID))
+		}
+	}
+	html.WriteString("\n</div>\n")
+
+	// Footer
+	html.WriteString("</div>\n</body>\n</html>\n")
+
+	return html.String()
+}
+
+// RenderBytes renders every token with its raw bytes in hex as an HTML table,
+// bracketing tokens that only form whole characters together
+func (r *HTMLInlineRenderer) RenderBytes(result *tokenizers.TokenizationResult) string {
+	var html strings.Builder
+
+	// HTML header with CSS
+	html.WriteString("<!DOCTYPE html>\n<html>\n<head>\n")
+	html.WriteString("<meta charset=\"UTF-8\">\n")
+	html.WriteString("<meta name=\"viewport\" content=\"width=device-width, initial-scale=1.0\">\n")
+	html.WriteString("<title>Token Bytes</title>\n")
+	html.WriteString(r.generateCSS())
+	html.WriteString("</head>\n<body>\n<div class=\"container\">\n")
+
+	groups := bytegroup.Compute(result.Tokens)
+
+	html.WriteString(fmt.Sprintf("<div class=\"model-header\">%s</div>\n", escapeHTML(result.Model)))
+	html.WriteString(fmt.Sprintf("<div class=\"token-count\">%s</div>\n", escapeHTML(bytesStats(result, groups))))
+
+	html.WriteString("<table class=\"merge-table\">\n")
+	html.WriteString("<tr><th>#</th><th>ID</th><th>Bytes</th><th>Text</th><th>Character</th></tr>\n")
+	i := 0
+	for _, group := range groups {
+		for j, token := range group.Tokens {
+			row := "<tr>"
+			if group.Partial() {
+				row = "<tr class=\"byte-partial\">"
+			}
+			html.WriteString(fmt.Sprintf("%s<td class=\"token-id\">%d</td><td class=\"token-id\">%s</td><td>% x</td><td><span class=\"token %s\">%s</span></td><td>%s</td></tr>\n",
+				row, i+1, tokenID(token), token.Text, r.coloring.htmlClass(r.theme, result, i),
+				escapeHTML(visibleText(token.Text, false)), escapeHTML(groupMarker(group, j))))
+			i++
+		}
+	}
+	html.WriteString("</table>\n")
+
+	// Footer
+	html.WriteString("</div>\n</body>\n</html>\n")
+
+	return html.String()
+}
+
+// RenderMergeTrace renders the pre-tokenized chunks of a text and every BPE
+// merge applied to each chunk as HTML tables
+func (r *HTMLInlineRenderer) RenderMergeTrace(model string, traces []tokenizers.MergeTrace) string {
+	var html strings.Builder
+
+	// HTML header with CSS
+	html.WriteString("<!DOCTYPE html>\n<html>\n<head>\n")
+	html.WriteString("<meta charset=\"UTF-8\">\n")
+	html.WriteString("<meta name=\"viewport\" content=\"width=device-width, initial-scale=1.0\">\n")
+	html.WriteString("<title>BPE Merges</title>\n")
+	html.WriteString(r.generateCSS())
+	html.WriteString("</head>\n<body>\n<div class=\"container\">\n")
+
+	html.WriteString(fmt.Sprintf("<div class=\"model-header\">%s merges</div>\n", escapeHTML(model)))
+	html.WriteString(fmt.Sprintf("<div class=\"token-count\">Pre-tokenized into %s</div>\n", pluralize(len(traces), "chunk")))
+
+	html.WriteString("<div class=\"tokens\">\n")
+	for i, trace := range traces {
+		if i > 0 {
+			html.WriteString("<span class=\"boundary\">|</span>")
+		}
+		html.WriteString(fmt.Sprintf("<span class=\"token token-%d\">%s</span>", i%len(r.theme.tokens), escapeHTML(visibleText(trace.Chunk.Text, false))))
+	}
+	html.WriteString("\n</div>\n")
+
+	for i, trace := range traces {
+		html.WriteString(fmt.Sprintf("<div class=\"token-count\">Chunk %d: %s, %s</div>\n",
+			i+1, escapeHTML(strconv.Quote(trace.Chunk.Text)), pluralize(len(trace.Steps), "merge")))
+
+		html.WriteString("<table class=\"merge-table\">\n")
+		html.WriteString(mergeRow("start", trace.Initial, -1, ""))
+		for _, step := range trace.Steps {
+			id := ""
+			if step.ID != step.Rank {
+				id = fmt.Sprintf("→ ID %d", step.ID)
+			}
+			html.WriteString(mergeRow(fmt.Sprintf("rank %d", step.Rank), step.Parts, step.Index, id))
+		}
+
+		html.WriteString("<tr><td class=\"merge-label\">tokens</td><td>")
+		for j, token := range trace.Tokens {
+			html.WriteString(fmt.Sprintf("<span class=\"token token-%d\">%s</span><span class=\"token-id\">[%d]</span> ",
+				j%len(r.theme.tokens), escapeHTML(visibleText(token.Text, false)), token.ID))
+		}
+		html.WriteString("</td><td></td></tr>\n")
+		html.WriteString("</table>\n")
+	}
+
+	// Footer
+	html.WriteString("</div>\n</body>\n</html>\n")
+
+	return html.String()
+}
+
+// mergeRow renders one step of a merge trace, highlighting the merged part
+func mergeRow(label string, parts []string, highlight int, id string) string {
+	var row strings.Builder
+
+	row.WriteString(fmt.Sprintf("<tr><td class=\"merge-label\">%s</td><td>", escapeHTML(label)))
+	for i, part := range parts {
+		class := "merge-part"
+		if i == highlight {
+			class += " merge-new"
+		}
+		row.WriteString(fmt.Sprintf("<span class=\"%s\">%s</span>", class, escapeHTML(visibleText(part, false))))
+	}
+	row.WriteString(fmt.Sprintf("</td><td class=\"token-id\">%s</td></tr>\n", escapeHTML(id)))
+
+	return row.String()
+}
+
+// RenderVocab renders vocabulary entries as an HTML table
+func (r *HTMLInlineRenderer) RenderVocab(model string, entries []vocab.Entry, matched int) string {
+	var html strings.Builder
+
+	// HTML header with CSS
+	html.WriteString("<!DOCTYPE html>\n<html>\n<head>\n")
+	html.WriteString("<meta charset=\"UTF-8\">\n")
+	html.WriteString("<meta name=\"viewport\" content=\"width=device-width, initial-scale=1.0\">\n")
+	html.WriteString("<title>Vocabulary</title>\n")
+	html.WriteString(r.generateCSS())
+	html.WriteString("</head>\n<body>\n<div class=\"container\">\n")
+
+	html.WriteString(fmt.Sprintf("<div class=\"model-header\">%s vocabulary</div>\n", escapeHTML(model)))
+	stats := fmt.Sprintf("Matches: %d", matched)
+	if matched > len(entries) {
+		stats = fmt.Sprintf("Showing %d of %d matches", len(entries), matched)
+	}
+	html.WriteString(fmt.Sprintf("<div class=\"token-count\">%s</div>\n", stats))
+
+	html.WriteString("<table class=\"merge-table\">\n")
+	html.WriteString("<tr><th>ID</th><th>Token</th><th>Bytes</th></tr>\n")
+	for i, entry := range entries {
+		html.WriteString(fmt.Sprintf("<tr><td class=\"token-id\">%d</td><td><span class=\"token token-%d\">%s</span></td><td>%s</td></tr>\n",
+			entry.ID, i%len(r.theme.tokens), escapeHTML(entry.Escaped()), entry.Hex()))
+	}
+	html.WriteString("</table>\n")
+
+	// Footer
+	html.WriteString("</div>\n</body>\n</html>\n")
+
+	return html.String()
+}
+
+// RenderLookup renders whether strings are single vocabulary entries and how they tokenize
+func (r *HTMLInlineRenderer) RenderLookup(model string, matches []vocab.Match) string {
+	var html strings.Builder
+
+	// HTML header with CSS
+	html.WriteString("<!DOCTYPE html>\n<html>\n<head>\n")
+	html.WriteString("<meta charset=\"UTF-8\">\n")
+	html.WriteString("<meta name=\"viewport\" content=\"width=device-width, initial-scale=1.0\">\n")
+	html.WriteString("<title>Vocabulary Lookup</title>\n")
+	html.WriteString(r.generateCSS())
+	html.WriteString("</head>\n<body>\n<div class=\"container\">\n")
+
+	html.WriteString(fmt.Sprintf("<div class=\"model-header\">%s lookup</div>\n", escapeHTML(model)))
+
+	for _, match := range matches {
+		found := "<span class=\"token-delete\">✗ not in the vocabulary</span>"
+		if match.Entry != nil {
+			found = fmt.Sprintf("<span class=\"token-insert\">✓ single token, ID %d</span> <span class=\"token-id\">bytes %s</span>",
+				match.Entry.ID, match.Entry.Hex())
+		}
+		html.WriteString(fmt.Sprintf("<div class=\"token-count\">%s: %s</div>\n", escapeHTML(strconv.Quote(match.Text)), found))
+
+		html.WriteString("<div class=\"tokens\">\n")
+		for i, token := range match.Encoded {
+			html.WriteString(fmt.Sprintf("<span class=\"token token-%d\">%s</span><span class=\"token-id\">[%d]</span>",
+				i%len(r.theme.tokens), escapeHTML(strconv.Quote(lookupTokenText(match, token))), token.ID))
+		}
+		html.WriteString("\n</div>\n")
+	}
+
+	// Footer
+	html.WriteString("</div>\n</body>\n</html>\n")
+
+	return html.String()
+}
+
+// htmlTable renders rows as an HTML stats table
+func htmlTable(headers []string, rows [][]string) string {
+	var table strings.Builder
+
+	table.WriteString("<table class=\"stats-table\">\n<tr>")
+	for _, header := range headers {
+		table.WriteString(fmt.Sprintf("<th>%s</th>", escapeHTML(header)))
+	}
+	table.WriteString("</tr>\n")
+
+	for _, row := range rows {
+		table.WriteString("<tr>")
+		for _, cell := range row {
+			table.WriteString(fmt.Sprintf("<td>%s</td>", escapeHTML(cell)))
+		}
+		table.WriteString("</tr>\n")
+	}
+	table.WriteString("</table>\n")
+
+	return table.String()
+}
+
 // escapeHTML escapes HTML special characters
 func escapeHTML(s string) string {
 	return html.EscapeString(s)
@@ -0,0 +1,289 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/mattn/go-runewidth"
+	"github.com/spandigital/token-visualizer/internal/tokenizers"
+)
+
+// DefaultImageColumns is the width at which image output wraps, in characters
+const DefaultImageColumns = 80
+
+// imageRun is text drawn in one color, starting at a character cell
+type imageRun struct {
+	col, row int
+	cells    int // Width of the text in cells; wide characters take two
+	text     string
+	color    string
+}
+
+// imageCell is a position on the character grid
+type imageCell struct {
+	col, row int
+}
+
+// imageLayout is token output placed on a monospace character grid, which the
+// SVG and PNG encoders draw at their own cell size
+type imageLayout struct {
+	cols, rows int
+	runs       []imageRun
+	boundaries []imageCell // Token boundaries, drawn as a line at the left edge of the cell
+	background string
+	dim        string // Color of the boundary lines
+}
+
+// imageEncoder draws a layout in one image format
+type imageEncoder interface {
+	contentType() string
+	encode(w io.Writer, layout *imageLayout) error
+}
+
+// ImageRenderer draws the colored token flow as an image. Text wraps at a
+// fixed number of columns; the font is embedded so the output looks the same
+// everywhere.
+type ImageRenderer struct {
+	encoder           imageEncoder
+	showIDs           bool
+	showBoundaries    bool
+	visibleWhitespace bool
+	coloring          *Coloring
+	theme             *Theme
+	columns           int
+}
+
+// NewSVGRenderer creates a renderer that writes SVG with an embedded font
+func NewSVGRenderer(showIDs, showBoundaries bool) *ImageRenderer {
+	return newImageRenderer(svgEncoder{}, showIDs, showBoundaries)
+}
+
+// NewPNGRenderer creates a renderer that writes PNG images
+func NewPNGRenderer(showIDs, showBoundaries bool) *ImageRenderer {
+	return newImageRenderer(pngEncoder{}, showIDs, showBoundaries)
+}
+
+func newImageRenderer(encoder imageEncoder, showIDs, showBoundaries bool) *ImageRenderer {
+	return &ImageRenderer{
+		encoder:        encoder,
+		showIDs:        showIDs,
+		showBoundaries: showBoundaries,
+		coloring:       cycleColoring,
+		theme:          builtinThemes[DefaultTheme],
+		columns:        DefaultImageColumns,
+	}
+}
+
+// WithTheme sets the colors of the output. Images use the theme colors but
+// not its text attributes.
+func (r *ImageRenderer) WithTheme(theme *Theme) *ImageRenderer {
+	r.theme = theme
+	return r
+}
+
+// WithColoring sets the strategy used to color tokens
+func (r *ImageRenderer) WithColoring(coloring *Coloring) *ImageRenderer {
+	r.coloring = coloring
+	return r
+}
+
+// WithVisibleWhitespace shows spaces, tabs, line breaks and invisible characters as markers
+func (r *ImageRenderer) WithVisibleWhitespace(visible bool) *ImageRenderer {
+	r.visibleWhitespace = visible
+	return r
+}
+
+// WithColumns sets the width at which text wraps, in characters
+func (r *ImageRenderer) WithColumns(columns int) *ImageRenderer {
+	if columns > 0 {
+		r.columns = columns
+	}
+	return r
+}
+
+// ContentType returns the MIME type of the image format
+func (r *ImageRenderer) ContentType() string {
+	return r.encoder.contentType()
+}
+
+// Single draws a single tokenization result
+func (r *ImageRenderer) Single(w io.Writer, result *tokenizers.TokenizationResult) error {
+	return r.encoder.encode(w, r.layoutTokens([]*tokenizers.TokenizationResult{result}))
+}
+
+// Comparison draws multiple tokenization results, one below the other
+func (r *ImageRenderer) Comparison(w io.Writer, results []*tokenizers.TokenizationResult) error {
+	return r.encoder.encode(w, r.layoutTokens(results))
+}
+
+// Counts draws the token count of each result
+func (r *ImageRenderer) Counts(w io.Writer, results []*tokenizers.TokenizationResult) error {
+	b := newImageBuilder(r.columns)
+	b.write("Token Counts", r.theme.htmlColor(r.theme.accent))
+	b.newline()
+	b.newline()
+
+	width := 0
+	for _, result := range results {
+		width = max(width, runewidth.StringWidth(result.Model))
+	}
+	for _, result := range results {
+		b.write(runewidth.FillRight(result.Model, width+2), r.theme.htmlColor(r.theme.label))
+		b.write(fmt.Sprintf("%d tokens", result.TotalCount), r.theme.htmlColor(r.theme.highlight))
+		b.newline()
+	}
+
+	return r.encoder.encode(w, r.finish(b))
+}
+
+// layoutTokens places the header and tokens of each result on the grid
+func (r *ImageRenderer) layoutTokens(results []*tokenizers.TokenizationResult) *imageLayout {
+	b := newImageBuilder(r.columns)
+
+	if r.coloring.HasLegend() {
+		palette := r.coloring.palette(r.theme)
+		b.write(fmt.Sprintf("Colored by %s:", r.coloring.name), r.theme.htmlColor(r.theme.label))
+		for i, label := range r.coloring.legend {
+			b.token(" ■ "+label, r.theme.htmlColor(palette[i]), false)
+		}
+		b.newline()
+		b.newline()
+	}
+
+	for n, result := range results {
+		if n > 0 {
+			b.newline()
+			b.newline()
+		}
+
+		b.write(result.Model, r.theme.htmlColor(r.theme.accent))
+		b.newline()
+		b.write(fmt.Sprintf("Total tokens: %d", result.TotalCount), r.theme.htmlColor(r.theme.label))
+		b.newline()
+		b.newline()
+
+		for i, token := range result.Tokens {
+			b.token(r.tokenText(token.Text), r.theme.htmlColor(r.coloring.swatch(r.theme, result, i)), r.showBoundaries && i > 0)
+			if r.showIDs && token.ID >= 0 {
+				b.token(fmt.Sprintf("[%d]", token.ID), r.theme.htmlColor(r.theme.ids), false)
+			}
+		}
+	}
+
+	return r.finish(b)
+}
+
+// finish completes the layout with the page colors of the theme
+func (r *ImageRenderer) finish(b *imageBuilder) *imageLayout {
+	layout := b.finish()
+	layout.background = r.theme.background
+	layout.dim = r.theme.htmlColor(r.theme.ids)
+	return layout
+}
+
+// tokenText returns the text of a token as it should be drawn. Tabs are
+// expanded, and bytes and control characters that can't be drawn are escaped.
+func (r *ImageRenderer) tokenText(s string) string {
+	if r.visibleWhitespace {
+		return visibleText(s, true)
+	}
+
+	var text strings.Builder
+	for len(s) > 0 {
+		c, size := utf8.DecodeRuneInString(s)
+		switch {
+		case c == utf8.RuneError && size <= 1:
+			text.WriteString(fmt.Sprintf("<0x%02X>", s[0]))
+		case c == '\t':
+			text.WriteString("    ")
+		case c == '\r':
+		case c != '\n' && unicode.IsControl(c):
+			text.WriteString(fmt.Sprintf("<U+%04X>", c))
+		default:
+			text.WriteRune(c)
+		}
+		s = s[size:]
+	}
+	return text.String()
+}
+
+// imageBuilder flows text onto the grid, wrapping at the column limit
+type imageBuilder struct {
+	layout   imageLayout
+	columns  int
+	col, row int
+	text     strings.Builder // Text of the last run, stored in it by endRun
+}
+
+func newImageBuilder(columns int) *imageBuilder {
+	return &imageBuilder{columns: columns}
+}
+
+// token writes text that is kept on one line if it fits, moving it to the
+// next line rather than splitting it
+func (b *imageBuilder) token(text, color string, boundary bool) {
+	first, _, _ := strings.Cut(text, "\n")
+	width := runewidth.StringWidth(first)
+	if b.col > 0 && b.col+width > b.columns && width <= b.columns {
+		b.newline()
+	}
+	if boundary {
+		b.layout.boundaries = append(b.layout.boundaries, imageCell{col: b.col, row: b.row})
+	}
+	b.write(text, color)
+}
+
+// write writes text character by character, wrapping wherever the line is full
+func (b *imageBuilder) write(text, color string) {
+	for _, c := range text {
+		if c == '\n' {
+			b.newline()
+			continue
+		}
+
+		width := runewidth.RuneWidth(c)
+		if b.col+width > b.columns && b.col > 0 {
+			b.newline()
+		}
+
+		runs := b.layout.runs
+		if n := len(runs); n > 0 && runs[n-1].row == b.row && runs[n-1].col+runs[n-1].cells == b.col && runs[n-1].color == color {
+			runs[n-1].cells += width
+		} else {
+			b.endRun()
+			b.layout.runs = append(runs, imageRun{col: b.col, row: b.row, cells: width, color: color})
+		}
+		b.text.WriteRune(c)
+
+		b.col += width
+		b.layout.cols = max(b.layout.cols, b.col)
+	}
+}
+
+func (b *imageBuilder) newline() {
+	b.col = 0
+	b.row++
+}
+
+// endRun stores the text written since the last run started in that run
+func (b *imageBuilder) endRun() {
+	if n := len(b.layout.runs); n > 0 {
+		b.layout.runs[n-1].text = b.text.String()
+	}
+	b.text.Reset()
+}
+
+// finish returns the layout, trimming trailing empty lines
+func (b *imageBuilder) finish() *imageLayout {
+	b.endRun()
+	b.layout.rows = b.row + 1
+	if b.col == 0 {
+		b.layout.rows = b.row
+	}
+	b.layout.cols = max(b.layout.cols, 1)
+	b.layout.rows = max(b.layout.rows, 1)
+	return &b.layout
+}
@@ -0,0 +1,81 @@
+package output
+
+import (
+	"fmt"
+	"image/color"
+	"strconv"
+	"sync"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gomono"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+// Go Mono is embedded in the binary and in SVG output, so images don't depend
+// on the fonts installed where they are made or viewed
+var (
+	imageFontOnce sync.Once
+	imageFont     *opentype.Font
+	imageFontErr  error
+)
+
+// Font size and line height of image output, in pixels at 1x
+const (
+	imageFontSize   = 14
+	imageLineHeight = 1.6 // Multiple of the font size
+	imagePadding    = 20
+)
+
+// imageMetrics is the size of a character cell
+type imageMetrics struct {
+	face       font.Face
+	cellWidth  float64
+	lineHeight float64
+	baseline   float64 // Offset of the baseline from the top of a line
+}
+
+// newImageMetrics loads Go Mono at the given size
+func newImageMetrics(size float64, hinting font.Hinting) (*imageMetrics, error) {
+	imageFontOnce.Do(func() {
+		imageFont, imageFontErr = opentype.Parse(gomono.TTF)
+	})
+	if imageFontErr != nil {
+		return nil, fmt.Errorf("failed to load font: %w", imageFontErr)
+	}
+
+	face, err := opentype.NewFace(imageFont, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: hinting})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load font: %w", err)
+	}
+
+	advance, _ := face.GlyphAdvance('0')
+	metrics := face.Metrics()
+	lineHeight := size * imageLineHeight
+
+	// Center the glyph box of ascent plus descent in the line
+	ascent, descent := fixedFloat(metrics.Ascent), fixedFloat(metrics.Descent)
+	return &imageMetrics{
+		face:       face,
+		cellWidth:  fixedFloat(advance),
+		lineHeight: lineHeight,
+		baseline:   (lineHeight-ascent-descent)/2 + ascent,
+	}, nil
+}
+
+// fixedFloat converts a 26.6 fixed-point value to pixels
+func fixedFloat(x fixed.Int26_6) float64 {
+	return float64(x) / 64
+}
+
+// parseHexColor parses a #rrggbb color
+func parseHexColor(hex string) color.RGBA {
+	if len(hex) != 7 || hex[0] != '#' {
+		return parseHexColor(builtinThemes[DefaultTheme].neutral.html)
+	}
+	value, err := strconv.ParseUint(hex[1:], 16, 32)
+	if err != nil {
+		return parseHexColor(builtinThemes[DefaultTheme].neutral.html)
+	}
+	return color.RGBA{R: uint8(value >> 16), G: uint8(value >> 8), B: uint8(value), A: 0xff}
+}
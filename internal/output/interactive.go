@@ -0,0 +1,232 @@
+package output
+
+import (
+	_ "embed"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/spandigital/token-visualizer/internal/align"
+	"github.com/spandigital/token-visualizer/internal/metrics"
+	"github.com/spandigital/token-visualizer/internal/tokenizers"
+)
+
+//go:embed static/interactive.css
+var interactiveCSS string
+
+//go:embed static/interactive.js
+var interactiveJS string
+
+// HTMLInteractiveRenderer generates a self-contained HTML page with token
+// tooltips, selection of repeated tokens, search and keyboard navigation. Views
+// without interactive support fall back to the inline HTML renderer.
+type HTMLInteractiveRenderer struct {
+	*HTMLInlineRenderer
+}
+
+// NewHTMLInteractiveRenderer creates a new interactive HTML renderer. showIDs
+// and showBoundaries set the initial state of the toggles.
+func NewHTMLInteractiveRenderer(showIDs, showBoundaries bool) *HTMLInteractiveRenderer {
+	return &HTMLInteractiveRenderer{HTMLInlineRenderer: NewHTMLInlineRenderer(showIDs, showBoundaries)}
+}
+
+// WithTheme sets the colors of the output
+func (r *HTMLInteractiveRenderer) WithTheme(theme *Theme) *HTMLInteractiveRenderer {
+	r.HTMLInlineRenderer.WithTheme(theme)
+	return r
+}
+
+// WithColoring sets the strategy used to color tokens
+func (r *HTMLInteractiveRenderer) WithColoring(coloring *Coloring) *HTMLInteractiveRenderer {
+	r.HTMLInlineRenderer.WithColoring(coloring)
+	return r
+}
+
+// WithVisibleWhitespace shows spaces, tabs, line breaks and invisible characters as markers
+func (r *HTMLInteractiveRenderer) WithVisibleWhitespace(visible bool) *HTMLInteractiveRenderer {
+	r.HTMLInlineRenderer.WithVisibleWhitespace(visible)
+	return r
+}
+
+// RenderSingle renders a single tokenization result as an interactive page
+func (r *HTMLInteractiveRenderer) RenderSingle(result *tokenizers.TokenizationResult) string {
+	var body strings.Builder
+	if r.coloring.HasLegend() {
+		body.WriteString(r.coloring.htmlLegend())
+	}
+	body.WriteString(fmt.Sprintf("<div class=\"model-header\">%s</div>\n", escapeHTML(result.Model)))
+	body.WriteString(fmt.Sprintf("<div class=\"token-count\">Total tokens: %d</div>\n", result.TotalCount))
+	body.WriteString(r.tokenSpans(result, "", nil))
+
+	return r.page("Token Visualization", "container", body.String())
+}
+
+// RenderComparison renders multiple tokenization results as a comparison
+// report: a summary chart of cost per model, and model columns that scroll
+// together and highlight the tokens covering the hovered character in every
+// column. Spans where the models place their boundaries differently are marked.
+func (r *HTMLInteractiveRenderer) RenderComparison(results []*tokenizers.TokenizationResult) string {
+	var body strings.Builder
+	aligned := align.Compute(results)
+
+	body.WriteString(summaryChart(results))
+	body.WriteString(fmt.Sprintf("<div class=\"aligned-legend\">%s · <span class=\"divergent\">%s</span></div>\n",
+		pluralize(aligned.Shared, "shared boundary"), pluralize(aligned.Divergent, "divergent span")))
+	if r.coloring.HasLegend() {
+		body.WriteString(r.coloring.htmlLegend())
+	}
+
+	// Model columns
+	body.WriteString("<div class=\"report-columns\">\n")
+	for i, result := range results {
+		body.WriteString("<div class=\"report-column\">\n")
+		body.WriteString(fmt.Sprintf("<div class=\"model-header\">%s</div>\n", escapeHTML(result.Model)))
+		body.WriteString(fmt.Sprintf("<div class=\"token-count\">Total tokens: %d</div>\n", result.TotalCount))
+		body.WriteString(r.tokenSpans(result, "report-tokens", divergentSpans(aligned, i)))
+		body.WriteString("</div>\n")
+	}
+	body.WriteString("</div>\n")
+
+	return r.page("Token Comparison Report", "container report", body.String())
+}
+
+// page wraps body in the page with the styles, toolbar, tooltip and script.
+// class is the class of the container the toolbar and body go in.
+func (r *HTMLInteractiveRenderer) page(title, class, body string) string {
+	var html strings.Builder
+
+	// HTML header with CSS
+	html.WriteString("<!DOCTYPE html>\n<html>\n<head>\n")
+	html.WriteString("<meta charset=\"UTF-8\">\n")
+	html.WriteString("<meta name=\"viewport\" content=\"width=device-width, initial-scale=1.0\">\n")
+	html.WriteString(fmt.Sprintf("<title>%s</title>\n", title))
+	html.WriteString(r.generateCSS())
+	html.WriteString("<style>\n" + interactiveCSS + "</style>\n")
+	html.WriteString(fmt.Sprintf("</head>\n<body>\n<div class=\"%s\">\n", class))
+
+	html.WriteString(r.toolbar())
+	html.WriteString(body)
+
+	// Footer with the tooltip and script
+	html.WriteString("</div>\n<div id=\"tooltip\" role=\"tooltip\"></div>\n")
+	html.WriteString("<script>\n" + interactiveJS + "</script>\n")
+	html.WriteString("</body>\n</html>\n")
+
+	return html.String()
+}
+
+// summaryChart renders the token count, characters per token and cost
+// relative to the cheapest model as bars
+func summaryChart(results []*tokenizers.TokenizationResult) string {
+	maxCount, minCount, maxChars := 0, 0, 0.0
+	chars := make([]float64, len(results))
+	for i, result := range results {
+		chars[i] = metrics.Compute(result).Overall.CharsPerToken()
+		maxCount = max(maxCount, result.TotalCount)
+		maxChars = max(maxChars, chars[i])
+		if i == 0 || result.TotalCount < minCount {
+			minCount = result.TotalCount
+		}
+	}
+
+	bar := func(value, scale float64, label string) string {
+		width := 0.0
+		if scale > 0 {
+			width = value / scale * 100
+		}
+		return fmt.Sprintf("<div class=\"bar\"><span class=\"bar-fill\" style=\"width: %.1f%%\"></span></div><span class=\"bar-value\">%s</span>", width, label)
+	}
+
+	var html strings.Builder
+	html.WriteString("<table class=\"stats-table summary-table\">\n")
+	html.WriteString("<tr><th>Model</th><th>Tokens</th><th>Chars/token</th><th>Relative cost</th></tr>\n")
+	for i, result := range results {
+		cost := "n/a"
+		relative := 0.0
+		if minCount > 0 {
+			relative = float64(result.TotalCount) / float64(minCount)
+			cost = fmt.Sprintf("%.2f×", relative)
+		}
+		html.WriteString(fmt.Sprintf("<tr><td>%s</td><td>%s</td><td>%s</td><td>%s</td></tr>\n",
+			escapeHTML(result.Model),
+			bar(float64(result.TotalCount), float64(maxCount), strconv.Itoa(result.TotalCount)),
+			bar(chars[i], maxChars, fmt.Sprintf("%.2f", chars[i])),
+			bar(relative, float64(maxCount)/float64(max(minCount, 1)), cost)))
+	}
+	html.WriteString("</table>\n")
+
+	return html.String()
+}
+
+// divergentSpans returns the byte ranges of the divergent segments for the
+// result at index, or nil if it was left out of the alignment
+func divergentSpans(aligned *align.Result, index int) [][2]int {
+	if !slices.Contains(aligned.Indices, index) {
+		return nil
+	}
+
+	var spans [][2]int
+	for _, segment := range aligned.Segments {
+		if segment.Divergent {
+			spans = append(spans, [2]int{segment.Start, segment.End})
+		}
+	}
+	return spans
+}
+
+// toolbar renders the search box, toggles and keyboard help
+func (r *HTMLInteractiveRenderer) toolbar() string {
+	checked := func(on bool) string {
+		if on {
+			return " checked"
+		}
+		return ""
+	}
+
+	var html strings.Builder
+	html.WriteString("<div class=\"toolbar\">\n")
+	html.WriteString("<input type=\"search\" id=\"search\" placeholder=\"Search text or #ID\" aria-label=\"Search tokens\">\n")
+	html.WriteString(fmt.Sprintf("<label><input type=\"checkbox\" id=\"show-ids\"%s> IDs</label>\n", checked(r.showIDs)))
+	html.WriteString(fmt.Sprintf("<label><input type=\"checkbox\" id=\"show-boundaries\"%s> Boundaries</label>\n", checked(r.showBoundaries)))
+	html.WriteString("<span class=\"status\" id=\"status\" aria-live=\"polite\"></span>\n")
+	html.WriteString("<span class=\"keys\">←/→ move · Enter select · n/N next/previous · / search · i IDs · b boundaries · Esc clear</span>\n")
+	html.WriteString("</div>\n")
+	return html.String()
+}
+
+// tokenSpans renders the tokens of a result with the data the script needs,
+// marking tokens that overlap one of the divergent byte ranges. Only the first
+// token is a tab stop; the arrow keys move between the others.
+func (r *HTMLInteractiveRenderer) tokenSpans(result *tokenizers.TokenizationResult, class string, divergent [][2]int) string {
+	var html strings.Builder
+
+	html.WriteString(fmt.Sprintf("<div class=\"tokens %s\" aria-label=\"%s tokens\">\n", class, escapeHTML(result.Model)))
+	next := 0
+	for i, token := range result.Tokens {
+		tabIndex := -1
+		if i == 0 {
+			tabIndex = 0
+		}
+
+		classes := "token " + r.coloring.htmlClass(r.theme, result, i)
+		for next < len(divergent) && divergent[next][1] <= token.Start {
+			next++
+		}
+		if next < len(divergent) && divergent[next][0] < token.End {
+			classes += " divergent"
+		}
+
+		html.WriteString(fmt.Sprintf(
+			"<span class=\"%s\" tabindex=\"%d\" data-index=\"%d\" data-id=\"%d\" data-start=\"%d\" data-end=\"%d\" data-text=\"%s\">%s</span>",
+			classes, tabIndex, i, token.ID, token.Start, token.End,
+			escapeHTML(strconv.Quote(token.Text)), r.tokenText(token.Text)))
+
+		if token.ID >= 0 {
+			html.WriteString(fmt.Sprintf("<span class=\"token-id\">%d</span>", token.ID))
+		}
+	}
+	html.WriteString("\n</div>\n")
+
+	return html.String()
+}
@@ -0,0 +1,64 @@
+package output
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spandigital/token-visualizer/internal/tokenizers"
+)
+
+func TestInteractivePages(t *testing.T) {
+	result := &tokenizers.TokenizationResult{Model: "<m>", Text: "ab", TotalCount: 2, Tokens: []tokenizers.Token{
+		{Text: "a", ID: 1, Start: 0, End: 1},
+		{Text: "b", ID: 2, Start: 1, End: 2},
+	}}
+	r := NewHTMLInteractiveRenderer(false, false)
+
+	tests := []struct {
+		name      string
+		page      string
+		title     string
+		container string
+	}{
+		{"single", r.RenderSingle(result), "Token Visualization", `<div class="container">`},
+		{"comparison", r.RenderComparison([]*tokenizers.TokenizationResult{result, result}), "Token Comparison Report", `<div class="container report">`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !strings.HasPrefix(tt.page, "<!DOCTYPE html>") || !strings.HasSuffix(tt.page, "</html>\n") {
+				t.Error("page isn't a complete HTML document")
+			}
+			for _, part := range []string{"<title>" + tt.title + "</title>", tt.container, `<div class="toolbar">`, `<div id="tooltip"`, "<script>"} {
+				if strings.Count(tt.page, part) != 1 {
+					t.Errorf("page has %d of %q, want 1", strings.Count(tt.page, part), part)
+				}
+			}
+			// The body goes between the toolbar and the tooltip
+			toolbar, model, tooltip := strings.Index(tt.page, `class="toolbar"`), strings.Index(tt.page, "&lt;m&gt;"), strings.Index(tt.page, `id="tooltip"`)
+			if !(toolbar < model && model < tooltip) {
+				t.Errorf("model header at %d, want it between the toolbar at %d and the tooltip at %d", model, toolbar, tooltip)
+			}
+		})
+	}
+}
+
+func TestInteractiveComparisonLegend(t *testing.T) {
+	tests := []struct {
+		name    string
+		results []*tokenizers.TokenizationResult
+		want    string
+	}{
+		{"singular", []*tokenizers.TokenizationResult{pieces("a", "ab", "c"), pieces("b", "ab", "c")},
+			`1 shared boundary · <span class="divergent">0 divergent spans</span>`},
+		{"plural", []*tokenizers.TokenizationResult{pieces("a", "ab", " cd", "e"), pieces("b", "ab", " c", "d", "e")},
+			`2 shared boundaries · <span class="divergent">1 divergent span</span>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page := NewHTMLInteractiveRenderer(false, false).RenderComparison(tt.results)
+			if !strings.Contains(page, tt.want) {
+				t.Errorf("page doesn't contain the legend %q", tt.want)
+			}
+		})
+	}
+}
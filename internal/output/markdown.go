@@ -3,9 +3,16 @@ package output
 import (
 	"bytes"
 	"fmt"
+	"strconv"
 	"strings"
 
+	"github.com/spandigital/token-visualizer/internal/align"
+	"github.com/spandigital/token-visualizer/internal/bytegroup"
+	"github.com/spandigital/token-visualizer/internal/diff"
+	"github.com/spandigital/token-visualizer/internal/metrics"
+	"github.com/spandigital/token-visualizer/internal/outline"
 	"github.com/spandigital/token-visualizer/internal/tokenizers"
+	"github.com/spandigital/token-visualizer/internal/vocab"
 	"github.com/yuin/goldmark"
 	"github.com/yuin/goldmark/extension"
 	"github.com/yuin/goldmark/renderer/html"
@@ -13,16 +20,40 @@ import (
 
 // MarkdownRenderer renders tokenization results as markdown
 type MarkdownRenderer struct {
-	showIDs bool
+	showIDs           bool
+	visibleWhitespace bool
+	coloring          *Coloring
 }
 
 // NewMarkdownRenderer creates a new markdown renderer
 func NewMarkdownRenderer(showIDs bool) *MarkdownRenderer {
 	return &MarkdownRenderer{
-		showIDs: showIDs,
+		showIDs:  showIDs,
+		coloring: cycleColoring,
 	}
 }
 
+// WithColoring sets the strategy used to classify tokens; markdown has no
+// colors, so heatmap strategies add a column with each token's legend label
+func (r *MarkdownRenderer) WithColoring(coloring *Coloring) *MarkdownRenderer {
+	r.coloring = coloring
+	return r
+}
+
+// WithVisibleWhitespace shows spaces, tabs, line breaks and invisible characters as markers
+func (r *MarkdownRenderer) WithVisibleWhitespace(visible bool) *MarkdownRenderer {
+	r.visibleWhitespace = visible
+	return r
+}
+
+// tokenText returns the text of a token on a single line
+func (r *MarkdownRenderer) tokenText(s string) string {
+	if r.visibleWhitespace {
+		return visibleText(s, false)
+	}
+	return strings.ReplaceAll(s, "\n", "\\n")
+}
+
 // RenderSingle renders a single tokenization result as markdown
 func (r *MarkdownRenderer) RenderSingle(result *tokenizers.TokenizationResult) string {
 	var md strings.Builder
@@ -32,19 +63,7 @@ func (r *MarkdownRenderer) RenderSingle(result *tokenizers.TokenizationResult) s
 
 	if len(result.Tokens) > 0 && result.Tokens[0].ID >= 0 {
 		md.WriteString("## Tokens\n\n")
-		md.WriteString("| # | Text | ID |\n")
-		md.WriteString("|---|------|----|\n")
-
-		for i, token := range result.Tokens {
-			text := strings.ReplaceAll(token.Text, "|", "\\|")
-			text = strings.ReplaceAll(text, "\n", "\\n")
-
-			if r.showIDs {
-				md.WriteString(fmt.Sprintf("| %d | `%s` | %d |\n", i+1, text, token.ID))
-			} else {
-				md.WriteString(fmt.Sprintf("| %d | `%s` | |\n", i+1, text))
-			}
-		}
+		md.WriteString(r.tokenTable(result))
 	} else {
 		md.WriteString("## Text\n\n")
 		md.WriteString(fmt.Sprintf("```\n%s\n```\n", result.Text))
@@ -83,19 +102,7 @@ func (r *MarkdownRenderer) RenderComparison(results []*tokenizers.TokenizationRe
 		md.WriteString(fmt.Sprintf("## %s\n\n", result.Model))
 
 		if len(result.Tokens) > 0 && result.Tokens[0].ID >= 0 {
-			md.WriteString("| # | Text | ID |\n")
-			md.WriteString("|---|------|----|\n")
-
-			for i, token := range result.Tokens {
-				text := strings.ReplaceAll(token.Text, "|", "\\|")
-				text = strings.ReplaceAll(text, "\n", "\\n")
-
-				if r.showIDs {
-					md.WriteString(fmt.Sprintf("| %d | `%s` | %d |\n", i+1, text, token.ID))
-				} else {
-					md.WriteString(fmt.Sprintf("| %d | `%s` | |\n", i+1, text))
-				}
-			}
+			md.WriteString(r.tokenTable(result))
 		} else {
 			md.WriteString(fmt.Sprintf("**Total tokens:** %d\n", result.TotalCount))
 		}
@@ -106,6 +113,94 @@ func (r *MarkdownRenderer) RenderComparison(results []*tokenizers.TokenizationRe
 	return md.String()
 }
 
+// tokenTable renders the tokens of a result as a table, with a legend and
+// label column when a heatmap coloring is set
+func (r *MarkdownRenderer) tokenTable(result *tokenizers.TokenizationResult) string {
+	var md strings.Builder
+
+	legend := r.coloring.HasLegend()
+	if legend {
+		md.WriteString(r.coloring.markdownLegend())
+		name := r.coloring.Name()
+		md.WriteString(fmt.Sprintf("| # | Text | ID | %s |\n", strings.ToUpper(name[:1])+name[1:]))
+		md.WriteString("|---|------|----|----|\n")
+	} else {
+		md.WriteString("| # | Text | ID |\n")
+		md.WriteString("|---|------|----|\n")
+	}
+
+	for i, token := range result.Tokens {
+		text := strings.ReplaceAll(r.tokenText(token.Text), "|", "\\|")
+
+		id := " "
+		if r.showIDs {
+			id = fmt.Sprintf(" %d ", token.ID)
+		}
+
+		if legend {
+			md.WriteString(fmt.Sprintf("| %d | `%s` |%s| %s |\n", i+1, text, id, r.coloring.Label(result, i)))
+		} else {
+			md.WriteString(fmt.Sprintf("| %d | `%s` |%s|\n", i+1, text, id))
+		}
+	}
+
+	return md.String()
+}
+
+// RenderStages renders the layers of the tokenization pipeline as markdown
+func (r *MarkdownRenderer) RenderStages(stages *tokenizers.Stages) string {
+	var md strings.Builder
+	result := stages.Result
+
+	md.WriteString(fmt.Sprintf("# %s\n\n", result.Model))
+
+	md.WriteString("## Normalized Text\n\n")
+	if stages.Normalized == result.Text {
+		md.WriteString("Unchanged by normalization.\n\n")
+	}
+	md.WriteString(fmt.Sprintf("```\n%s\n```\n\n", stages.Normalized))
+
+	md.WriteString("## Pre-tokenized Chunks\n\n")
+	md.WriteString(fmt.Sprintf("**Total chunks:** %d\n\n", len(stages.Chunks)))
+	md.WriteString("| # | Text | Bytes |\n")
+	md.WriteString("|---|------|-------|\n")
+	for i, chunk := range stages.Chunks {
+		text := strings.ReplaceAll(r.tokenText(chunk.Text), "|", "\\|")
+		md.WriteString(fmt.Sprintf("| %d | `%s` | %d-%d |\n", i+1, text, chunk.Start, chunk.End))
+	}
+	md.WriteString("\n")
+
+	md.WriteString("## Tokens\n\n")
+	md.WriteString(fmt.Sprintf("**Total tokens:** %d\n\n", result.TotalCount))
+	md.WriteString(r.tokenTable(result))
+
+	return md.String()
+}
+
+// RenderBytes renders every token with its raw bytes in hex as a markdown table
+func (r *MarkdownRenderer) RenderBytes(result *tokenizers.TokenizationResult) string {
+	var md strings.Builder
+
+	groups := bytegroup.Compute(result.Tokens)
+
+	md.WriteString(fmt.Sprintf("# %s\n\n", result.Model))
+	md.WriteString(fmt.Sprintf("**%s**\n\n", bytesStats(result, groups)))
+
+	md.WriteString("| # | ID | Bytes | Text | Character |\n")
+	md.WriteString("|---|----|-------|------|-----------|\n")
+	i := 0
+	for _, group := range groups {
+		for j, token := range group.Tokens {
+			text := strings.ReplaceAll(visibleText(token.Text, false), "|", "\\|")
+			marker := strings.ReplaceAll(groupMarker(group, j), "|", "\\|")
+			md.WriteString(fmt.Sprintf("| %d | %s | `%x` | `%s` | %s |\n", i+1, tokenID(token), token.Text, text, marker))
+			i++
+		}
+	}
+
+	return md.String()
+}
+
 // RenderCountOnly renders just token counts as markdown
 func (r *MarkdownRenderer) RenderCountOnly(results []*tokenizers.TokenizationResult) string {
 	var md strings.Builder
@@ -121,6 +216,271 @@ func (r *MarkdownRenderer) RenderCountOnly(results []*tokenizers.TokenizationRes
 	return md.String()
 }
 
+// RenderDiff renders token-level diffs as markdown
+func (r *MarkdownRenderer) RenderDiff(results []*diff.Result) string {
+	var md strings.Builder
+
+	md.WriteString("# Token Diff\n\n")
+
+	// Summary table
+	md.WriteString("| Model | Old | New | Delta | Inserted | Deleted |\n")
+	md.WriteString("|-------|-----|-----|-------|----------|---------|\n")
+
+	for _, result := range results {
+		md.WriteString(fmt.Sprintf("| %s | %d | %d | %s | %d | %d |\n",
+			result.Model, result.Old.TotalCount, result.New.TotalCount, formatDelta(result.Delta()), result.Inserted, result.Deleted))
+	}
+
+	md.WriteString("\n")
+
+	// Changed tokens per model
+	for _, result := range results {
+		if len(result.New.Tokens) > 0 && result.New.Tokens[0].ID < 0 {
+			continue
+		}
+
+		md.WriteString(fmt.Sprintf("## %s\n\n", result.Model))
+
+		hunks := result.Hunks()
+		if len(hunks) == 0 {
+			md.WriteString("No token changes.\n\n")
+			continue
+		}
+
+		md.WriteString("```diff\n")
+		for _, hunk := range hunks {
+			if len(hunk.Deleted) > 0 {
+				md.WriteString(fmt.Sprintf("- %s\n", r.diffTokens(hunk.Deleted)))
+			}
+			if len(hunk.Inserted) > 0 {
+				md.WriteString(fmt.Sprintf("+ %s\n", r.diffTokens(hunk.Inserted)))
+			}
+		}
+		md.WriteString("```\n\n")
+	}
+
+	return md.String()
+}
+
+// diffTokens joins the tokens of a hunk with boundaries and optional IDs
+func (r *MarkdownRenderer) diffTokens(tokens []tokenizers.Token) string {
+	parts := make([]string, len(tokens))
+	for i, token := range tokens {
+		text := r.tokenText(token.Text)
+		if r.showIDs {
+			parts[i] = fmt.Sprintf("%s(%d)", text, token.ID)
+		} else {
+			parts[i] = text
+		}
+	}
+	return strings.Join(parts, "|")
+}
+
+// RenderEfficiency renders characters, bytes and words per token as markdown tables
+func (r *MarkdownRenderer) RenderEfficiency(reports []*metrics.Report) string {
+	var md strings.Builder
+
+	md.WriteString("# Tokenization Efficiency\n\n")
+
+	rows := make([][]string, len(reports))
+	for i, report := range reports {
+		rows[i] = append([]string{report.Model}, efficiencyCells(report.Overall)...)
+	}
+	md.WriteString(markdownTable(append([]string{"Model"}, efficiencyHeaders...), rows))
+	md.WriteString("\n")
+
+	for _, report := range reports {
+		if len(report.Scripts) == 0 {
+			continue
+		}
+
+		md.WriteString(fmt.Sprintf("## %s\n\n", report.Model))
+		md.WriteString("### By Script\n\n")
+		md.WriteString(markdownTable(append([]string{"Script"}, efficiencyHeaders...), groupRows(report.Scripts)))
+		md.WriteString("\n### By Class\n\n")
+		md.WriteString(markdownTable(append([]string{"Class"}, efficiencyHeaders...), groupRows(report.Classes)))
+		md.WriteString("\n")
+	}
+
+	return md.String()
+}
+
+// RenderSections renders the tokens of each part of a markdown document as a
+// markdown table, indented like the document outline
+func (r *MarkdownRenderer) RenderSections(report *outline.Report) string {
+	var md strings.Builder
+
+	md.WriteString("# Section Budget\n\n")
+	for _, model := range report.Skipped {
+		md.WriteString(fmt.Sprintf("%s has no individual tokens and is not broken down.\n\n", model))
+	}
+
+	if len(report.Models) == 0 {
+		return md.String()
+	}
+
+	rows := sectionRows(report, "\u00a0\u00a0")
+	for _, row := range rows {
+		row[0] = strings.ReplaceAll(row[0], "|", "\\|")
+	}
+	md.WriteString(markdownTable(sectionHeaders(report), rows))
+
+	return md.String()
+}
+
+// RenderAligned renders the divergent spans of an aligned comparison as a
+// markdown table, with each model's pieces in its own column
+func (r *MarkdownRenderer) RenderAligned(result *align.Result) string {
+	var md strings.Builder
+
+	md.WriteString("# Aligned Comparison\n\n")
+	md.WriteString(fmt.Sprintf("**%s · %s**\n\n", pluralize(result.Shared, "shared boundary"), pluralize(result.Divergent, "divergent span")))
+	for _, model := range result.Skipped {
+		md.WriteString(fmt.Sprintf("%s has no individual tokens and is not aligned.\n\n", model))
+	}
+
+	if result.Divergent == 0 || len(result.Models) == 0 {
+		return md.String()
+	}
+
+	md.WriteString("| Bytes | " + strings.Join(result.Models, " | ") + " |\n")
+	md.WriteString("|---" + strings.Repeat("|---", len(result.Models)) + "|\n")
+	for _, segment := range result.Segments {
+		if !segment.Divergent {
+			continue
+		}
+
+		cells := make([]string, len(result.Models))
+		for m := range result.Models {
+			pieces := make([]string, len(segment.Pieces[m]))
+			for i, piece := range segment.Pieces[m] {
+				text := markdownCode(r.tokenText(alignedPieceText(result.Text, segment, m, i)))
+				if r.showIDs {
+					text += fmt.Sprintf(" (%s)", pieceIDs(piece))
+				}
+				pieces[i] = text
+			}
+			cells[m] = strings.Join(pieces, " ")
+		}
+		md.WriteString(fmt.Sprintf("| %d-%d | %s |\n", segment.Start, segment.End, strings.Join(cells, " | ")))
+	}
+
+	return md.String()
+}
+
+// RenderMergeTrace renders the BPE merges of each chunk as markdown tables,
+// with the merged part of each step in bold
+func (r *MarkdownRenderer) RenderMergeTrace(model string, traces []tokenizers.MergeTrace) string {
+	var md strings.Builder
+
+	md.WriteString(fmt.Sprintf("# %s merges\n\n", model))
+
+	chunks := make([]string, len(traces))
+	for i, trace := range traces {
+		chunks[i] = markdownCode(visibleText(trace.Chunk.Text, false))
+	}
+	md.WriteString(fmt.Sprintf("**Pre-tokenized into %s:** %s\n\n", pluralize(len(traces), "chunk"), strings.Join(chunks, " ")))
+
+	for i, trace := range traces {
+		md.WriteString(fmt.Sprintf("## Chunk %d: %s\n\n", i+1, markdownCode(visibleText(trace.Chunk.Text, false))))
+		md.WriteString("| Step | Parts | ID |\n")
+		md.WriteString("|------|-------|----|\n")
+		md.WriteString(fmt.Sprintf("| start | %s | |\n", mergeParts(trace.Initial, -1)))
+		for _, step := range trace.Steps {
+			md.WriteString(fmt.Sprintf("| rank %d | %s | %d |\n", step.Rank, mergeParts(step.Parts, step.Index), step.ID))
+		}
+
+		tokens := make([]string, len(trace.Tokens))
+		for j, token := range trace.Tokens {
+			tokens[j] = fmt.Sprintf("%s (%d)", markdownCode(visibleText(token.Text, false)), token.ID)
+		}
+		md.WriteString(fmt.Sprintf("\n**Tokens:** %s\n\n", strings.Join(tokens, " ")))
+	}
+
+	return md.String()
+}
+
+// mergeParts renders the parts of a merge step as code spans, the merged one in bold
+func mergeParts(parts []string, highlight int) string {
+	cells := make([]string, len(parts))
+	for i, part := range parts {
+		cells[i] = markdownCode(visibleText(part, false))
+		if i == highlight {
+			cells[i] = "**" + cells[i] + "**"
+		}
+	}
+	return strings.Join(cells, " ")
+}
+
+// RenderVocab renders vocabulary entries as a markdown table
+func (r *MarkdownRenderer) RenderVocab(model string, entries []vocab.Entry, matched int) string {
+	var md strings.Builder
+
+	md.WriteString(fmt.Sprintf("# %s vocabulary\n\n", model))
+
+	md.WriteString("| ID | Token | Bytes |\n")
+	md.WriteString("|----|-------|-------|\n")
+	for _, entry := range entries {
+		md.WriteString(fmt.Sprintf("| %d | %s | `%s` |\n", entry.ID, markdownCode(entry.Escaped()), entry.Hex()))
+	}
+	md.WriteString("\n")
+
+	if matched > len(entries) {
+		md.WriteString(fmt.Sprintf("Showing %d of %d matches\n", len(entries), matched))
+	} else {
+		md.WriteString(fmt.Sprintf("Matches: %d\n", matched))
+	}
+
+	return md.String()
+}
+
+// RenderLookup renders whether strings are single vocabulary entries as a markdown list
+func (r *MarkdownRenderer) RenderLookup(model string, matches []vocab.Match) string {
+	var md strings.Builder
+
+	md.WriteString(fmt.Sprintf("# %s lookup\n\n", model))
+
+	for _, match := range matches {
+		found := "not in the vocabulary"
+		if match.Entry != nil {
+			found = fmt.Sprintf("single token, ID %d (bytes `%s`)", match.Entry.ID, match.Entry.Hex())
+		}
+
+		tokens := make([]string, len(match.Encoded))
+		for i, token := range match.Encoded {
+			tokens[i] = fmt.Sprintf("%s (%d)", markdownCode(strconv.Quote(lookupTokenText(match, token))), token.ID)
+		}
+
+		md.WriteString(fmt.Sprintf("- %s: %s; encodes as %s: %s\n",
+			markdownCode(strconv.Quote(match.Text)), found, pluralize(len(match.Encoded), "token"), strings.Join(tokens, " ")))
+	}
+
+	return md.String()
+}
+
+// markdownCode wraps text in a code span that is safe inside a table cell
+func markdownCode(text string) string {
+	return "`" + strings.ReplaceAll(text, "|", "\\|") + "`"
+}
+
+// markdownTable renders rows as a markdown table; every column but the first is right-aligned
+func markdownTable(headers []string, rows [][]string) string {
+	var table strings.Builder
+
+	table.WriteString("| " + strings.Join(headers, " | ") + " |\n")
+	table.WriteString("|---")
+	for range headers[1:] {
+		table.WriteString("|--:")
+	}
+	table.WriteString("|\n")
+
+	for _, row := range rows {
+		table.WriteString("| " + strings.Join(row, " | ") + " |\n")
+	}
+
+	return table.String()
+}
+
 // HTMLRenderer converts markdown to HTML
 type HTMLRenderer struct {
 	md goldmark.Markdown
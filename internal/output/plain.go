@@ -0,0 +1,333 @@
+package output
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/spandigital/token-visualizer/internal/align"
+	"github.com/spandigital/token-visualizer/internal/diff"
+	"github.com/spandigital/token-visualizer/internal/metrics"
+	"github.com/spandigital/token-visualizer/internal/outline"
+	"github.com/spandigital/token-visualizer/internal/tokenizers"
+	"github.com/spandigital/token-visualizer/internal/vocab"
+)
+
+// PlainRenderer renders tokenization results as plain text for logs, email
+// and screen readers. Every token is wrapped in brackets, and the output has
+// no ANSI codes, emoji or box drawing, and doesn't depend on the terminal,
+// so it can be diffed against golden files.
+type PlainRenderer struct {
+	showIDs  bool
+	numbered bool
+	coloring *Coloring
+}
+
+// NewPlainRenderer creates a new plain text renderer
+func NewPlainRenderer(showIDs bool) *PlainRenderer {
+	return &PlainRenderer{
+		showIDs:  showIDs,
+		coloring: cycleColoring,
+	}
+}
+
+// WithNumbering lists one numbered token per line instead of running the tokens together
+func (r *PlainRenderer) WithNumbering(numbered bool) *PlainRenderer {
+	r.numbered = numbered
+	return r
+}
+
+// WithColoring sets the strategy used to classify tokens; plain text has no
+// colors, so numbered lists show each token's legend label for heatmap strategies
+func (r *PlainRenderer) WithColoring(coloring *Coloring) *PlainRenderer {
+	r.coloring = coloring
+	return r
+}
+
+// plainText escapes a token so its brackets stay unambiguous: backslashes and
+// brackets are escaped, line breaks and tabs become \n, \r and \t, other
+// invisible characters become \uXXXX, and bytes that are not valid UTF-8 on
+// their own become \xNN
+func plainText(s string) string {
+	var text strings.Builder
+
+	for len(s) > 0 {
+		c, size := utf8.DecodeRuneInString(s)
+		switch {
+		case c == utf8.RuneError && size <= 1:
+			text.WriteString(fmt.Sprintf(`\x%02X`, s[0]))
+		case c == '\\' || c == '[' || c == ']':
+			text.WriteByte('\\')
+			text.WriteRune(c)
+		case c == '\n':
+			text.WriteString(`\n`)
+		case c == '\r':
+			text.WriteString(`\r`)
+		case c == '\t':
+			text.WriteString(`\t`)
+		case c != ' ' && isInvisible(c):
+			if c > 0xffff {
+				text.WriteString(fmt.Sprintf(`\U%08X`, c))
+			} else {
+				text.WriteString(fmt.Sprintf(`\u%04X`, c))
+			}
+		default:
+			text.WriteRune(c)
+		}
+		s = s[size:]
+	}
+
+	return text.String()
+}
+
+// plainToken returns a token in brackets, followed by its ID if IDs are shown
+func (r *PlainRenderer) plainToken(token tokenizers.Token) string {
+	if r.showIDs && token.ID >= 0 {
+		return fmt.Sprintf("[%s](%d)", plainText(token.Text), token.ID)
+	}
+	return fmt.Sprintf("[%s]", plainText(token.Text))
+}
+
+// RenderSingle renders a single tokenization result as plain text
+func (r *PlainRenderer) RenderSingle(result *tokenizers.TokenizationResult) string {
+	var out strings.Builder
+
+	out.WriteString(fmt.Sprintf("Model: %s\n", result.Model))
+	out.WriteString(fmt.Sprintf("Tokens: %d\n", result.TotalCount))
+	if r.coloring.HasLegend() && r.numbered {
+		out.WriteString(fmt.Sprintf("Labeled by: %s\n", r.coloring.name))
+	}
+	out.WriteString("\n")
+	out.WriteString(r.tokens(result))
+
+	return out.String()
+}
+
+// tokens writes the tokens of a result, either run together with a line
+// break after each token that ends a line of the text, or one per line
+func (r *PlainRenderer) tokens(result *tokenizers.TokenizationResult) string {
+	var out strings.Builder
+
+	if r.numbered {
+		width := len(strconv.Itoa(len(result.Tokens)))
+		for i, token := range result.Tokens {
+			out.WriteString(fmt.Sprintf("%*d %s", width, i+1, r.plainToken(token)))
+			if label := r.coloring.Label(result, i); label != "" {
+				out.WriteString(" - " + label)
+			}
+			out.WriteString("\n")
+		}
+		return out.String()
+	}
+
+	lineStart := true
+	for _, token := range result.Tokens {
+		out.WriteString(r.plainToken(token))
+		lineStart = false
+		if strings.Contains(token.Text, "\n") {
+			out.WriteString("\n")
+			lineStart = true
+		}
+	}
+	if !lineStart {
+		out.WriteString("\n")
+	}
+
+	return out.String()
+}
+
+// RenderComparison renders multiple tokenization results one after the other
+func (r *PlainRenderer) RenderComparison(results []*tokenizers.TokenizationResult) string {
+	sections := make([]string, len(results))
+	for i, result := range results {
+		sections[i] = r.RenderSingle(result)
+	}
+	return strings.Join(sections, "\n")
+}
+
+// RenderCountOnly renders just the token counts as a table
+func (r *PlainRenderer) RenderCountOnly(results []*tokenizers.TokenizationResult) string {
+	rows := make([][]string, len(results))
+	for i, result := range results {
+		rows[i] = []string{result.Model, strconv.Itoa(result.TotalCount)}
+	}
+	return plainTable([]string{"Model", "Tokens"}, rows, 1)
+}
+
+// RenderDiff renders token-level diffs, with the deleted and inserted tokens
+// of each change on lines starting with - and +
+func (r *PlainRenderer) RenderDiff(results []*diff.Result) string {
+	var out strings.Builder
+
+	rows := make([][]string, len(results))
+	for i, result := range results {
+		rows[i] = []string{result.Model,
+			strconv.Itoa(result.Old.TotalCount), strconv.Itoa(result.New.TotalCount), formatDelta(result.Delta()),
+			strconv.Itoa(result.Inserted), strconv.Itoa(result.Deleted)}
+	}
+	out.WriteString(plainTable([]string{"Model", "Old", "New", "Delta", "Inserted", "Deleted"}, rows, 1))
+
+	for _, result := range results {
+		if len(result.New.Tokens) > 0 && result.New.Tokens[0].ID < 0 {
+			continue
+		}
+
+		out.WriteString(fmt.Sprintf("\nModel: %s\n", result.Model))
+
+		hunks := result.Hunks()
+		if len(hunks) == 0 {
+			out.WriteString("No token changes\n")
+			continue
+		}
+
+		for _, hunk := range hunks {
+			if len(hunk.Deleted) > 0 {
+				out.WriteString("- " + r.tokenList(hunk.Deleted) + "\n")
+			}
+			if len(hunk.Inserted) > 0 {
+				out.WriteString("+ " + r.tokenList(hunk.Inserted) + "\n")
+			}
+		}
+	}
+
+	return out.String()
+}
+
+// tokenList runs tokens together in brackets
+func (r *PlainRenderer) tokenList(tokens []tokenizers.Token) string {
+	var list strings.Builder
+	for _, token := range tokens {
+		list.WriteString(r.plainToken(token))
+	}
+	return list.String()
+}
+
+// RenderEfficiency renders characters, bytes and words per token as tables
+func (r *PlainRenderer) RenderEfficiency(reports []*metrics.Report) string {
+	var out strings.Builder
+
+	rows := make([][]string, len(reports))
+	for i, report := range reports {
+		rows[i] = append([]string{report.Model}, efficiencyCells(report.Overall)...)
+	}
+	out.WriteString(plainTable(append([]string{"Model"}, efficiencyHeaders...), rows, 1))
+
+	for _, report := range reports {
+		if len(report.Scripts) == 0 {
+			continue
+		}
+
+		out.WriteString(fmt.Sprintf("\nModel: %s\n", report.Model))
+		out.WriteString(plainTable(append([]string{"Script"}, efficiencyHeaders...), groupRows(report.Scripts), 1))
+		out.WriteString("\n")
+		out.WriteString(plainTable(append([]string{"Class"}, efficiencyHeaders...), groupRows(report.Classes), 1))
+	}
+
+	return out.String()
+}
+
+// RenderSections renders the tokens of each part of a markdown document as a
+// table, indented like the document outline
+func (r *PlainRenderer) RenderSections(report *outline.Report) string {
+	var out strings.Builder
+
+	for _, model := range report.Skipped {
+		out.WriteString(fmt.Sprintf("Not broken down: %s (no individual tokens)\n", model))
+	}
+	if len(report.Models) == 0 {
+		return out.String()
+	}
+	if len(report.Skipped) > 0 {
+		out.WriteString("\n")
+	}
+
+	out.WriteString(plainTable(sectionHeaders(report), sectionRows(report, "  "), 1))
+
+	return out.String()
+}
+
+// RenderAligned renders the divergent spans of an aligned comparison, with
+// one line per model under the byte range of each span
+func (r *PlainRenderer) RenderAligned(result *align.Result) string {
+	var out strings.Builder
+
+	out.WriteString(fmt.Sprintf("Shared boundaries: %d\n", result.Shared))
+	out.WriteString(fmt.Sprintf("Divergent spans: %d\n", result.Divergent))
+	for _, model := range result.Skipped {
+		out.WriteString(fmt.Sprintf("Not aligned: %s (no individual tokens)\n", model))
+	}
+
+	labelWidth := 0
+	for _, model := range result.Models {
+		labelWidth = max(labelWidth, utf8.RuneCountInString(model))
+	}
+
+	for _, segment := range result.Segments {
+		if !segment.Divergent {
+			continue
+		}
+
+		out.WriteString(fmt.Sprintf("\nBytes %d-%d\n", segment.Start, segment.End))
+		for m, model := range result.Models {
+			out.WriteString(fmt.Sprintf("  %s%s ", model, strings.Repeat(" ", labelWidth-utf8.RuneCountInString(model))))
+			for i, piece := range segment.Pieces[m] {
+				out.WriteString(fmt.Sprintf("[%s]", plainText(alignedPieceText(result.Text, segment, m, i))))
+				if r.showIDs {
+					out.WriteString(fmt.Sprintf("(%s)", pieceIDs(piece)))
+				}
+			}
+			out.WriteString("\n")
+		}
+	}
+
+	return out.String()
+}
+
+// RenderVocab renders vocabulary entries as a table
+func (r *PlainRenderer) RenderVocab(model string, entries []vocab.Entry, matched int) string {
+	var out strings.Builder
+
+	out.WriteString(fmt.Sprintf("Model: %s\n", model))
+	if matched > len(entries) {
+		out.WriteString(fmt.Sprintf("Showing %d of %d matches\n\n", len(entries), matched))
+	} else {
+		out.WriteString(fmt.Sprintf("Matches: %d\n\n", matched))
+	}
+
+	rows := make([][]string, len(entries))
+	for i, entry := range entries {
+		rows[i] = []string{strconv.Itoa(entry.ID), entry.Escaped(), entry.Hex()}
+	}
+	out.WriteString(plainTable([]string{"ID", "Token", "Bytes"}, rows, 3))
+
+	return out.String()
+}
+
+// RenderLookup renders whether each text is a single token and the tokens it encodes as
+func (r *PlainRenderer) RenderLookup(model string, matches []vocab.Match) string {
+	var out strings.Builder
+
+	out.WriteString(fmt.Sprintf("Model: %s\n", model))
+	for _, match := range matches {
+		out.WriteString(fmt.Sprintf("\nText: %s\n", strconv.Quote(match.Text)))
+		if match.Entry != nil {
+			out.WriteString(fmt.Sprintf("Single token: yes, ID %d, bytes %s\n", match.Entry.ID, match.Entry.Hex()))
+		} else {
+			out.WriteString("Single token: no\n")
+		}
+
+		out.WriteString(fmt.Sprintf("Encodes as %s: ", pluralize(len(match.Encoded), "token")))
+		for _, token := range match.Encoded {
+			out.WriteString(fmt.Sprintf("[%s](%d)", plainText(lookupTokenText(match, token)), token.ID))
+		}
+		out.WriteString("\n")
+	}
+
+	return out.String()
+}
+
+// plainTable renders a table as lines of columns separated by spaces
+func plainTable(headers []string, rows [][]string, leftColumns int) string {
+	return strings.Join(padTable(headers, rows, leftColumns), "\n") + "\n"
+}
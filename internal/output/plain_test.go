@@ -0,0 +1,92 @@
+package output
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spandigital/token-visualizer/internal/diff"
+	"github.com/spandigital/token-visualizer/internal/metrics"
+	"github.com/spandigital/token-visualizer/internal/tokenizers"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// pieces builds a tokenization of the concatenated pieces, with IDs counting up from 1
+func pieces(model string, texts ...string) *tokenizers.TokenizationResult {
+	r := &tokenizers.TokenizationResult{Model: model}
+	for i, text := range texts {
+		start := len(r.Text)
+		r.Text += text
+		r.Tokens = append(r.Tokens, tokenizers.Token{Text: text, ID: i + 1, Start: start, End: len(r.Text)})
+	}
+	r.TotalCount = len(r.Tokens)
+	return r
+}
+
+// withIDs sets the IDs of the tokens of r
+func withIDs(r *tokenizers.TokenizationResult, ids ...int) *tokenizers.TokenizationResult {
+	for i, id := range ids {
+		r.Tokens[i].ID = id
+	}
+	return r
+}
+
+// checkGolden compares output with testdata/name, or rewrites the file with -update
+func checkGolden(t *testing.T, name, output string) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, []byte(output), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output != string(want) {
+		t.Errorf("output differs from %s (rerun with -update to accept it):\ngot:\n%s\nwant:\n%s", path, output, want)
+	}
+}
+
+func TestPlainGolden(t *testing.T) {
+	// Brackets and backslashes, control characters, an invisible character,
+	// a line break, and the two halves of "é" as separate tokens
+	tricky := pieces("tricky",
+		"[x]", " a\\b", "\x00\x1b", "\t", "\u200b", "zwj\u200d", "\r\n",
+		"caf", "\xc3", "\xa9", " \xff\xfe", " \U0001F600", "\n")
+	// The same text with different splits
+	other := pieces("other",
+		"[", "x", "]", " a", "\\b", "\x00", "\x1b\t", "\u200b", "zwj", "\u200d\r\n",
+		"café", " ", "\xff", "\xfe", " \U0001F600\n")
+
+	length, err := NewColoring("length")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		output string
+	}{
+		{"plain_single.golden", NewPlainRenderer(false).RenderSingle(tricky)},
+		{"plain_single_ids.golden", NewPlainRenderer(true).RenderSingle(tricky)},
+		{"plain_numbered.golden", NewPlainRenderer(true).WithNumbering(true).WithColoring(length).RenderSingle(tricky)},
+		{"plain_compare.golden", NewPlainRenderer(false).RenderComparison([]*tokenizers.TokenizationResult{tricky, other})},
+		{"plain_diff.golden", NewPlainRenderer(true).RenderDiff([]*diff.Result{
+			diff.Compute("tricky",
+				withIDs(pieces("tricky", "[a]", " b", "\t", "\xff"), 10, 11, 12, 13),
+				withIDs(pieces("tricky", "[a]", " c", "\\", "\xff", "\x00"), 10, 20, 21, 13, 22)),
+			diff.Compute("same", pieces("same", "x"), pieces("same", "x")),
+		})},
+		{"plain_efficiency.golden", NewPlainRenderer(false).RenderEfficiency([]*metrics.Report{metrics.Compute(tricky), metrics.Compute(other)})},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkGolden(t, tt.name, tt.output)
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package output
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"io"
+	"math"
+
+	"github.com/mattn/go-runewidth"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// pngScale renders PNG output at twice the size of SVG output, so it stays
+// sharp on high-density screens and in slides
+const pngScale = 2
+
+// pngMaxPixels caps the size of PNG output. The image is held in memory at 4
+// bytes per pixel, so this is 256 MB, about 1,000 lines at 80 columns.
+const pngMaxPixels = 64 << 20
+
+// pngEncoder rasterizes a layout with Go Mono. Characters missing from Go Mono
+// are drawn as boxes.
+type pngEncoder struct{}
+
+func (pngEncoder) contentType() string {
+	return "image/png"
+}
+
+func (pngEncoder) encode(w io.Writer, layout *imageLayout) error {
+	metrics, err := newImageMetrics(imageFontSize*pngScale, font.HintingFull)
+	if err != nil {
+		return err
+	}
+
+	padding := float64(imagePadding * pngScale)
+	width := int(math.Ceil(2*padding + float64(layout.cols)*metrics.cellWidth))
+	height := int(math.Ceil(2*padding + float64(layout.rows)*metrics.lineHeight))
+	if width*height > pngMaxPixels {
+		return fmt.Errorf("png output of %d lines would be %dx%d pixels, over the limit of %d megapixels; use svg or shorter input",
+			layout.rows, width, height, pngMaxPixels>>20)
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(parseHexColor(layout.background)), image.Point{}, draw.Src)
+
+	boundary := image.NewUniform(parseHexColor(layout.dim))
+	for _, cell := range layout.boundaries {
+		x := int(padding + float64(cell.col)*metrics.cellWidth)
+		top := int(padding + float64(cell.row)*metrics.lineHeight)
+		rect := image.Rect(x, top+2*pngScale, x+pngScale, top+int(metrics.lineHeight)-2*pngScale)
+		draw.Draw(img, rect, boundary, image.Point{}, draw.Src)
+	}
+
+	// Characters are placed on the grid one by one, so wide characters take two cells
+	drawer := &font.Drawer{Dst: img, Face: metrics.face}
+	for _, run := range layout.runs {
+		drawer.Src = image.NewUniform(parseHexColor(run.color))
+		y := padding + float64(run.row)*metrics.lineHeight + metrics.baseline
+		col := run.col
+		for _, c := range run.text {
+			x := padding + float64(col)*metrics.cellWidth
+			drawer.Dot = fixed.Point26_6{X: fixed.Int26_6(x * 64), Y: fixed.Int26_6(y * 64)}
+			drawer.DrawString(string(c))
+			col += runewidth.RuneWidth(c)
+		}
+	}
+
+	return png.Encode(w, img)
+}
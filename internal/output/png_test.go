@@ -0,0 +1,44 @@
+package output
+
+import (
+	"bytes"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func TestPNGSizeLimit(t *testing.T) {
+	tests := []struct {
+		name string
+		rows int
+		err  bool
+	}{
+		{"small", 3, false},
+		{"too many lines", 1 << 20, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			layout := &imageLayout{
+				cols:       80,
+				rows:       tt.rows,
+				runs:       []imageRun{{text: "hello", color: "#ff0000"}},
+				background: "#ffffff",
+				dim:        "#888888",
+			}
+			var buf bytes.Buffer
+			err := pngEncoder{}.encode(&buf, layout)
+			if tt.err {
+				if err == nil || !strings.Contains(err.Error(), "megapixels") {
+					t.Errorf("error = %v, want a size limit error", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := png.Decode(&buf); err != nil {
+				t.Errorf("invalid PNG: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,346 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/spandigital/token-visualizer/internal/align"
+	"github.com/spandigital/token-visualizer/internal/diff"
+	"github.com/spandigital/token-visualizer/internal/metrics"
+	"github.com/spandigital/token-visualizer/internal/outline"
+	"github.com/spandigital/token-visualizer/internal/tokenizers"
+	"github.com/spandigital/token-visualizer/internal/vocab"
+)
+
+// Renderer writes tokenization results in one output format
+type Renderer interface {
+	// ContentType returns the MIME type of the output
+	ContentType() string
+
+	// Single writes the tokens of one result
+	Single(w io.Writer, result *tokenizers.TokenizationResult) error
+
+	// Comparison writes several tokenizations of the same text side by side
+	Comparison(w io.Writer, results []*tokenizers.TokenizationResult) error
+
+	// Counts writes only the token count of each result
+	Counts(w io.Writer, results []*tokenizers.TokenizationResult) error
+}
+
+// DiffRenderer is implemented by renderers that can write token diffs
+type DiffRenderer interface {
+	Diff(w io.Writer, results []*diff.Result) error
+}
+
+// AlignedRenderer is implemented by renderers that can write aligned comparisons
+type AlignedRenderer interface {
+	Aligned(w io.Writer, result *align.Result) error
+}
+
+// EfficiencyRenderer is implemented by renderers that can write efficiency reports
+type EfficiencyRenderer interface {
+	Efficiency(w io.Writer, reports []*metrics.Report) error
+}
+
+// SectionsRenderer is implemented by renderers that can write markdown section budgets
+type SectionsRenderer interface {
+	Sections(w io.Writer, report *outline.Report) error
+}
+
+// StagesRenderer is implemented by renderers that can write tokenization pipeline layers
+type StagesRenderer interface {
+	Stages(w io.Writer, stages *tokenizers.Stages) error
+}
+
+// BytesRenderer is implemented by renderers that can write the raw bytes of tokens
+type BytesRenderer interface {
+	Bytes(w io.Writer, result *tokenizers.TokenizationResult) error
+}
+
+// MergeTraceRenderer is implemented by renderers that can write BPE merge traces
+type MergeTraceRenderer interface {
+	MergeTrace(w io.Writer, model string, traces []tokenizers.MergeTrace) error
+}
+
+// VocabRenderer is implemented by renderers that can write vocabulary listings and lookups
+type VocabRenderer interface {
+	Vocab(w io.Writer, model string, entries []vocab.Entry, matched int) error
+	Lookup(w io.Writer, model string, matches []vocab.Match) error
+}
+
+// Options configure a renderer. Formats ignore options they have no use for.
+type Options struct {
+	ShowIDs           bool
+	ShowBoundaries    bool
+	VisibleWhitespace bool
+	Numbered          bool      // One numbered token per line, in plain output
+	Coloring          *Coloring // Defaults to cycling through the palette
+	Theme             *Theme    // Defaults to the dark theme
+	Columns           int       // Output width in characters; defaults to DefaultTerminalWidth or DefaultImageColumns
+}
+
+// Factory creates a renderer with the given options
+type Factory func(opts Options) Renderer
+
+// registry maps format names to renderer factories
+var registry = map[string]Factory{}
+
+// Register makes a format available to New. It panics if the format is
+// already registered, as that is a programming error.
+func Register(format string, factory Factory) {
+	if _, ok := registry[format]; ok {
+		panic(fmt.Sprintf("output format %s registered twice", format))
+	}
+	registry[format] = factory
+}
+
+// New creates a renderer for the named format
+func New(format string, opts Options) (Renderer, error) {
+	factory, ok := registry[format]
+	if !ok {
+		return nil, fmt.Errorf("unknown format: %s", format)
+	}
+	if opts.Coloring == nil {
+		opts.Coloring = cycleColoring
+	}
+	if opts.Theme == nil {
+		opts.Theme = builtinThemes[DefaultTheme]
+	}
+	return factory(opts), nil
+}
+
+// Formats returns the names of all registered formats, with terminal first
+func Formats() []string {
+	formats := make([]string, 0, len(registry))
+	for format := range registry {
+		formats = append(formats, format)
+	}
+	sort.Slice(formats, func(i, j int) bool {
+		if (formats[i] == "terminal") != (formats[j] == "terminal") {
+			return formats[i] == "terminal"
+		}
+		return formats[i] < formats[j]
+	})
+	return formats
+}
+
+// As returns the renderer as the interface of an optional view, or an error
+// naming the format and view if the renderer doesn't support it
+func As[T any](renderer Renderer, format, view string) (T, error) {
+	r, ok := renderer.(T)
+	if adapter, adapted := renderer.(*stringAdapter); adapted && ok {
+		ok = adapter.supports(&r)
+	}
+	if !ok {
+		return r, fmt.Errorf("format %s does not support %s", format, view)
+	}
+	return r, nil
+}
+
+func init() {
+	Register("terminal", func(opts Options) Renderer {
+		r := NewTerminalRenderer(opts.ShowIDs, opts.ShowBoundaries).
+			WithTheme(opts.Theme).
+			WithColoring(opts.Coloring).
+			WithVisibleWhitespace(opts.VisibleWhitespace).
+			WithWidth(opts.Columns)
+		return adapt("text/plain; charset=utf-8", r)
+	})
+	Register("markdown", func(opts Options) Renderer {
+		r := NewMarkdownRenderer(opts.ShowIDs).
+			WithColoring(opts.Coloring).
+			WithVisibleWhitespace(opts.VisibleWhitespace)
+		return adapt("text/markdown; charset=utf-8", r)
+	})
+	Register("plain", func(opts Options) Renderer {
+		r := NewPlainRenderer(opts.ShowIDs).
+			WithColoring(opts.Coloring).
+			WithNumbering(opts.Numbered)
+		return adapt("text/plain; charset=utf-8", r)
+	})
+	Register("html", func(opts Options) Renderer {
+		r := NewHTMLInlineRenderer(opts.ShowIDs, opts.ShowBoundaries).
+			WithTheme(opts.Theme).
+			WithColoring(opts.Coloring).
+			WithVisibleWhitespace(opts.VisibleWhitespace)
+		return adapt("text/html; charset=utf-8", r)
+	})
+	Register("html-interactive", func(opts Options) Renderer {
+		r := NewHTMLInteractiveRenderer(opts.ShowIDs, opts.ShowBoundaries).
+			WithTheme(opts.Theme).
+			WithColoring(opts.Coloring).
+			WithVisibleWhitespace(opts.VisibleWhitespace)
+		return adapt("text/html; charset=utf-8", r)
+	})
+	Register("svg", func(opts Options) Renderer {
+		return NewSVGRenderer(opts.ShowIDs, opts.ShowBoundaries).
+			WithTheme(opts.Theme).
+			WithColoring(opts.Coloring).
+			WithVisibleWhitespace(opts.VisibleWhitespace).
+			WithColumns(opts.Columns)
+	})
+	Register("png", func(opts Options) Renderer {
+		return NewPNGRenderer(opts.ShowIDs, opts.ShowBoundaries).
+			WithTheme(opts.Theme).
+			WithColoring(opts.Coloring).
+			WithVisibleWhitespace(opts.VisibleWhitespace).
+			WithColumns(opts.Columns)
+	})
+}
+
+// write writes rendered output to w
+func write(w io.Writer, rendered string) error {
+	_, err := io.WriteString(w, rendered)
+	return err
+}
+
+// stringRenderer is implemented by the built-in text renderers, which build
+// their output as strings. stringAdapter writes those strings to the writers
+// of Renderer and the optional views
+type stringRenderer interface {
+	RenderSingle(result *tokenizers.TokenizationResult) string
+	RenderComparison(results []*tokenizers.TokenizationResult) string
+	RenderCountOnly(results []*tokenizers.TokenizationResult) string
+}
+
+// String methods behind the optional views
+type (
+	diffStringer interface {
+		RenderDiff(results []*diff.Result) string
+	}
+	alignedStringer interface {
+		RenderAligned(result *align.Result) string
+	}
+	efficiencyStringer interface {
+		RenderEfficiency(reports []*metrics.Report) string
+	}
+	sectionsStringer interface {
+		RenderSections(report *outline.Report) string
+	}
+	stagesStringer interface {
+		RenderStages(stages *tokenizers.Stages) string
+	}
+	bytesStringer interface {
+		RenderBytes(result *tokenizers.TokenizationResult) string
+	}
+	mergeTraceStringer interface {
+		RenderMergeTrace(model string, traces []tokenizers.MergeTrace) string
+	}
+	vocabStringer interface {
+		RenderVocab(model string, entries []vocab.Entry, matched int) string
+		RenderLookup(model string, matches []vocab.Match) string
+	}
+)
+
+// stringAdapter adapts a string renderer to Renderer and to the optional views
+// it has string methods for
+type stringAdapter struct {
+	contentType string
+	renderer    stringRenderer
+}
+
+// adapt returns r as a Renderer with the given MIME type
+func adapt(contentType string, r stringRenderer) Renderer {
+	return &stringAdapter{contentType: contentType, renderer: r}
+}
+
+// supports reports whether the adapted renderer has the string method for the
+// optional view that view points to, such as a *DiffRenderer
+func (a *stringAdapter) supports(view any) bool {
+	var ok bool
+	switch view.(type) {
+	case *DiffRenderer:
+		_, ok = a.renderer.(diffStringer)
+	case *AlignedRenderer:
+		_, ok = a.renderer.(alignedStringer)
+	case *EfficiencyRenderer:
+		_, ok = a.renderer.(efficiencyStringer)
+	case *SectionsRenderer:
+		_, ok = a.renderer.(sectionsStringer)
+	case *StagesRenderer:
+		_, ok = a.renderer.(stagesStringer)
+	case *BytesRenderer:
+		_, ok = a.renderer.(bytesStringer)
+	case *MergeTraceRenderer:
+		_, ok = a.renderer.(mergeTraceStringer)
+	case *VocabRenderer:
+		_, ok = a.renderer.(vocabStringer)
+	default:
+		ok = true
+	}
+	return ok
+}
+
+// render writes the output of a string method of the adapted renderer, or
+// returns an error if it doesn't have the method
+func render[S any](a *stringAdapter, w io.Writer, method func(S) string) error {
+	r, ok := a.renderer.(S)
+	if !ok {
+		return fmt.Errorf("%T does not support this view", a.renderer)
+	}
+	return write(w, method(r))
+}
+
+// ContentType returns the MIME type of the output
+func (a *stringAdapter) ContentType() string { return a.contentType }
+
+// Single writes a single tokenization result
+func (a *stringAdapter) Single(w io.Writer, result *tokenizers.TokenizationResult) error {
+	return write(w, a.renderer.RenderSingle(result))
+}
+
+// Comparison writes multiple tokenization results
+func (a *stringAdapter) Comparison(w io.Writer, results []*tokenizers.TokenizationResult) error {
+	return write(w, a.renderer.RenderComparison(results))
+}
+
+// Counts writes just the token counts
+func (a *stringAdapter) Counts(w io.Writer, results []*tokenizers.TokenizationResult) error {
+	return write(w, a.renderer.RenderCountOnly(results))
+}
+
+// Diff writes token-level diffs
+func (a *stringAdapter) Diff(w io.Writer, results []*diff.Result) error {
+	return render(a, w, func(r diffStringer) string { return r.RenderDiff(results) })
+}
+
+// Aligned writes an aligned comparison
+func (a *stringAdapter) Aligned(w io.Writer, result *align.Result) error {
+	return render(a, w, func(r alignedStringer) string { return r.RenderAligned(result) })
+}
+
+// Efficiency writes efficiency reports
+func (a *stringAdapter) Efficiency(w io.Writer, reports []*metrics.Report) error {
+	return render(a, w, func(r efficiencyStringer) string { return r.RenderEfficiency(reports) })
+}
+
+// Sections writes a markdown section budget
+func (a *stringAdapter) Sections(w io.Writer, report *outline.Report) error {
+	return render(a, w, func(r sectionsStringer) string { return r.RenderSections(report) })
+}
+
+// Stages writes the layers of the tokenization pipeline
+func (a *stringAdapter) Stages(w io.Writer, stages *tokenizers.Stages) error {
+	return render(a, w, func(r stagesStringer) string { return r.RenderStages(stages) })
+}
+
+// Bytes writes the raw bytes of every token
+func (a *stringAdapter) Bytes(w io.Writer, result *tokenizers.TokenizationResult) error {
+	return render(a, w, func(r bytesStringer) string { return r.RenderBytes(result) })
+}
+
+// MergeTrace writes BPE merge traces
+func (a *stringAdapter) MergeTrace(w io.Writer, model string, traces []tokenizers.MergeTrace) error {
+	return render(a, w, func(r mergeTraceStringer) string { return r.RenderMergeTrace(model, traces) })
+}
+
+// Vocab writes vocabulary entries
+func (a *stringAdapter) Vocab(w io.Writer, model string, entries []vocab.Entry, matched int) error {
+	return render(a, w, func(r vocabStringer) string { return r.RenderVocab(model, entries, matched) })
+}
+
+// Lookup writes vocabulary lookups
+func (a *stringAdapter) Lookup(w io.Writer, model string, matches []vocab.Match) error {
+	return render(a, w, func(r vocabStringer) string { return r.RenderLookup(model, matches) })
+}
@@ -0,0 +1,78 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/spandigital/token-visualizer/internal/tokenizers"
+)
+
+// supported reports whether As finds the optional view T on renderer
+func supported[T any](renderer Renderer) bool {
+	_, err := As[T](renderer, "", "")
+	return err == nil
+}
+
+func TestViews(t *testing.T) {
+	// Views in the order diff, aligned, efficiency, sections, stages, bytes, merge trace, vocab
+	all := [8]bool{true, true, true, true, true, true, true, true}
+	tests := []struct {
+		format      string
+		contentType string
+		views       [8]bool
+	}{
+		{"terminal", "text/plain; charset=utf-8", all},
+		{"markdown", "text/markdown; charset=utf-8", all},
+		{"plain", "text/plain; charset=utf-8", [8]bool{true, true, true, true, false, false, false, true}},
+		{"html", "text/html; charset=utf-8", all},
+		{"html-interactive", "text/html; charset=utf-8", all},
+		{"svg", "image/svg+xml", [8]bool{}},
+		{"png", "image/png", [8]bool{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			renderer, err := New(tt.format, Options{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if renderer.ContentType() != tt.contentType {
+				t.Errorf("content type = %q, want %q", renderer.ContentType(), tt.contentType)
+			}
+			views := [8]bool{
+				supported[DiffRenderer](renderer),
+				supported[AlignedRenderer](renderer),
+				supported[EfficiencyRenderer](renderer),
+				supported[SectionsRenderer](renderer),
+				supported[StagesRenderer](renderer),
+				supported[BytesRenderer](renderer),
+				supported[MergeTraceRenderer](renderer),
+				supported[VocabRenderer](renderer),
+			}
+			if views != tt.views {
+				t.Errorf("views = %v, want %v", views, tt.views)
+			}
+		})
+	}
+}
+
+func TestStringAdapter(t *testing.T) {
+	result := &tokenizers.TokenizationResult{Model: "m", Text: "ab", TotalCount: 2, Tokens: []tokenizers.Token{
+		{Text: "a", ID: 1, Start: 0, End: 1},
+		{Text: "b", ID: 2, Start: 1, End: 2},
+	}}
+	plain := NewPlainRenderer(false)
+	renderer := adapt("text/plain; charset=utf-8", plain)
+
+	var buf bytes.Buffer
+	if err := renderer.Single(&buf, result); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != plain.RenderSingle(result) {
+		t.Errorf("Single wrote %q, want the output of RenderSingle %q", buf.String(), plain.RenderSingle(result))
+	}
+
+	// Calling a view the renderer has no string method for is an error, not a panic
+	if err := renderer.(StagesRenderer).Stages(&buf, &tokenizers.Stages{}); err == nil {
+		t.Error("Stages on plain output succeeded, want an error")
+	}
+}
@@ -0,0 +1,384 @@
+(function () {
+    "use strict";
+
+    var searchEl = document.getElementById("search");
+    var showIDsEl = document.getElementById("show-ids");
+    var showBoundariesEl = document.getElementById("show-boundaries");
+    var statusEl = document.getElementById("status");
+    var tooltipEl = document.getElementById("tooltip");
+    var tokens = Array.prototype.slice.call(document.querySelectorAll(".tokens .token"));
+    var highlighted = [];
+
+    // tooltipText describes a token: its position, ID, byte range and escaped text
+    function tooltipText(span) {
+        var id = Number(span.dataset.id);
+        return "Token #" + (Number(span.dataset.index) + 1) + "\n" +
+            "ID: " + (id >= 0 ? id : "n/a") + "\n" +
+            "Bytes: " + span.dataset.start + "-" + span.dataset.end + "\n" +
+            "Text: " + span.dataset.text;
+    }
+
+    function showTooltip(span, x, y) {
+        tooltipEl.textContent = tooltipText(span);
+        tooltipEl.style.left = x + "px";
+        tooltipEl.style.top = y + "px";
+        tooltipEl.style.display = "block";
+    }
+
+    function hideTooltip() {
+        tooltipEl.style.display = "none";
+    }
+
+    // sameToken reports whether two tokens of the same model are the same vocabulary entry
+    function sameToken(a, b) {
+        if (a.parentNode !== b.parentNode) {
+            return false;
+        }
+        if (Number(a.dataset.id) >= 0) {
+            return a.dataset.id === b.dataset.id;
+        }
+        return a.dataset.text === b.dataset.text;
+    }
+
+    // highlight marks tokens with a class and remembers them for n/N navigation
+    function highlight(className, matches) {
+        tokens.forEach(function (token) {
+            token.classList.remove(className);
+        });
+        matches.forEach(function (token) {
+            token.classList.add(className);
+        });
+        highlighted = matches;
+    }
+
+    function select(span) {
+        var matches = tokens.filter(function (token) { return sameToken(span, token); });
+        highlight("selected", matches);
+        var id = Number(span.dataset.id);
+        statusEl.textContent = (id >= 0 ? "ID " + id : span.dataset.text) + ": " +
+            matches.length + (matches.length === 1 ? " occurrence" : " occurrences");
+    }
+
+    function clearSelection() {
+        highlight("selected", []);
+        statusEl.textContent = "";
+    }
+
+    // search marks every token overlapping a match of the query in the text of
+    // its model, and every token whose ID is the query if it is a number
+    function search(query) {
+        tokens.forEach(function (token) {
+            token.classList.remove("match");
+        });
+        if (query === "") {
+            highlighted = [];
+            statusEl.textContent = "";
+            return;
+        }
+
+        var idQuery = /^#?\d+$/.test(query) ? query.replace("#", "") : null;
+        var needle = query.toLowerCase();
+        var matches = [];
+
+        document.querySelectorAll(".tokens").forEach(function (container) {
+            var spans = Array.prototype.slice.call(container.querySelectorAll(".token"));
+            var text = "";
+            var starts = spans.map(function (span) {
+                var start = text.length;
+                text += span.textContent;
+                return start;
+            });
+            var haystack = text.toLowerCase();
+            var marked = {};
+            var first = 0;
+
+            // Matches come in order, so the first overlapping token only moves forward
+            for (var pos = haystack.indexOf(needle); pos >= 0; pos = haystack.indexOf(needle, pos + 1)) {
+                while (first < spans.length - 1 && starts[first + 1] <= pos) {
+                    first++;
+                }
+                for (var i = first; i < spans.length && starts[i] < pos + needle.length; i++) {
+                    marked[i] = true;
+                }
+            }
+            spans.forEach(function (span, i) {
+                if (marked[i] || (idQuery !== null && span.dataset.id === idQuery)) {
+                    matches.push(span);
+                }
+            });
+        });
+
+        highlight("match", matches);
+        statusEl.textContent = matches.length + (matches.length === 1 ? " token matches" : " tokens match");
+    }
+
+    // focusToken moves the keyboard focus to a token, keeping a single tab stop
+    function focusToken(span) {
+        tokens.forEach(function (token) {
+            token.tabIndex = -1;
+        });
+        span.tabIndex = 0;
+        span.focus();
+    }
+
+    // jump moves to the next or previous highlighted token
+    function jump(current, step) {
+        if (highlighted.length === 0) {
+            return;
+        }
+        var at = highlighted.indexOf(current);
+        if (at < 0) {
+            var index = tokens.indexOf(current);
+            at = step > 0 ? -1 : 0;
+            for (var i = 0; i < highlighted.length; i++) {
+                if (tokens.indexOf(highlighted[i]) > index) {
+                    at = step > 0 ? i - 1 : i;
+                    break;
+                }
+            }
+        }
+        focusToken(highlighted[(at + step + highlighted.length) % highlighted.length]);
+    }
+
+    document.addEventListener("mousemove", function (event) {
+        var span = event.target.closest(".tokens .token");
+        if (!span) {
+            hideTooltip();
+            return;
+        }
+        showTooltip(span, event.clientX + 12, event.clientY + 12);
+    });
+
+    document.addEventListener("click", function (event) {
+        var span = event.target.closest(".tokens .token");
+        if (span) {
+            focusToken(span);
+            select(span);
+        }
+    });
+
+    document.addEventListener("focusin", function (event) {
+        var span = event.target.closest(".tokens .token");
+        if (span) {
+            var rect = span.getBoundingClientRect();
+            showTooltip(span, rect.left, rect.bottom + 6);
+        }
+    });
+
+    document.addEventListener("focusout", hideTooltip);
+
+    document.addEventListener("keydown", function (event) {
+        if (event.target === searchEl) {
+            if (event.key === "Escape") {
+                searchEl.value = "";
+                search("");
+                searchEl.blur();
+            } else if (event.key === "Enter" && highlighted.length > 0) {
+                event.preventDefault();
+                focusToken(highlighted[0]);
+            }
+            return;
+        }
+        if (event.target.tagName === "INPUT" || event.ctrlKey || event.metaKey || event.altKey) {
+            return;
+        }
+
+        var span = event.target.closest ? event.target.closest(".tokens .token") : null;
+        var index = span ? tokens.indexOf(span) : -1;
+
+        switch (event.key) {
+        case "/":
+            event.preventDefault();
+            searchEl.focus();
+            break;
+        case "Escape":
+            clearSelection();
+            break;
+        case "ArrowRight":
+        case "ArrowLeft":
+            if (tokens.length > 0) {
+                event.preventDefault();
+                var step = event.key === "ArrowRight" ? 1 : -1;
+                focusToken(tokens[Math.min(Math.max(index + step, 0), tokens.length - 1)]);
+            }
+            break;
+        case "Home":
+        case "End":
+            if (span) {
+                event.preventDefault();
+                var siblings = span.parentNode.querySelectorAll(".token");
+                focusToken(siblings[event.key === "Home" ? 0 : siblings.length - 1]);
+            }
+            break;
+        case "Enter":
+        case " ":
+            if (span) {
+                event.preventDefault();
+                select(span);
+            }
+            break;
+        case "n":
+        case "N":
+            jump(span, event.key === "n" ? 1 : -1);
+            break;
+        case "i":
+            showIDsEl.click();
+            break;
+        case "b":
+            showBoundariesEl.click();
+            break;
+        }
+    });
+
+    searchEl.addEventListener("input", function () {
+        search(searchEl.value);
+    });
+
+    function toggle(box, className) {
+        document.body.classList.toggle(className, box.checked);
+        box.addEventListener("change", function () {
+            document.body.classList.toggle(className, box.checked);
+        });
+    }
+
+    // The comparison report links the model columns by byte offset
+    var columns = Array.prototype.slice.call(document.querySelectorAll(".report-tokens")).map(function (container) {
+        var spans = Array.prototype.slice.call(container.querySelectorAll(".token"));
+        return {
+            container: container,
+            spans: spans,
+            starts: spans.map(function (span) { return Number(span.dataset.start); }),
+            ends: spans.map(function (span) { return Number(span.dataset.end); })
+        };
+    });
+    var linked = [];
+    var scrollSource = null;
+    var scrollTimer = null;
+
+    // tokenAt returns the index of the last token in a column starting at or before a byte offset
+    function tokenAt(column, offset) {
+        var lo = 0;
+        var hi = column.spans.length - 1;
+        while (lo < hi) {
+            var mid = (lo + hi + 1) >> 1;
+            if (column.starts[mid] <= offset) {
+                lo = mid;
+            } else {
+                hi = mid - 1;
+            }
+        }
+        return lo;
+    }
+
+    // utf8Length returns the number of UTF-8 bytes of a string
+    function utf8Length(s) {
+        return new TextEncoder().encode(s).length;
+    }
+
+    // hoveredOffset returns the byte offset of the character under the pointer
+    // within a token, falling back to the start of the token
+    function hoveredOffset(span, x, y) {
+        var node = null;
+        var offset = 0;
+        if (document.caretPositionFromPoint) {
+            var position = document.caretPositionFromPoint(x, y);
+            if (position) {
+                node = position.offsetNode;
+                offset = position.offset;
+            }
+        } else if (document.caretRangeFromPoint) {
+            var range = document.caretRangeFromPoint(x, y);
+            if (range) {
+                node = range.startContainer;
+                offset = range.startOffset;
+            }
+        }
+
+        var start = Number(span.dataset.start);
+        var end = Number(span.dataset.end);
+        if (!node || !span.contains(node)) {
+            return start;
+        }
+        return Math.min(start + utf8Length(node.textContent.slice(0, offset)), Math.max(end - 1, start));
+    }
+
+    function link(offset) {
+        linked.forEach(function (span) {
+            span.classList.remove("linked");
+        });
+        linked = [];
+        if (offset === null) {
+            return;
+        }
+        columns.forEach(function (column) {
+            if (column.spans.length === 0) {
+                return;
+            }
+            var i = tokenAt(column, offset);
+            if (column.starts[i] <= offset && offset < column.ends[i]) {
+                column.spans[i].classList.add("linked");
+                linked.push(column.spans[i]);
+            }
+        });
+    }
+
+    // syncScroll scrolls the other columns so the text at the top of the
+    // scrolled column is at the top of each of them
+    function syncScroll(source) {
+        if (source.spans.length === 0) {
+            return;
+        }
+        var top = source.container.scrollTop;
+        var lo = 0;
+        var hi = source.spans.length - 1;
+        while (lo < hi) {
+            var mid = (lo + hi) >> 1;
+            var span = source.spans[mid];
+            if (span.offsetTop + span.offsetHeight <= top) {
+                lo = mid + 1;
+            } else {
+                hi = mid;
+            }
+        }
+        var first = source.spans[lo];
+        var shift = first.offsetTop - top;
+
+        columns.forEach(function (column) {
+            if (column === source || column.spans.length === 0) {
+                return;
+            }
+            var target = column.spans[tokenAt(column, source.starts[lo])];
+            column.container.scrollTop = target.offsetTop - shift;
+        });
+    }
+
+    columns.forEach(function (column) {
+        column.container.addEventListener("mousemove", function (event) {
+            var span = event.target.closest(".token");
+            link(span ? hoveredOffset(span, event.clientX, event.clientY) : null);
+        });
+        column.container.addEventListener("mouseleave", function () {
+            link(null);
+        });
+        column.container.addEventListener("focusin", function (event) {
+            var span = event.target.closest(".token");
+            if (span) {
+                link(Number(span.dataset.start));
+            }
+        });
+
+        // Scroll events from the columns being synced are ignored until the user stops scrolling
+        column.container.addEventListener("scroll", function () {
+            if (scrollSource !== null && scrollSource !== column) {
+                return;
+            }
+            scrollSource = column;
+            clearTimeout(scrollTimer);
+            scrollTimer = setTimeout(function () { scrollSource = null; }, 150);
+            syncScroll(column);
+        });
+    });
+
+    toggle(showIDsEl, "show-ids");
+    toggle(showBoundariesEl, "show-boundaries");
+})();
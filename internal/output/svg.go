@@ -0,0 +1,62 @@
+package output
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"io"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gomono"
+)
+
+// svgEncoder writes a layout as SVG text with Go Mono embedded as a web font.
+// Characters missing from Go Mono fall back to the viewer's monospace font.
+type svgEncoder struct{}
+
+func (svgEncoder) contentType() string {
+	return "image/svg+xml"
+}
+
+func (svgEncoder) encode(w io.Writer, layout *imageLayout) error {
+	metrics, err := newImageMetrics(imageFontSize, font.HintingNone)
+	if err != nil {
+		return err
+	}
+
+	width := 2*imagePadding + float64(layout.cols)*metrics.cellWidth
+	height := 2*imagePadding + float64(layout.rows)*metrics.lineHeight
+
+	out := bufio.NewWriter(w)
+	fmt.Fprintf(out, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%.0f\" height=\"%.0f\" viewBox=\"0 0 %.1f %.1f\" xml:space=\"preserve\">\n",
+		width, height, width, height)
+	out.WriteString("<style>\n")
+	out.WriteString("@font-face { font-family: 'Go Mono'; src: url(data:font/ttf;base64,")
+	out.WriteString(base64.StdEncoding.EncodeToString(gomono.TTF))
+	out.WriteString(") format('truetype'); }\n")
+	fmt.Fprintf(out, "text { font-family: 'Go Mono', monospace; font-size: %dpx; white-space: pre; }\n", imageFontSize)
+	out.WriteString("</style>\n")
+	fmt.Fprintf(out, "<rect width=\"100%%\" height=\"100%%\" fill=\"%s\"/>\n", layout.background)
+
+	for _, cell := range layout.boundaries {
+		x := imagePadding + float64(cell.col)*metrics.cellWidth
+		top := imagePadding + float64(cell.row)*metrics.lineHeight
+		fmt.Fprintf(out, "<line x1=\"%.1f\" y1=\"%.1f\" x2=\"%.1f\" y2=\"%.1f\" stroke=\"%s\"/>\n",
+			x, top+2, x, top+metrics.lineHeight-2, layout.dim)
+	}
+
+	// textLength keeps every run on the grid even where the fallback font is
+	// wider. Runs of zero-width characters would be squeezed to nothing.
+	for _, run := range layout.runs {
+		if run.cells == 0 {
+			continue
+		}
+		x := imagePadding + float64(run.col)*metrics.cellWidth
+		y := imagePadding + float64(run.row)*metrics.lineHeight + metrics.baseline
+		fmt.Fprintf(out, "<text x=\"%.1f\" y=\"%.1f\" fill=\"%s\" textLength=\"%.1f\" lengthAdjust=\"spacingAndGlyphs\">%s</text>\n",
+			x, y, run.color, float64(run.cells)*metrics.cellWidth, escapeHTML(run.text))
+	}
+
+	out.WriteString("</svg>\n")
+	return out.Flush()
+}
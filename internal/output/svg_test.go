@@ -0,0 +1,47 @@
+package output
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSVGRuns(t *testing.T) {
+	b := newImageBuilder(80)
+	b.write("ab", "#111111")
+	b.write("\u200b", "#222222")
+	b.write("c", "#111111")
+	b.write("de", "#111111")
+	b.newline()
+	b.write("f", "#111111")
+	layout := b.finish()
+	layout.background, layout.dim = "#ffffff", "#888888"
+
+	var texts []string
+	for _, run := range layout.runs {
+		texts = append(texts, run.text)
+	}
+	if want := []string{"ab", "\u200b", "cde", "f"}; !slices.Equal(texts, want) {
+		t.Errorf("runs = %q, want %q", texts, want)
+	}
+
+	var buf bytes.Buffer
+	if err := (svgEncoder{}).encode(&buf, layout); err != nil {
+		t.Fatal(err)
+	}
+	svg := buf.String()
+
+	// The zero-width run isn't drawn
+	if n := strings.Count(svg, "<text "); n != 3 {
+		t.Errorf("svg has %d text elements, want 3", n)
+	}
+	if strings.Contains(svg, `textLength="0.0"`) || strings.Contains(svg, "\u200b") {
+		t.Error("svg has a zero-width run")
+	}
+	for _, text := range []string{">ab</text>", ">cde</text>", ">f</text>"} {
+		if !strings.Contains(svg, text) {
+			t.Errorf("svg doesn't contain %q", text)
+		}
+	}
+}
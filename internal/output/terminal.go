@@ -2,51 +2,81 @@ package output
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
+	"github.com/spandigital/token-visualizer/internal/align"
+	"github.com/spandigital/token-visualizer/internal/bytegroup"
+	"github.com/spandigital/token-visualizer/internal/diff"
+	"github.com/spandigital/token-visualizer/internal/metrics"
+	"github.com/spandigital/token-visualizer/internal/outline"
 	"github.com/spandigital/token-visualizer/internal/tokenizers"
+	"github.com/spandigital/token-visualizer/internal/vocab"
 )
 
-var (
-	// Color palette for tokens
-	tokenColors = []lipgloss.Color{
-		lipgloss.Color("205"), // Pink
-		lipgloss.Color("141"), // Purple
-		lipgloss.Color("87"),  // Cyan
-		lipgloss.Color("228"), // Yellow
-		lipgloss.Color("118"), // Green
-		lipgloss.Color("213"), // Magenta
-		lipgloss.Color("117"), // Light Blue
-		lipgloss.Color("223"), // Peach
-	}
+// terminalStyles are the text styles of the terminal renderer in a theme
+type terminalStyles struct {
+	header    lipgloss.Style
+	stats     lipgloss.Style
+	tokenID   lipgloss.Style
+	equal     lipgloss.Style
+	insert    lipgloss.Style
+	delete    lipgloss.Style
+	diverge   lipgloss.Style
+	column    lipgloss.Style
+	model     lipgloss.Style
+	count     lipgloss.Style
+	separator lipgloss.Style
+	heading   lipgloss.Style
+}
 
-	headerStyle = lipgloss.NewStyle().
+// newTerminalStyles builds the terminal styles from the colors of a theme
+func newTerminalStyles(theme *Theme) terminalStyles {
+	return terminalStyles{
+		header: theme.accent.style().
 			Bold(true).
-			Foreground(lipgloss.Color("86")).
 			Border(lipgloss.RoundedBorder()).
 			Padding(0, 1).
-			MarginBottom(1)
-
-	statsStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("240")).
-			Italic(true)
-
-	tokenIDStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("243")).
-			Faint(true)
-
-	columnStyle = lipgloss.NewStyle().
-			Width(50).
+			MarginBottom(1),
+		stats:   theme.muted.style().Italic(true),
+		tokenID: theme.ids.style().Faint(true),
+		equal:   theme.neutral.style(),
+		insert: theme.insert.style().
+			Bold(true).
+			Underline(true),
+		delete:  theme.delete.style().Strikethrough(true),
+		diverge: theme.delete.style().Bold(true),
+		column: lipgloss.NewStyle().
 			Padding(1).
 			Border(lipgloss.RoundedBorder()).
-			BorderForeground(lipgloss.Color("240"))
-)
+			BorderForeground(theme.muted.terminal),
+		model:     theme.label.style().Bold(true),
+		count:     theme.highlight.style().Bold(true),
+		separator: theme.border.style(),
+		heading:   theme.accent.style().Bold(true),
+	}
+}
+
+// DefaultTerminalWidth is the width comparisons are laid out for when the
+// terminal width is unknown
+const DefaultTerminalWidth = 80
+
+// minColumnWidth is the narrowest a comparison column gets before columns
+// are stacked instead, in characters of token text
+const minColumnWidth = 24
 
 // TerminalRenderer renders tokenization results to colorized terminal output
 type TerminalRenderer struct {
-	showIDs        bool
-	showBoundaries bool
+	showIDs           bool
+	showBoundaries    bool
+	visibleWhitespace bool
+	coloring          *Coloring
+	theme             *Theme
+	styles            terminalStyles
+	width             int // Terminal width, or 0 when it's unknown
 }
 
 // NewTerminalRenderer creates a new terminal renderer
@@ -54,7 +84,53 @@ func NewTerminalRenderer(showIDs, showBoundaries bool) *TerminalRenderer {
 	return &TerminalRenderer{
 		showIDs:        showIDs,
 		showBoundaries: showBoundaries,
+		coloring:       cycleColoring,
+		theme:          builtinThemes[DefaultTheme],
+		styles:         newTerminalStyles(builtinThemes[DefaultTheme]),
+	}
+}
+
+// WithTheme sets the colors of the output
+func (r *TerminalRenderer) WithTheme(theme *Theme) *TerminalRenderer {
+	r.theme = theme
+	r.styles = newTerminalStyles(theme)
+	return r
+}
+
+// WithWidth sets the terminal width that comparisons are laid out for
+func (r *TerminalRenderer) WithWidth(width int) *TerminalRenderer {
+	if width > 0 {
+		r.width = width
+	}
+	return r
+}
+
+// widthOr returns the terminal width, or fallback when it's unknown
+func (r *TerminalRenderer) widthOr(fallback int) int {
+	if r.width > 0 {
+		return r.width
 	}
+	return fallback
+}
+
+// WithColoring sets the strategy used to color tokens
+func (r *TerminalRenderer) WithColoring(coloring *Coloring) *TerminalRenderer {
+	r.coloring = coloring
+	return r
+}
+
+// WithVisibleWhitespace shows spaces, tabs, line breaks and invisible characters as markers
+func (r *TerminalRenderer) WithVisibleWhitespace(visible bool) *TerminalRenderer {
+	r.visibleWhitespace = visible
+	return r
+}
+
+// tokenText returns the text of a token as it should be displayed
+func (r *TerminalRenderer) tokenText(s string) string {
+	if r.visibleWhitespace {
+		return visibleText(s, true)
+	}
+	return s
 }
 
 // RenderSingle renders a single tokenization result
@@ -62,33 +138,36 @@ func (r *TerminalRenderer) RenderSingle(result *tokenizers.TokenizationResult) s
 	var output strings.Builder
 
 	// Header with model name
-	header := headerStyle.Render(fmt.Sprintf("🔤 %s", result.Model))
+	header := r.styles.header.Render(fmt.Sprintf("🔤 %s", result.Model))
 	output.WriteString(header)
 	output.WriteString("\n\n")
 
 	// Stats
-	stats := statsStyle.Render(fmt.Sprintf("Total tokens: %d", result.TotalCount))
+	stats := r.styles.stats.Render(fmt.Sprintf("Total tokens: %d", result.TotalCount))
 	output.WriteString(stats)
 	output.WriteString("\n\n")
 
+	if r.coloring.HasLegend() {
+		output.WriteString(r.coloring.terminalLegend(r.theme))
+		output.WriteString("\n\n")
+	}
+
 	// Render tokens
 	for i, token := range result.Tokens {
-		colorIdx := i % len(tokenColors)
-		tokenStyle := lipgloss.NewStyle().
-			Foreground(tokenColors[colorIdx]).
+		tokenStyle := r.coloring.swatch(r.theme, result, i).style().
 			Bold(true)
 
 		// Token text
-		output.WriteString(tokenStyle.Render(token.Text))
+		output.WriteString(renderLines(tokenStyle, r.tokenText(token.Text)))
 
 		// Optional: Show boundaries
 		if r.showBoundaries && i < len(result.Tokens)-1 {
-			output.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color("238")).Render("|"))
+			output.WriteString(r.styles.separator.Render("|"))
 		}
 
 		// Optional: Show token IDs
 		if r.showIDs && token.ID >= 0 {
-			idStr := tokenIDStyle.Render(fmt.Sprintf("[%d]", token.ID))
+			idStr := r.styles.tokenID.Render(fmt.Sprintf("[%d]", token.ID))
 			output.WriteString(idStr)
 		}
 	}
@@ -107,74 +186,137 @@ func (r *TerminalRenderer) RenderComparison(results []*tokenizers.TokenizationRe
 		return r.RenderSingle(results[0])
 	}
 
-	columns := make([]string, len(results))
+	// Fit as many columns side by side as the width allows, spreading them
+	// evenly over the rows so the last row isn't left with a single column
+	width := r.widthOr(DefaultTerminalWidth)
+	perRow := min(max(width/(minColumnWidth+columnFrame), 1), len(results))
+	rows := (len(results) + perRow - 1) / perRow
+	perRow = (len(results) + rows - 1) / rows
+	contentWidth := max(width/perRow-columnFrame, minColumnWidth)
+
+	var blocks []string
+	for start := 0; start < len(results); start += perRow {
+		var columns []string
+		for _, result := range results[start:min(start+perRow, len(results))] {
+			columns = append(columns, r.renderColumn(result, contentWidth))
+		}
+		blocks = append(blocks, lipgloss.JoinHorizontal(lipgloss.Top, columns...))
+	}
 
-	for i, result := range results {
-		columns[i] = r.renderColumn(result)
+	comparison := strings.Join(blocks, "\n") + "\n"
+	if r.coloring.HasLegend() {
+		comparison += r.coloring.terminalLegend(r.theme) + "\n"
 	}
 
-	return lipgloss.JoinHorizontal(lipgloss.Top, columns...)
+	return comparison
 }
 
-// renderColumn renders a single column for comparison view
-func (r *TerminalRenderer) renderColumn(result *tokenizers.TokenizationResult) string {
+// columnFrame is the width the border and padding add to a column
+const columnFrame = 4
+
+// renderColumn renders a single column for comparison view, flowing the
+// tokens into lines of at most width characters
+func (r *TerminalRenderer) renderColumn(result *tokenizers.TokenizationResult, width int) string {
 	var content strings.Builder
 
 	// Model name header
-	header := lipgloss.NewStyle().
-		Bold(true).
-		Foreground(lipgloss.Color("86")).
+	header := r.styles.heading.
 		Underline(true).
 		Render(result.Model)
 	content.WriteString(header)
 	content.WriteString("\n\n")
 
 	// Token count
-	stats := statsStyle.Render(fmt.Sprintf("Tokens: %d", result.TotalCount))
+	stats := r.styles.stats.Render(fmt.Sprintf("Tokens: %d", result.TotalCount))
 	content.WriteString(stats)
 	content.WriteString("\n\n")
 
 	// Render tokens
+	flow := &lineFlow{width: width}
 	for i, token := range result.Tokens {
-		colorIdx := i % len(tokenColors)
-		tokenStyle := lipgloss.NewStyle().
-			Foreground(tokenColors[colorIdx]).
+		tokenStyle := r.coloring.swatch(r.theme, result, i).style().
 			Bold(true)
 
 		// Token text with optional boundary
 		if r.showBoundaries {
-			content.WriteString(tokenStyle.Render(fmt.Sprintf("[%s]", token.Text)))
+			flow.place(tokenStyle, fmt.Sprintf("[%s]", r.columnText(token.Text)))
 		} else {
-			content.WriteString(tokenStyle.Render(token.Text))
+			flow.place(tokenStyle, r.columnText(token.Text))
 		}
 
 		// Optional: Show token IDs
 		if r.showIDs && token.ID >= 0 {
-			idStr := tokenIDStyle.Render(fmt.Sprintf("(%d)", token.ID))
-			content.WriteString(idStr)
+			flow.place(r.styles.tokenID, fmt.Sprintf("(%d)", token.ID))
+		}
+
+		// Keep the line breaks of the text
+		if strings.Contains(token.Text, "\n") {
+			flow.breakLine()
+		}
+	}
+	content.WriteString(flow.String())
+
+	return r.styles.column.Width(width + 2).Render(content.String())
+}
+
+// lineFlow lays out styled pieces of text in lines of a fixed width
+type lineFlow struct {
+	width     int
+	output    strings.Builder
+	lineWidth int
+}
+
+// place adds text to the current line, starting a new line if it doesn't fit.
+// Text wider than a whole line is split over several lines.
+func (f *lineFlow) place(style lipgloss.Style, text string) {
+	textWidth := runewidth.StringWidth(text)
+	if f.lineWidth > 0 && f.lineWidth+textWidth > f.width && textWidth <= f.width {
+		f.breakLine()
+	}
+
+	for f.lineWidth+textWidth > f.width && text != "" {
+		head := runewidth.Truncate(text, f.width-f.lineWidth, "")
+		if head == "" && f.lineWidth == 0 {
+			// A single character wider than the line
+			_, size := utf8.DecodeRuneInString(text)
+			head = text[:size]
+		}
+		if head != "" {
+			f.output.WriteString(style.Render(head))
 		}
+		text = text[len(head):]
+		textWidth = runewidth.StringWidth(text)
+		f.breakLine()
+	}
 
-		content.WriteString("\n")
+	if text != "" {
+		f.output.WriteString(style.Render(text))
+		f.lineWidth += textWidth
 	}
+}
 
-	return columnStyle.Render(content.String())
+// breakLine ends the current line
+func (f *lineFlow) breakLine() {
+	f.output.WriteString("\n")
+	f.lineWidth = 0
+}
+
+// String returns the lines laid out so far
+func (f *lineFlow) String() string {
+	return strings.TrimSuffix(f.output.String(), "\n")
 }
 
 // RenderCountOnly renders just the token count for multiple models
 func (r *TerminalRenderer) RenderCountOnly(results []*tokenizers.TokenizationResult) string {
 	var output strings.Builder
 
-	output.WriteString(headerStyle.Render("📊 Token Counts"))
+	output.WriteString(r.styles.header.Render("📊 Token Counts"))
 	output.WriteString("\n\n")
 
 	for _, result := range results {
-		modelStyle := lipgloss.NewStyle().
-			Foreground(lipgloss.Color("141")).
-			Bold(true)
+		modelStyle := r.styles.model
 
-		countStyle := lipgloss.NewStyle().
-			Foreground(lipgloss.Color("228")).
-			Bold(true)
+		countStyle := r.styles.count
 
 		line := fmt.Sprintf("%s: %s",
 			modelStyle.Render(result.Model),
@@ -186,3 +328,670 @@ func (r *TerminalRenderer) RenderCountOnly(results []*tokenizers.TokenizationRes
 
 	return output.String()
 }
+
+// RenderDiff renders token-level diffs between two versions of a text
+func (r *TerminalRenderer) RenderDiff(results []*diff.Result) string {
+	var output strings.Builder
+
+	output.WriteString(r.styles.header.Render("🔀 Token Diff"))
+	output.WriteString("\n\n")
+
+	for _, result := range results {
+		modelStyle := r.styles.model
+
+		output.WriteString(modelStyle.Render(result.Model))
+		output.WriteString("\n")
+		output.WriteString(r.styles.stats.Render(fmt.Sprintf("%d → %d tokens (%s) · %d inserted · %d deleted",
+			result.Old.TotalCount, result.New.TotalCount, formatDelta(result.Delta()), result.Inserted, result.Deleted)))
+		output.WriteString("\n\n")
+
+		if len(result.New.Tokens) > 0 && result.New.Tokens[0].ID < 0 {
+			// No individual tokens available (e.g. Claude), counts only
+			continue
+		}
+
+		for _, edit := range result.Edits {
+			style := r.styles.equal
+			switch edit.Op {
+			case diff.Insert:
+				style = r.styles.insert
+			case diff.Delete:
+				style = r.styles.delete
+			}
+
+			output.WriteString(renderLines(style, r.tokenText(edit.Token.Text)))
+
+			if r.showIDs && edit.Op != diff.Equal {
+				output.WriteString(r.styles.tokenID.Render(fmt.Sprintf("[%d]", edit.Token.ID)))
+			}
+		}
+
+		output.WriteString("\n\n")
+	}
+
+	return output.String()
+}
+
+// formatDelta formats a token count change with an explicit sign
+func formatDelta(delta int) string {
+	if delta > 0 {
+		return fmt.Sprintf("+%d", delta)
+	}
+	return fmt.Sprintf("%d", delta)
+}
+
+// alignedWidth is the line width the aligned comparison wraps at when the
+// terminal width is unknown
+const alignedWidth = 120
+
+// RenderAligned renders the models as rows lined up on their shared token boundaries,
+// marking the spans where the segmentations diverge
+func (r *TerminalRenderer) RenderAligned(result *align.Result) string {
+	var output strings.Builder
+
+	output.WriteString(r.styles.header.Render("📐 Aligned Comparison"))
+	output.WriteString("\n\n")
+
+	output.WriteString(r.styles.stats.Render(fmt.Sprintf("%s · %s",
+		pluralize(result.Shared, "shared boundary"), pluralize(result.Divergent, "divergent span"))))
+	output.WriteString("\n")
+	for _, model := range result.Skipped {
+		output.WriteString(r.styles.stats.Render(fmt.Sprintf("%s has no individual tokens and is not aligned", model)))
+		output.WriteString("\n")
+	}
+	output.WriteString("\n")
+
+	if len(result.Models) == 0 {
+		return output.String()
+	}
+
+	modelStyle := r.styles.model
+	separator := r.styles.separator.Render("│")
+
+	labelWidth := 0
+	for _, model := range result.Models {
+		labelWidth = max(labelWidth, lipgloss.Width(model))
+	}
+
+	// rows holds one line per model plus the divergence marker line, which is
+	// only written for blocks with a divergent segment
+	rows := make([]strings.Builder, len(result.Models)+1)
+	lineWidth := 0
+	lineLimit := r.widthOr(alignedWidth)
+	divergent := false
+
+	flush := func() {
+		for i := range rows {
+			if i == len(result.Models) && !divergent {
+				rows[i].Reset()
+				continue
+			}
+			label := ""
+			if i < len(result.Models) {
+				label = result.Models[i]
+			}
+			output.WriteString(modelStyle.Render(label + strings.Repeat(" ", labelWidth-lipgloss.Width(label))))
+			output.WriteString(" ")
+			output.WriteString(separator)
+			output.WriteString(strings.TrimRight(rows[i].String(), " "))
+			output.WriteString("\n")
+			rows[i].Reset()
+		}
+		output.WriteString("\n")
+		lineWidth = 0
+		divergent = false
+	}
+
+	for _, segment := range result.Segments {
+		cells := make([]string, len(result.Models))
+		width := 0
+		for m := range result.Models {
+			cells[m] = r.renderAlignedCell(result.Text, segment, m)
+			width = max(width, lipgloss.Width(cells[m]))
+		}
+
+		if lineWidth > 0 && labelWidth+2+lineWidth+width+1 > lineLimit {
+			flush()
+		}
+
+		for m, cell := range cells {
+			rows[m].WriteString(cell)
+			rows[m].WriteString(strings.Repeat(" ", width-lipgloss.Width(cell)))
+			rows[m].WriteString(separator)
+		}
+
+		marker := strings.Repeat(" ", width)
+		if segment.Divergent {
+			marker = r.styles.diverge.Render(strings.Repeat("^", width))
+			divergent = true
+		}
+		rows[len(result.Models)].WriteString(marker + " ")
+
+		lineWidth += width + 1
+	}
+
+	if lineWidth > 0 {
+		flush()
+	}
+
+	return output.String()
+}
+
+// renderAlignedCell renders one model's pieces of a segment
+func (r *TerminalRenderer) renderAlignedCell(text string, segment align.Segment, model int) string {
+	var cell strings.Builder
+
+	for i, piece := range segment.Pieces[model] {
+		style := r.styles.equal
+		if segment.Divergent {
+			style = r.theme.tokenSwatch(i).style().
+				Bold(true)
+		}
+
+		if r.showBoundaries && i > 0 {
+			cell.WriteString(r.styles.separator.Render("|"))
+		}
+
+		cell.WriteString(style.Render(r.columnText(alignedPieceText(text, segment, model, i))))
+
+		if r.showIDs {
+			cell.WriteString(r.styles.tokenID.Render(fmt.Sprintf("(%s)", pieceIDs(piece))))
+		}
+	}
+
+	return cell.String()
+}
+
+// alignedPieceText returns the input text of a piece, extended over any gap
+// to the next piece so that every row of a segment covers the same text
+func alignedPieceText(text string, segment align.Segment, model, i int) string {
+	pieces := segment.Pieces[model]
+
+	start := pieces[i].Start
+	if i == 0 {
+		start = segment.Start
+	}
+	end := segment.End
+	if i+1 < len(pieces) {
+		end = pieces[i+1].Start
+	}
+
+	return text[start:end]
+}
+
+// pieceIDs joins the IDs of the tokens in a piece
+func pieceIDs(piece align.Piece) string {
+	ids := make([]string, len(piece.Tokens))
+	for i, token := range piece.Tokens {
+		ids[i] = fmt.Sprintf("%d", token.ID)
+	}
+	return strings.Join(ids, "+")
+}
+
+// renderLines styles each line of s on its own, so lipgloss doesn't pad a
+// token that contains line breaks into a block
+func renderLines(style lipgloss.Style, s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = style.Render(line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// columnText returns the text of a token for layouts that keep each token on one line
+func (r *TerminalRenderer) columnText(s string) string {
+	if r.visibleWhitespace {
+		return visibleText(s, false)
+	}
+	return escapeControl(s)
+}
+
+// escapeControl replaces line breaks and tabs so a token stays on a single line
+func escapeControl(s string) string {
+	return strings.NewReplacer("\n", `\n`, "\r", `\r`, "\t", `\t`).Replace(s)
+}
+
+// RenderEfficiency renders characters, bytes and words per token for each model,
+// overall and broken down by script and character class
+func (r *TerminalRenderer) RenderEfficiency(reports []*metrics.Report) string {
+	var output strings.Builder
+
+	output.WriteString(r.styles.header.Render("📈 Tokenization Efficiency"))
+	output.WriteString("\n\n")
+
+	modelStyle := r.styles.model
+
+	rows := make([][]string, len(reports))
+	for i, report := range reports {
+		rows[i] = append([]string{report.Model}, efficiencyCells(report.Overall)...)
+	}
+	output.WriteString(r.renderTable(append([]string{"Model"}, efficiencyHeaders...), rows, 1))
+	output.WriteString("\n")
+
+	for _, report := range reports {
+		if len(report.Scripts) == 0 {
+			continue
+		}
+
+		output.WriteString(modelStyle.Render(report.Model))
+		output.WriteString("\n\n")
+
+		output.WriteString(r.renderTable(append([]string{"Script"}, efficiencyHeaders...), groupRows(report.Scripts), 1))
+		output.WriteString("\n")
+		output.WriteString(r.renderTable(append([]string{"Class"}, efficiencyHeaders...), groupRows(report.Classes), 1))
+		output.WriteString("\n")
+	}
+
+	return output.String()
+}
+
+// RenderSections renders the tokens of each section, code block, table and
+// list of a markdown document, indented like the document outline
+func (r *TerminalRenderer) RenderSections(report *outline.Report) string {
+	var output strings.Builder
+
+	output.WriteString(r.styles.header.Render("📑 Section Budget"))
+	output.WriteString("\n\n")
+
+	for _, model := range report.Skipped {
+		output.WriteString(r.styles.stats.Render(fmt.Sprintf("%s has no individual tokens and is not broken down", model)))
+		output.WriteString("\n\n")
+	}
+
+	if len(report.Models) > 0 {
+		output.WriteString(r.renderTable(sectionHeaders(report), sectionRows(report, "  "), 1))
+	}
+
+	return output.String()
+}
+
+// sectionHeaders are the column headers of a section budget: one token
+// column per model, and the share of the first model's total
+func sectionHeaders(report *outline.Report) []string {
+	headers := append([]string{"Section"}, report.Models...)
+	return append(headers, "Share")
+}
+
+// sectionRows formats the nodes of an outline as table rows, in document
+// order, with each label indented once per level of nesting
+func sectionRows(report *outline.Report, indent string) [][]string {
+	var rows [][]string
+	total := report.Root.Tokens[0]
+
+	var walk func(node *outline.Node, depth int)
+	walk = func(node *outline.Node, depth int) {
+		row := []string{strings.Repeat(indent, depth) + node.Label()}
+		for _, tokens := range node.Tokens {
+			row = append(row, strconv.Itoa(tokens))
+		}
+		share := 0.0
+		if total > 0 {
+			share = float64(node.Tokens[0]) / float64(total) * 100
+		}
+		rows = append(rows, append(row, fmt.Sprintf("%.1f%%", share)))
+
+		for _, child := range node.Children {
+			walk(child, depth+1)
+		}
+	}
+	walk(report.Root, 0)
+
+	return rows
+}
+
+// efficiencyHeaders are the column headers for efficiency stats
+var efficiencyHeaders = []string{"Tokens", "Chars", "Bytes", "Words", "Chars/tok", "Bytes/tok", "Words/tok"}
+
+// efficiencyCells formats efficiency stats as table cells
+func efficiencyCells(stats metrics.Stats) []string {
+	return []string{
+		strconv.FormatFloat(stats.Tokens, 'f', -1, 64),
+		fmt.Sprintf("%d", stats.Chars),
+		fmt.Sprintf("%d", stats.Bytes),
+		fmt.Sprintf("%d", stats.Words),
+		fmt.Sprintf("%.2f", stats.CharsPerToken()),
+		fmt.Sprintf("%.2f", stats.BytesPerToken()),
+		fmt.Sprintf("%.2f", stats.WordsPerToken()),
+	}
+}
+
+// groupRows formats script or class stats as table rows
+func groupRows(groups []metrics.Group) [][]string {
+	rows := make([][]string, len(groups))
+	for i, group := range groups {
+		cells := efficiencyCells(group.Stats)
+		cells[0] = fmt.Sprintf("%.1f", group.Tokens)
+		rows[i] = append([]string{group.Name}, cells...)
+	}
+	return rows
+}
+
+// renderTable renders rows as aligned columns with a bold header; the first
+// leftColumns columns are left-aligned and the rest right-aligned
+func (r *TerminalRenderer) renderTable(headers []string, rows [][]string, leftColumns int) string {
+	lines := padTable(headers, rows, leftColumns)
+
+	var table strings.Builder
+	table.WriteString(r.styles.heading.Render(lines[0]))
+	table.WriteString("\n")
+	for _, line := range lines[1:] {
+		table.WriteString(line)
+		table.WriteString("\n")
+	}
+
+	return table.String()
+}
+
+// padTable pads the header and rows of a table into aligned lines, with the
+// first leftColumns columns left-aligned and the rest right-aligned
+func padTable(headers []string, rows [][]string, leftColumns int) []string {
+	widths := make([]int, len(headers))
+	for i, header := range headers {
+		widths[i] = lipgloss.Width(header)
+	}
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], lipgloss.Width(cell))
+		}
+	}
+
+	pad := func(cells []string) string {
+		padded := make([]string, len(cells))
+		for i, cell := range cells {
+			space := strings.Repeat(" ", widths[i]-lipgloss.Width(cell))
+			if i < leftColumns {
+				padded[i] = cell + space
+			} else {
+				padded[i] = space + cell
+			}
+		}
+		return strings.TrimRight(strings.Join(padded, "  "), " ")
+	}
+
+	lines := make([]string, 0, len(rows)+1)
+	lines = append(lines, pad(headers))
+	for _, row := range rows {
+		lines = append(lines, pad(row))
+	}
+	return lines
+}
+
+// RenderVocab renders vocabulary entries as a table of IDs, escaped text and bytes
+func (r *TerminalRenderer) RenderVocab(model string, entries []vocab.Entry, matched int) string {
+	var output strings.Builder
+
+	output.WriteString(r.styles.header.Render(fmt.Sprintf("📖 %s vocabulary", model)))
+	output.WriteString("\n\n")
+
+	rows := make([][]string, len(entries))
+	for i, entry := range entries {
+		rows[i] = []string{fmt.Sprintf("%d", entry.ID), entry.Escaped(), entry.Hex()}
+	}
+	output.WriteString(r.renderTable([]string{"ID", "Token", "Bytes"}, rows, 3))
+	output.WriteString("\n")
+
+	stats := fmt.Sprintf("Matches: %d", matched)
+	if matched > len(entries) {
+		stats = fmt.Sprintf("Showing %d of %d matches", len(entries), matched)
+	}
+	output.WriteString(r.styles.stats.Render(stats))
+	output.WriteString("\n")
+
+	return output.String()
+}
+
+// RenderLookup renders whether strings are single vocabulary entries and how they tokenize
+func (r *TerminalRenderer) RenderLookup(model string, matches []vocab.Match) string {
+	var output strings.Builder
+
+	output.WriteString(r.styles.header.Render(fmt.Sprintf("🔎 %s lookup", model)))
+	output.WriteString("\n\n")
+
+	foundStyle := r.theme.insert.style().Bold(true)
+	missingStyle := r.theme.delete.style().Bold(true)
+
+	for _, match := range matches {
+		output.WriteString(lipgloss.NewStyle().Bold(true).Render(strconv.Quote(match.Text)))
+		output.WriteString("\n  ")
+
+		if match.Entry != nil {
+			output.WriteString(foundStyle.Render(fmt.Sprintf("✓ single token, ID %d", match.Entry.ID)))
+			output.WriteString(r.styles.stats.Render(fmt.Sprintf("  bytes %s", match.Entry.Hex())))
+		} else {
+			output.WriteString(missingStyle.Render("✗ not in the vocabulary"))
+		}
+		output.WriteString("\n  ")
+
+		output.WriteString(r.styles.stats.Render(fmt.Sprintf("encodes as %s: ", pluralize(len(match.Encoded), "token"))))
+		for i, token := range match.Encoded {
+			text := lookupTokenText(match, token)
+			style := r.theme.tokenSwatch(i).style().Bold(true)
+			output.WriteString(style.Render(strconv.Quote(text)))
+			output.WriteString(r.styles.tokenID.Render(fmt.Sprintf("(%d) ", token.ID)))
+		}
+		output.WriteString("\n\n")
+	}
+
+	return output.String()
+}
+
+// lookupTokenText returns the text a token covers in a looked up string
+func lookupTokenText(match vocab.Match, token tokenizers.Token) string {
+	if token.Start < token.End && token.End <= len(match.Text) {
+		return match.Text[token.Start:token.End]
+	}
+	return token.Text
+}
+
+// pluralize formats a count with a singular or plural noun
+func pluralize(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, noun)
+	}
+	if stem, ok := strings.CutSuffix(noun, "y"); ok && !strings.ContainsAny(stem[len(stem)-1:], "aeiou") {
+		return fmt.Sprintf("%d %sies", n, stem)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// RenderMergeTrace renders the pre-tokenized chunks of a text and every BPE
+// merge applied to each chunk, from single bytes up to the final tokens
+func (r *TerminalRenderer) RenderMergeTrace(model string, traces []tokenizers.MergeTrace) string {
+	var output strings.Builder
+
+	output.WriteString(r.styles.header.Render(fmt.Sprintf("🧬 %s merges", model)))
+	output.WriteString("\n\n")
+
+	output.WriteString(r.styles.stats.Render(fmt.Sprintf("Pre-tokenized into %s: ", pluralize(len(traces), "chunk"))))
+	for i, trace := range traces {
+		style := r.theme.tokenSwatch(i).style().Bold(true)
+		output.WriteString(style.Render(visibleText(trace.Chunk.Text, false)))
+		if i < len(traces)-1 {
+			output.WriteString(r.styles.tokenID.Render("|"))
+		}
+	}
+	output.WriteString("\n\n")
+
+	mergedStyle := r.theme.insert.style().Bold(true)
+	labelStyle := r.theme.accent.style()
+
+	for i, trace := range traces {
+		output.WriteString(lipgloss.NewStyle().Bold(true).Render(fmt.Sprintf("Chunk %d ", i+1)))
+		output.WriteString(r.styles.stats.Render(fmt.Sprintf("%s, %s", strconv.Quote(trace.Chunk.Text), pluralize(len(trace.Steps), "merge"))))
+		output.WriteString("\n")
+
+		labels := []string{"start"}
+		for _, step := range trace.Steps {
+			labels = append(labels, fmt.Sprintf("rank %d", step.Rank))
+		}
+		labels = append(labels, "tokens")
+		width := 0
+		for _, label := range labels {
+			width = max(width, len(label))
+		}
+
+		writeRow := func(label string, parts []string, highlight int, suffix string) {
+			output.WriteString("  ")
+			output.WriteString(labelStyle.Render(fmt.Sprintf("%-*s", width, label)))
+			output.WriteString("  ")
+			for j, part := range parts {
+				style := r.styles.equal
+				if j == highlight {
+					style = mergedStyle
+				}
+				output.WriteString(style.Render(visibleText(part, false)))
+				if j < len(parts)-1 {
+					output.WriteString(r.styles.tokenID.Render("|"))
+				}
+			}
+			if suffix != "" {
+				output.WriteString(r.styles.tokenID.Render("  " + suffix))
+			}
+			output.WriteString("\n")
+		}
+
+		writeRow(labels[0], trace.Initial, -1, "")
+		for j, step := range trace.Steps {
+			// tiktoken ranks are token IDs; HF BPE ranks are merge list positions
+			suffix := ""
+			if step.ID != step.Rank {
+				suffix = fmt.Sprintf("→ ID %d", step.ID)
+			}
+			writeRow(labels[j+1], step.Parts, step.Index, suffix)
+		}
+
+		output.WriteString("  ")
+		output.WriteString(labelStyle.Render(fmt.Sprintf("%-*s", width, labels[len(labels)-1])))
+		output.WriteString("  ")
+		for j, token := range trace.Tokens {
+			style := r.theme.tokenSwatch(j).style().Bold(true)
+			output.WriteString(style.Render(visibleText(token.Text, false)))
+			output.WriteString(r.styles.tokenID.Render(fmt.Sprintf("[%d] ", token.ID)))
+		}
+		output.WriteString("\n\n")
+	}
+
+	return output.String()
+}
+
+// RenderStages renders the layers of the tokenization pipeline: the normalized
+// text, the pre-tokenized chunks and the final tokens
+func (r *TerminalRenderer) RenderStages(stages *tokenizers.Stages) string {
+	var output strings.Builder
+	result := stages.Result
+
+	output.WriteString(r.styles.header.Render(fmt.Sprintf("🔤 %s stages", result.Model)))
+	output.WriteString("\n\n")
+
+	layerStyle := r.styles.heading
+
+	output.WriteString(layerStyle.Render("1. Normalized text"))
+	if stages.Normalized == result.Text {
+		output.WriteString(r.styles.stats.Render(" (unchanged)"))
+	}
+	output.WriteString("\n")
+	output.WriteString(renderLines(r.styles.equal, stages.Normalized))
+	output.WriteString("\n\n")
+
+	output.WriteString(layerStyle.Render("2. Pre-tokenized chunks"))
+	output.WriteString(r.styles.stats.Render(fmt.Sprintf(" (%d)", len(stages.Chunks))))
+	output.WriteString("\n")
+	for i, chunk := range stages.Chunks {
+		if i > 0 {
+			output.WriteString(r.styles.tokenID.Render("|"))
+		}
+		style := r.theme.tokenSwatch(i).style().Bold(true)
+		output.WriteString(renderLines(style, r.tokenText(chunk.Text)))
+	}
+	output.WriteString("\n\n")
+
+	output.WriteString(layerStyle.Render("3. Tokens"))
+	output.WriteString(r.styles.stats.Render(fmt.Sprintf(" (%d)", result.TotalCount)))
+	output.WriteString("\n")
+	if r.coloring.HasLegend() {
+		output.WriteString(r.coloring.terminalLegend(r.theme))
+		output.WriteString("\n")
+	}
+	for i, token := range result.Tokens {
+		if r.showBoundaries && i > 0 {
+			output.WriteString(r.styles.separator.Render("|"))
+		}
+		style := r.coloring.swatch(r.theme, result, i).style().Bold(true)
+		output.WriteString(renderLines(style, r.tokenText(token.Text)))
+		if r.showIDs && token.ID >= 0 {
+			output.WriteString(r.styles.tokenID.Render(fmt.Sprintf("[%d]", token.ID)))
+		}
+	}
+	output.WriteString("\n")
+
+	return output.String()
+}
+
+// RenderBytes renders every token with its raw bytes in hex next to its text,
+// joining tokens that only form whole characters together
+func (r *TerminalRenderer) RenderBytes(result *tokenizers.TokenizationResult) string {
+	var output strings.Builder
+
+	output.WriteString(r.styles.header.Render(fmt.Sprintf("🔢 %s bytes", result.Model)))
+	output.WriteString("\n\n")
+
+	groups := bytegroup.Compute(result.Tokens)
+	output.WriteString(r.styles.stats.Render(bytesStats(result, groups)))
+	output.WriteString("\n\n")
+
+	var rows [][]string
+	i := 0
+	for _, group := range groups {
+		for j, token := range group.Tokens {
+			style := r.coloring.swatch(r.theme, result, i).style().Bold(true)
+			rows = append(rows, []string{
+				fmt.Sprintf("%d", i+1),
+				tokenID(token),
+				fmt.Sprintf("% x", token.Text),
+				style.Render(visibleText(token.Text, false)),
+				groupMarker(group, j),
+			})
+			i++
+		}
+	}
+	output.WriteString(r.renderTable([]string{"#", "ID", "Bytes", "Text", "Character"}, rows, 5))
+
+	return output.String()
+}
+
+// bytesStats summarizes the token, byte and partial character counts of a result
+func bytesStats(result *tokenizers.TokenizationResult, groups []bytegroup.Group) string {
+	partial := 0
+	for _, group := range groups {
+		if group.Partial() {
+			partial++
+		}
+	}
+	return fmt.Sprintf("Total tokens: %d · %s · %s split across tokens",
+		result.TotalCount, pluralize(len(result.Text), "byte"), pluralize(partial, "character group"))
+}
+
+// groupMarker brackets the tokens of a partial group and shows the text they
+// form together on the first of them
+func groupMarker(group bytegroup.Group, i int) string {
+	switch {
+	case !group.Partial():
+		return ""
+	case i == 0:
+		return "┐ " + visibleText(group.Text(), false)
+	case i == len(group.Tokens)-1:
+		return "┘"
+	default:
+		return "│"
+	}
+}
+
+// tokenID formats a token ID, leaving tokens without IDs blank
+func tokenID(token tokenizers.Token) string {
+	if token.ID < 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d", token.ID)
+}
@@ -0,0 +1,80 @@
+package output
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spandigital/token-visualizer/internal/align"
+	"github.com/spandigital/token-visualizer/internal/tokenizers"
+)
+
+func TestTerminalAligned(t *testing.T) {
+	tests := []struct {
+		name    string
+		results []*tokenizers.TokenizationResult
+		legend  string
+		markers int
+	}{
+		{
+			name:    "divergent",
+			results: []*tokenizers.TokenizationResult{pieces("a", "ab", " cd", "e"), pieces("b", "ab", " c", "d", "e")},
+			legend:  "2 shared boundaries · 1 divergent span",
+			markers: 1,
+		},
+		{
+			name:    "identical",
+			results: []*tokenizers.TokenizationResult{pieces("a", "ab", "c"), pieces("b", "ab", "c")},
+			legend:  "1 shared boundary · 0 divergent spans",
+			markers: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := NewTerminalRenderer(false, false).RenderAligned(align.Compute(tt.results))
+			if !strings.Contains(output, tt.legend) {
+				t.Errorf("output doesn't contain %q:\n%s", tt.legend, output)
+			}
+
+			// One row per model, plus a marker row for blocks with divergent spans
+			rows, markers := 0, 0
+			for _, line := range strings.Split(output, "\n") {
+				if strings.Contains(line, "│") && !strings.Contains(line, "Aligned Comparison") {
+					rows++
+				}
+				if strings.Contains(line, "^") {
+					markers++
+				}
+			}
+			if want := len(tt.results) + tt.markers; rows != want || markers != tt.markers {
+				t.Errorf("got %d rows and %d marker rows, want %d and %d:\n%s", rows, markers, want, tt.markers, output)
+			}
+		})
+	}
+}
+
+func TestTerminalAlignedWidth(t *testing.T) {
+	words := strings.Fields(strings.Repeat("one two three four five six seven eight nine ten ", 4))
+	result := align.Compute([]*tokenizers.TokenizationResult{pieces("a", words...), pieces("b", words...)})
+
+	tests := []struct {
+		name  string
+		width int
+		want  int
+	}{
+		{"terminal width", 40, 40},
+		{"unknown width", 0, alignedWidth},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := NewTerminalRenderer(false, false).WithWidth(tt.width).RenderAligned(result)
+			widest := 0
+			for _, line := range strings.Split(output, "\n") {
+				widest = max(widest, lipgloss.Width(line))
+			}
+			if widest > tt.want || widest < tt.want-10 {
+				t.Errorf("widest line is %d characters, want the rows wrapped at %d:\n%s", widest, tt.want, output)
+			}
+		})
+	}
+}
@@ -0,0 +1,490 @@
+package output
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"gopkg.in/yaml.v3"
+)
+
+// Theme names
+const (
+	ThemeDark         = "dark"
+	ThemeLight        = "light"
+	ThemeColorblind   = "colorblind"
+	ThemeHighContrast = "high-contrast"
+	ThemeMonochrome   = "monochrome"
+	DefaultTheme      = ThemeDark
+)
+
+// heatEntries is the number of heatmap buckets a theme has colors for
+const heatEntries = 6
+
+// Theme is the set of colors shared by the terminal, HTML and image
+// renderers. Terminal colors are ANSI 256-color codes or hex colors; HTML
+// colors are hex colors and fall back to the text color when empty.
+type Theme struct {
+	name string
+
+	// Page colors, used by HTML and image output only
+	background string
+	text       string
+	panel      string
+
+	tokens []swatch // Cycled through to show token boundaries
+	heat   []swatch // Heatmap buckets, from cool to hot
+
+	neutral   swatch // Tokens a coloring can't place, and unchanged diff tokens
+	muted     swatch // Stats, legends and borders
+	ids       swatch // Token IDs and boundary markers
+	border    swatch // Separators
+	accent    swatch // Headers
+	label     swatch // Model names and counts
+	highlight swatch // Search matches and totals
+	insert    swatch // Inserted tokens and successful lookups
+	delete    swatch // Deleted tokens, divergent spans and failed lookups
+}
+
+// ansi is shorthand for a swatch with an ANSI 256-color code and its hex value
+func ansi(code, hex string) swatch {
+	return swatch{terminal: lipgloss.Color(code), html: hex}
+}
+
+// plain is shorthand for a swatch without color that sets text attributes
+func plain(attrs swatchAttrs) swatch {
+	return swatch{terminal: lipgloss.NoColor{}, attrs: attrs}
+}
+
+// builtinThemes are the themes selectable by name
+var builtinThemes = map[string]*Theme{
+	ThemeDark: {
+		name:       ThemeDark,
+		background: "#1e1e1e",
+		text:       "#d4d4d4",
+		panel:      "#252526",
+		tokens: []swatch{
+			ansi("205", "#ff5faf"), // Pink
+			ansi("141", "#af87ff"), // Purple
+			ansi("87", "#5fffff"),  // Cyan
+			ansi("228", "#ffff87"), // Yellow
+			ansi("118", "#87ff00"), // Green
+			ansi("213", "#ff87ff"), // Magenta
+			ansi("117", "#87d7ff"), // Light Blue
+			ansi("223", "#ffd7af"), // Peach
+		},
+		heat: []swatch{
+			ansi("39", "#00afff"),  // Blue
+			ansi("87", "#5fffff"),  // Cyan
+			ansi("118", "#87ff00"), // Green
+			ansi("226", "#ffff00"), // Yellow
+			ansi("214", "#ffaf00"), // Orange
+			ansi("196", "#ff0000"), // Red
+		},
+		neutral:   ansi("245", "#8a8a8a"),
+		muted:     ansi("240", "#6a6a6a"),
+		ids:       ansi("243", "#6a6a6a"),
+		border:    ansi("238", "#3c3c3c"),
+		accent:    ansi("86", "#569cd6"),
+		label:     ansi("141", "#9cdcfe"),
+		highlight: ansi("228", "#ffd700"),
+		insert:    ansi("118", "#87ff00"),
+		delete:    ansi("203", "#ff5f5f"),
+	},
+	ThemeLight: {
+		name:       ThemeLight,
+		background: "#ffffff",
+		text:       "#24292f",
+		panel:      "#f6f8fa",
+		tokens: []swatch{
+			ansi("161", "#d7005f"), // Pink
+			ansi("91", "#8700af"),  // Purple
+			ansi("30", "#008787"),  // Teal
+			ansi("130", "#af5f00"), // Brown
+			ansi("28", "#008700"),  // Green
+			ansi("127", "#af00af"), // Magenta
+			ansi("25", "#005faf"),  // Blue
+			ansi("94", "#875f00"),  // Olive
+		},
+		heat: []swatch{
+			ansi("25", "#005faf"),  // Blue
+			ansi("30", "#008787"),  // Teal
+			ansi("28", "#008700"),  // Green
+			ansi("136", "#af8700"), // Ochre
+			ansi("166", "#d75f00"), // Orange
+			ansi("160", "#d70000"), // Red
+		},
+		neutral:   ansi("244", "#808080"),
+		muted:     ansi("243", "#767676"),
+		ids:       ansi("246", "#949494"),
+		border:    ansi("252", "#d0d7de"),
+		accent:    ansi("25", "#005faf"),
+		label:     ansi("91", "#8700af"),
+		highlight: ansi("136", "#d7af00"),
+		insert:    ansi("28", "#008700"),
+		delete:    ansi("160", "#d70000"),
+	},
+	// The Okabe-Ito palette for tokens and viridis for heatmaps stay
+	// distinguishable with the common forms of color blindness, and diffs use
+	// blue and orange instead of green and red
+	ThemeColorblind: {
+		name:       ThemeColorblind,
+		background: "#1e1e1e",
+		text:       "#d4d4d4",
+		panel:      "#252526",
+		tokens: []swatch{
+			ansi("214", "#e69f00"), // Orange
+			ansi("74", "#56b4e9"),  // Sky Blue
+			ansi("36", "#009e73"),  // Bluish Green
+			ansi("227", "#f0e442"), // Yellow
+			ansi("32", "#0072b2"),  // Blue
+			ansi("166", "#d55e00"), // Vermillion
+			ansi("175", "#cc79a7"), // Reddish Purple
+			ansi("250", "#bbbbbb"), // Grey
+		},
+		heat: []swatch{
+			ansi("67", "#31688e"),
+			ansi("36", "#1f9e89"),
+			ansi("71", "#35b779"),
+			ansi("113", "#6ece58"),
+			ansi("148", "#b5de2b"),
+			ansi("220", "#fde725"),
+		},
+		neutral:   ansi("245", "#8a8a8a"),
+		muted:     ansi("240", "#6a6a6a"),
+		ids:       ansi("243", "#6a6a6a"),
+		border:    ansi("238", "#3c3c3c"),
+		accent:    ansi("74", "#56b4e9"),
+		label:     ansi("175", "#cc79a7"),
+		highlight: ansi("227", "#f0e442"),
+		insert:    ansi("74", "#56b4e9"),
+		delete:    ansi("214", "#e69f00"),
+	},
+	ThemeHighContrast: {
+		name:       ThemeHighContrast,
+		background: "#000000",
+		text:       "#ffffff",
+		panel:      "#000000",
+		tokens: []swatch{
+			ansi("226", "#ffff00"), // Yellow
+			ansi("51", "#00ffff"),  // Cyan
+			ansi("201", "#ff00ff"), // Magenta
+			ansi("46", "#00ff00"),  // Green
+			ansi("15", "#ffffff"),  // White
+			ansi("208", "#ff8700"), // Orange
+		},
+		heat: []swatch{
+			ansi("51", "#00ffff"),  // Cyan
+			ansi("46", "#00ff00"),  // Green
+			ansi("226", "#ffff00"), // Yellow
+			ansi("214", "#ffaf00"), // Orange
+			ansi("202", "#ff5f00"), // Dark Orange
+			ansi("196", "#ff0000"), // Red
+		},
+		neutral:   ansi("250", "#bcbcbc"),
+		muted:     ansi("250", "#bcbcbc"),
+		ids:       ansi("250", "#bcbcbc"),
+		border:    ansi("255", "#eeeeee"),
+		accent:    ansi("51", "#00ffff"),
+		label:     ansi("226", "#ffff00"),
+		highlight: ansi("226", "#ffff00"),
+		insert:    ansi("46", "#00ff00"),
+		delete:    ansi("203", "#ff5f5f"),
+	},
+	// Monochrome shows token boundaries by alternating underlines, and
+	// heatmap buckets with text attributes from faint to reversed
+	ThemeMonochrome: {
+		name:       ThemeMonochrome,
+		background: "#ffffff",
+		text:       "#000000",
+		panel:      "#ffffff",
+		tokens:     []swatch{plain(0), plain(attrUnderline)},
+		heat: []swatch{
+			plain(attrFaint),
+			plain(0),
+			plain(attrUnderline),
+			plain(attrBold),
+			plain(attrBold | attrUnderline),
+			plain(attrReverse),
+		},
+		neutral:   plain(attrFaint),
+		muted:     plain(attrFaint),
+		ids:       plain(attrFaint),
+		border:    plain(0),
+		accent:    plain(attrBold),
+		label:     plain(attrBold),
+		highlight: plain(attrReverse),
+		insert:    plain(attrUnderline),
+		delete:    plain(0),
+	},
+}
+
+// NewTheme returns the built-in theme with the given name
+func NewTheme(name string) (*Theme, error) {
+	if name == "" {
+		name = DefaultTheme
+	}
+	if theme, ok := builtinThemes[name]; ok {
+		return theme, nil
+	}
+	return nil, fmt.Errorf("unknown theme %q (expected %s)", name, strings.Join(Themes(), ", "))
+}
+
+// Themes returns the names of the built-in themes
+func Themes() []string {
+	names := []string{DefaultTheme}
+	for name := range builtinThemes {
+		if name != DefaultTheme {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names[1:])
+	return names
+}
+
+// themeFile is the YAML (or JSON) form of a user-defined theme. Every color
+// is a #rrggbb hex color, and anything left out comes from the base theme.
+type themeFile struct {
+	Name       string   `yaml:"name"`
+	Base       string   `yaml:"base"`
+	Background string   `yaml:"background"`
+	Text       string   `yaml:"text"`
+	Panel      string   `yaml:"panel"`
+	Tokens     []string `yaml:"tokens"`
+	Heat       []string `yaml:"heat"`
+	Neutral    string   `yaml:"neutral"`
+	Muted      string   `yaml:"muted"`
+	IDs        string   `yaml:"ids"`
+	Border     string   `yaml:"border"`
+	Accent     string   `yaml:"accent"`
+	Label      string   `yaml:"label"`
+	Highlight  string   `yaml:"highlight"`
+	Insert     string   `yaml:"insert"`
+	Delete     string   `yaml:"delete"`
+}
+
+var hexColorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// LoadTheme reads a user-defined theme from a YAML or JSON file
+func LoadTheme(path string) (*Theme, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read theme: %w", err)
+	}
+
+	// Misspelled keys are errors rather than colors that are silently ignored
+	var file themeFile
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&file); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse theme %s: %w", path, err)
+	}
+	if file.Name == "" {
+		file.Name = path
+	}
+
+	theme, err := file.theme()
+	if err != nil {
+		return nil, fmt.Errorf("invalid theme %s: %w", path, err)
+	}
+	return theme, nil
+}
+
+// theme builds a theme by overriding the colors of the base theme
+func (f *themeFile) theme() (*Theme, error) {
+	base, err := NewTheme(f.Base)
+	if err != nil {
+		return nil, err
+	}
+	theme := *base
+	theme.name = f.Name
+
+	for _, page := range []struct {
+		key   string
+		value string
+		dst   *string
+	}{
+		{"background", f.Background, &theme.background},
+		{"text", f.Text, &theme.text},
+		{"panel", f.Panel, &theme.panel},
+	} {
+		if page.value == "" {
+			continue
+		}
+		if !hexColorPattern.MatchString(page.value) {
+			return nil, fmt.Errorf("%s: %q is not a #rrggbb color", page.key, page.value)
+		}
+		*page.dst = page.value
+	}
+
+	for _, role := range []struct {
+		key   string
+		value string
+		dst   *swatch
+	}{
+		{"neutral", f.Neutral, &theme.neutral},
+		{"muted", f.Muted, &theme.muted},
+		{"ids", f.IDs, &theme.ids},
+		{"border", f.Border, &theme.border},
+		{"accent", f.Accent, &theme.accent},
+		{"label", f.Label, &theme.label},
+		{"highlight", f.Highlight, &theme.highlight},
+		{"insert", f.Insert, &theme.insert},
+		{"delete", f.Delete, &theme.delete},
+	} {
+		if role.value == "" {
+			continue
+		}
+		s, err := hexSwatch(role.key, role.value)
+		if err != nil {
+			return nil, err
+		}
+		*role.dst = s
+	}
+
+	if len(f.Tokens) > 0 {
+		if theme.tokens, err = hexSwatches("tokens", f.Tokens); err != nil {
+			return nil, err
+		}
+	}
+	if len(f.Heat) > 0 {
+		if len(f.Heat) != heatEntries {
+			return nil, fmt.Errorf("heat: expected %d colors, got %d", heatEntries, len(f.Heat))
+		}
+		if theme.heat, err = hexSwatches("heat", f.Heat); err != nil {
+			return nil, err
+		}
+	}
+
+	return &theme, nil
+}
+
+// hexSwatch returns a swatch that uses the same hex color in every format
+func hexSwatch(key, hex string) (swatch, error) {
+	if !hexColorPattern.MatchString(hex) {
+		return swatch{}, fmt.Errorf("%s: %q is not a #rrggbb color", key, hex)
+	}
+	return swatch{terminal: lipgloss.Color(hex), html: strings.ToLower(hex)}, nil
+}
+
+// hexSwatches converts a list of hex colors to swatches
+func hexSwatches(key string, hexes []string) ([]swatch, error) {
+	swatches := make([]swatch, len(hexes))
+	for i, hex := range hexes {
+		s, err := hexSwatch(key, hex)
+		if err != nil {
+			return nil, err
+		}
+		swatches[i] = s
+	}
+	return swatches, nil
+}
+
+// Name returns the name of the theme
+func (t *Theme) Name() string {
+	return t.name
+}
+
+// HasColor reports whether the theme uses colors, as opposed to text attributes only
+func (t *Theme) HasColor() bool {
+	for _, s := range append(append([]swatch{t.accent, t.label}, t.tokens...), t.heat...) {
+		if s.html != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// AccentStyle returns the terminal style of headers
+func (t *Theme) AccentStyle() lipgloss.Style {
+	return t.accent.style()
+}
+
+// LabelStyle returns the terminal style of model names
+func (t *Theme) LabelStyle() lipgloss.Style {
+	return t.label.style()
+}
+
+// HighlightStyle returns the terminal style of totals
+func (t *Theme) HighlightStyle() lipgloss.Style {
+	return t.highlight.style()
+}
+
+// MutedStyle returns the terminal style of secondary text and borders
+func (t *Theme) MutedStyle() lipgloss.Style {
+	return t.muted.style()
+}
+
+// ErrorStyle returns the terminal style of errors
+func (t *Theme) ErrorStyle() lipgloss.Style {
+	return t.delete.style()
+}
+
+// tokenSwatch returns the cycle color of the token at index i
+func (t *Theme) tokenSwatch(i int) swatch {
+	return t.tokens[i%len(t.tokens)]
+}
+
+// htmlColor returns the HTML color of a swatch, falling back to the text color
+func (t *Theme) htmlColor(s swatch) string {
+	if s.html == "" {
+		return t.text
+	}
+	return s.html
+}
+
+// cssVariables returns the theme colors as CSS custom properties, which the
+// HTML stylesheets refer to instead of fixed colors
+func (t *Theme) cssVariables() string {
+	var css strings.Builder
+	css.WriteString(":root {\n")
+	for _, v := range []struct {
+		name  string
+		value string
+	}{
+		{"background", t.background},
+		{"text", t.text},
+		{"panel", t.panel},
+		{"neutral", t.htmlColor(t.neutral)},
+		{"muted", t.htmlColor(t.muted)},
+		{"ids", t.htmlColor(t.ids)},
+		{"border", t.htmlColor(t.border)},
+		{"accent", t.htmlColor(t.accent)},
+		{"label", t.htmlColor(t.label)},
+		{"highlight", t.htmlColor(t.highlight)},
+		{"insert", t.htmlColor(t.insert)},
+		{"delete", t.htmlColor(t.delete)},
+	} {
+		css.WriteString(fmt.Sprintf("    --%s: %s;\n", v.name, v.value))
+	}
+	css.WriteString("}\n")
+
+	for i, s := range t.tokens {
+		css.WriteString(fmt.Sprintf(".token-%d { %s }\n", i, t.cssDeclarations(s)))
+	}
+	return css.String()
+}
+
+// cssDeclarations returns the CSS declarations of a swatch
+func (t *Theme) cssDeclarations(s swatch) string {
+	declarations := []string{fmt.Sprintf("color: %s;", t.htmlColor(s))}
+	if s.attrs&attrUnderline != 0 {
+		declarations = append(declarations, "text-decoration: underline;")
+	}
+	if s.attrs&attrBold != 0 {
+		declarations = append(declarations, "font-weight: 900;")
+	}
+	if s.attrs&attrFaint != 0 {
+		declarations = append(declarations, "opacity: 0.6;")
+	}
+	if s.attrs&attrReverse != 0 {
+		declarations = append(declarations, fmt.Sprintf("color: %s; background-color: %s;", t.background, t.htmlColor(s)))
+	}
+	return strings.Join(declarations, " ")
+}
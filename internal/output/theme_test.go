@@ -0,0 +1,42 @@
+package output
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadTheme(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		err     string
+	}{
+		{"colors", "name: mine\nbase: light\naccent: \"#ff0000\"\n", ""},
+		{"empty file", "", ""},
+		{"misspelled key", "name: mine\naccnet: \"#ff0000\"\n", "field accnet not found"},
+		{"invalid color", "accent: red\n", "invalid theme"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "theme.yaml")
+			if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			theme, err := LoadTheme(path)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("error = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if theme == nil {
+				t.Error("got no theme")
+			}
+		})
+	}
+}
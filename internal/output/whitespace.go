@@ -0,0 +1,63 @@
+package output
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Markers shown in place of whitespace in visible-whitespace mode
+const (
+	spaceMarker     = "·"
+	tabMarker       = "→"
+	newlineMarker   = "↵"
+	returnMarker    = "␍"
+	noBreakMarker   = "⍽"
+	sentencePieceWS = '▁' // SentencePiece space marker, shown like any other space
+)
+
+// visibleText replaces whitespace and invisible characters with markers: · for
+// spaces, → for tabs, ↵ for line breaks, ⍽ for no-break spaces and <U+XXXX> for
+// zero-width, control and other invisible characters. Bytes that are not valid
+// UTF-8 on their own are shown as <0xNN>, the same way SentencePiece writes
+// byte-fallback tokens. If keepBreaks is set, line breaks are kept after their
+// marker so the text keeps its shape.
+func visibleText(s string, keepBreaks bool) string {
+	var text strings.Builder
+
+	for len(s) > 0 {
+		r, size := utf8.DecodeRuneInString(s)
+		switch {
+		case r == utf8.RuneError && size <= 1:
+			text.WriteString(fmt.Sprintf("<0x%02X>", s[0]))
+		case r == ' ' || r == sentencePieceWS:
+			text.WriteString(spaceMarker)
+		case r == '\t':
+			text.WriteString(tabMarker)
+		case r == '\n':
+			text.WriteString(newlineMarker)
+			if keepBreaks {
+				text.WriteString("\n")
+			}
+		case r == '\r':
+			text.WriteString(returnMarker)
+		case r == '\u00a0' || r == '\u2007' || r == '\u202f':
+			text.WriteString(noBreakMarker)
+		case isInvisible(r):
+			text.WriteString(fmt.Sprintf("<U+%04X>", r))
+		default:
+			text.WriteRune(r)
+		}
+		s = s[size:]
+	}
+
+	return text.String()
+}
+
+// isInvisible reports whether a character takes no visible space: control and
+// format characters (zero-width spaces and joiners, byte order marks, bidi
+// controls) and whitespace other than the ASCII and no-break spaces
+func isInvisible(r rune) bool {
+	return unicode.IsControl(r) || unicode.Is(unicode.Cf, r) || unicode.IsSpace(r)
+}
@@ -0,0 +1,190 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"unicode/utf8"
+
+	"github.com/spandigital/token-visualizer/internal/tokenizers"
+)
+
+// compatTokenizeRequest accepts both llama.cpp (content, add_special, with_pieces)
+// and vLLM (model, prompt, add_special_tokens, return_token_strs) request bodies
+type compatTokenizeRequest struct {
+	Content    *string `json:"content"`
+	AddSpecial bool    `json:"add_special"`
+	WithPieces bool    `json:"with_pieces"`
+
+	Model            string  `json:"model"`
+	Prompt           *string `json:"prompt"`
+	AddSpecialTokens *bool   `json:"add_special_tokens"`
+	ReturnTokenStrs  bool    `json:"return_token_strs"`
+}
+
+// llamaCppPiece is a token with its piece, which is a string for valid UTF-8
+// and a list of byte values otherwise
+type llamaCppPiece struct {
+	ID    int `json:"id"`
+	Piece any `json:"piece"`
+}
+
+type llamaCppTokenizeResponse struct {
+	Tokens any `json:"tokens"`
+}
+
+type vllmTokenizeResponse struct {
+	Count     int      `json:"count"`
+	Tokens    []int    `json:"tokens"`
+	TokenStrs []string `json:"token_strs,omitempty"`
+}
+
+type compatDetokenizeRequest struct {
+	Model  string `json:"model"`
+	Tokens []int  `json:"tokens"`
+}
+
+type llamaCppDetokenizeResponse struct {
+	Content string `json:"content"`
+}
+
+type vllmDetokenizeResponse struct {
+	Prompt string `json:"prompt"`
+}
+
+// handleCompatTokenize implements the /tokenize endpoint of llama.cpp and vLLM
+func (s *Server) handleCompatTokenize(w http.ResponseWriter, r *http.Request) {
+	var req compatTokenizeRequest
+	if !s.decodeCompatRequest(w, r, &req) {
+		return
+	}
+
+	if req.Content == nil && req.Prompt == nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("request must contain either content or prompt"))
+		return
+	}
+
+	tokenizer, model, err := s.tokenizer(req.Model)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if !tokenizer.SupportsTokenIDs() {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("model does not support token IDs: %s", model))
+		return
+	}
+
+	// llama.cpp defaults to no special tokens, vLLM defaults to adding them
+	var text string
+	addSpecial := req.AddSpecial
+	if req.Prompt != nil {
+		text = *req.Prompt
+		addSpecial = req.AddSpecialTokens == nil || *req.AddSpecialTokens
+	} else {
+		text = *req.Content
+	}
+
+	result, err := encodeWithSpecial(r.Context(), tokenizer, text, addSpecial)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("tokenization failed: %w", err))
+		return
+	}
+
+	ids := make([]int, len(result.Tokens))
+	for i, token := range result.Tokens {
+		ids[i] = token.ID
+	}
+
+	if req.Prompt != nil {
+		response := vllmTokenizeResponse{
+			Count:  len(ids),
+			Tokens: ids,
+		}
+		if req.ReturnTokenStrs {
+			// vLLM returns vocabulary strings ("Ġworld"), not decoded text
+			response.TokenStrs = make([]string, len(ids))
+			for i, id := range ids {
+				str, err := tokenizers.VocabString(r.Context(), tokenizer, id)
+				if err != nil {
+					writeError(w, http.StatusInternalServerError, fmt.Errorf("decoding failed: %w", err))
+					return
+				}
+				response.TokenStrs[i] = str
+			}
+		}
+		writeJSON(w, http.StatusOK, response)
+		return
+	}
+
+	if !req.WithPieces {
+		writeJSON(w, http.StatusOK, llamaCppTokenizeResponse{Tokens: ids})
+		return
+	}
+
+	pieces := make([]llamaCppPiece, len(ids))
+	for i, id := range ids {
+		piece, err := tokenizer.Decode(r.Context(), []int{id})
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, fmt.Errorf("decoding failed: %w", err))
+			return
+		}
+		pieces[i] = llamaCppPiece{ID: id, Piece: pieceValue(piece)}
+	}
+
+	writeJSON(w, http.StatusOK, llamaCppTokenizeResponse{Tokens: pieces})
+}
+
+// handleCompatDetokenize implements the /detokenize endpoint of llama.cpp and vLLM
+func (s *Server) handleCompatDetokenize(w http.ResponseWriter, r *http.Request) {
+	var req compatDetokenizeRequest
+	if !s.decodeCompatRequest(w, r, &req) {
+		return
+	}
+
+	tokenizer, model, err := s.tokenizer(req.Model)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if !tokenizer.SupportsDecoding() {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("model does not support decoding: %s", model))
+		return
+	}
+
+	text, err := tokenizer.Decode(r.Context(), req.Tokens)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("decoding failed: %w", err))
+		return
+	}
+
+	// vLLM clients always name the model, llama.cpp clients never do
+	if req.Model != "" {
+		writeJSON(w, http.StatusOK, vllmDetokenizeResponse{Prompt: text})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, llamaCppDetokenizeResponse{Content: text})
+}
+
+// encodeWithSpecial encodes text, adding special tokens when the tokenizer supports it
+func encodeWithSpecial(ctx context.Context, tokenizer tokenizers.Tokenizer, text string, addSpecial bool) (*tokenizers.TokenizationResult, error) {
+	if encoder, ok := tokenizer.(tokenizers.SpecialTokenEncoder); ok {
+		return encoder.EncodeWithSpecial(ctx, text, addSpecial)
+	}
+	return tokenizer.Encode(ctx, text)
+}
+
+// pieceValue returns the piece as a string, or as its byte values if it is not valid UTF-8
+func pieceValue(piece string) any {
+	if utf8.ValidString(piece) {
+		return piece
+	}
+
+	values := make([]int, len(piece))
+	for i := 0; i < len(piece); i++ {
+		values[i] = int(piece[i])
+	}
+	return values
+}
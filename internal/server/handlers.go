@@ -0,0 +1,237 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/spandigital/token-visualizer/internal/tokenizers"
+)
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+type healthResponse struct {
+	Status string   `json:"status"`
+	Models []string `json:"models"`
+}
+
+type tokenizeRequest struct {
+	Text           string `json:"text"`
+	Model          string `json:"model"`
+	Format         string `json:"format"`
+	Theme          string `json:"theme"`
+	ShowIDs        bool   `json:"show_ids"`
+	ShowBoundaries bool   `json:"show_boundaries"`
+}
+
+type tokenResponse struct {
+	Text  string `json:"text"`
+	ID    int    `json:"id"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+}
+
+type tokenizeResponse struct {
+	Model      string          `json:"model"`
+	Tokenizer  string          `json:"tokenizer"`
+	TotalCount int             `json:"total_count"`
+	Tokens     []tokenResponse `json:"tokens"`
+}
+
+type countRequest struct {
+	Text   string   `json:"text"`
+	Models []string `json:"models"`
+}
+
+type modelCount struct {
+	Model      string `json:"model"`
+	TotalCount int    `json:"total_count"`
+}
+
+type countResponse struct {
+	Counts []modelCount `json:"counts"`
+}
+
+type compareRequest struct {
+	Text           string   `json:"text"`
+	Models         []string `json:"models"`
+	Format         string   `json:"format"`
+	Theme          string   `json:"theme"`
+	ShowIDs        bool     `json:"show_ids"`
+	ShowBoundaries bool     `json:"show_boundaries"`
+}
+
+type compareResponse struct {
+	Results []tokenizeResponse `json:"results"`
+}
+
+type decodeRequest struct {
+	IDs   []int  `json:"ids"`
+	Model string `json:"model"`
+}
+
+type decodeResponse struct {
+	Model string `json:"model"`
+	Text  string `json:"text"`
+}
+
+// newTokenizeResponse converts a tokenization result into its JSON form
+func newTokenizeResponse(model string, result *tokenizers.TokenizationResult) tokenizeResponse {
+	tokens := make([]tokenResponse, len(result.Tokens))
+	for i, token := range result.Tokens {
+		tokens[i] = tokenResponse{
+			Text:  token.Text,
+			ID:    token.ID,
+			Start: token.Start,
+			End:   token.End,
+		}
+	}
+
+	return tokenizeResponse{
+		Model:      model,
+		Tokenizer:  result.Model,
+		TotalCount: result.TotalCount,
+		Tokens:     tokens,
+	}
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, healthResponse{
+		Status: "ok",
+		Models: s.names,
+	})
+}
+
+func (s *Server) handleTokenize(w http.ResponseWriter, r *http.Request) {
+	var req tokenizeRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+
+	tokenizer, model, err := s.tokenizer(req.Model)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	result, err := tokenizer.Encode(r.Context(), req.Text)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("tokenization failed: %w", err))
+		return
+	}
+
+	if req.Format != "" && req.Format != "json" {
+		body, contentType, err := s.render(req.Format, req.Theme, req.ShowIDs, req.ShowBoundaries, []*tokenizers.TokenizationResult{result})
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		writeRendered(w, body, contentType)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newTokenizeResponse(model, result))
+}
+
+func (s *Server) handleCount(w http.ResponseWriter, r *http.Request) {
+	var req countRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+
+	models := req.Models
+	if len(models) == 0 {
+		models = s.names
+	}
+
+	counts := make([]modelCount, 0, len(models))
+	for _, model := range models {
+		tokenizer, name, err := s.tokenizer(model)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		count, err := tokenizer.CountTokens(r.Context(), req.Text)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, fmt.Errorf("token counting failed for %s: %w", model, err))
+			return
+		}
+
+		counts = append(counts, modelCount{Model: name, TotalCount: count})
+	}
+
+	writeJSON(w, http.StatusOK, countResponse{Counts: counts})
+}
+
+func (s *Server) handleCompare(w http.ResponseWriter, r *http.Request) {
+	var req compareRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+
+	models := req.Models
+	if len(models) == 0 {
+		models = s.names
+	}
+
+	names := make([]string, len(models))
+	for i, model := range models {
+		_, name, err := s.tokenizer(model)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		names[i] = name
+	}
+
+	results, err := s.encodeAll(r.Context(), models, req.Text)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if req.Format != "" && req.Format != "json" {
+		body, contentType, err := s.render(req.Format, req.Theme, req.ShowIDs, req.ShowBoundaries, results)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		writeRendered(w, body, contentType)
+		return
+	}
+
+	response := compareResponse{Results: make([]tokenizeResponse, len(results))}
+	for i, result := range results {
+		response.Results[i] = newTokenizeResponse(names[i], result)
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (s *Server) handleDecode(w http.ResponseWriter, r *http.Request) {
+	var req decodeRequest
+	if !s.decodeRequest(w, r, &req) {
+		return
+	}
+
+	tokenizer, model, err := s.tokenizer(req.Model)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if !tokenizer.SupportsDecoding() {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("model does not support decoding: %s", model))
+		return
+	}
+
+	text, err := tokenizer.Decode(r.Context(), req.IDs)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("decoding failed: %w", err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, decodeResponse{Model: model, Text: text})
+}
@@ -0,0 +1,16 @@
+package server
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+//go:embed static/playground.html
+var playgroundHTML []byte
+
+// handlePlayground serves the embedded single-page playground UI
+func (s *Server) handlePlayground(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(playgroundHTML)
+}
@@ -0,0 +1,271 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spandigital/token-visualizer/internal/output"
+	"github.com/spandigital/token-visualizer/internal/tokenizers"
+)
+
+// DefaultMaxBodyBytes is the default limit for request bodies (1 MiB)
+const DefaultMaxBodyBytes = 1 << 20
+
+// Config holds the settings for a Server
+type Config struct {
+	Models       []string                        // Model names in the order they were configured
+	Tokenizers   map[string]tokenizers.Tokenizer // Warm tokenizers keyed by model name
+	MaxBodyBytes int64                           // Maximum request body size in bytes
+	Theme        *output.Theme                   // Theme of rendered formats unless a request names one
+}
+
+// Server exposes the configured tokenizers over a JSON HTTP API
+type Server struct {
+	models       []string
+	names        []string          // Public names of models, in the same order
+	specs        map[string]string // Models by public name
+	tokenizers   map[string]tokenizers.Tokenizer
+	maxBodyBytes int64
+	theme        *output.Theme
+	mux          *http.ServeMux
+}
+
+// New creates a new server from the given configuration
+func New(cfg Config) *Server {
+	maxBodyBytes := cfg.MaxBodyBytes
+	if maxBodyBytes <= 0 {
+		maxBodyBytes = DefaultMaxBodyBytes
+	}
+
+	s := &Server{
+		models:       cfg.Models,
+		names:        make([]string, len(cfg.Models)),
+		specs:        make(map[string]string, len(cfg.Models)),
+		tokenizers:   cfg.Tokenizers,
+		maxBodyBytes: maxBodyBytes,
+		theme:        cfg.Theme,
+		mux:          http.NewServeMux(),
+	}
+
+	for i, model := range cfg.Models {
+		name := publicName(model)
+		for n := 2; s.specs[name] != ""; n++ {
+			name = fmt.Sprintf("%s (%d)", publicName(model), n)
+		}
+		s.names[i] = name
+		s.specs[name] = model
+	}
+
+	s.mux.HandleFunc("GET /{$}", s.handlePlayground)
+	s.mux.HandleFunc("GET /health", s.handleHealth)
+	s.mux.HandleFunc("POST /v1/tokenize", s.handleTokenize)
+	s.mux.HandleFunc("POST /v1/count", s.handleCount)
+	s.mux.HandleFunc("POST /v1/compare", s.handleCompare)
+	s.mux.HandleFunc("POST /v1/decode", s.handleDecode)
+
+	// vLLM / llama.cpp compatible endpoints
+	s.mux.HandleFunc("POST /tokenize", s.handleCompatTokenize)
+	s.mux.HandleFunc("POST /detokenize", s.handleCompatDetokenize)
+
+	return s
+}
+
+// Handler returns the HTTP handler for the server
+func (s *Server) Handler() http.Handler {
+	return s.mux
+}
+
+// ListenAndServe serves requests on addr until ctx is canceled, then shuts down gracefully
+func (s *Server) ListenAndServe(ctx context.Context, addr string, shutdownTimeout time.Duration) error {
+	httpServer := &http.Server{
+		Addr:              addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- httpServer.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("graceful shutdown failed: %w", err)
+	}
+
+	if err := <-errCh; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
+
+// publicName names a model spec without the path, URL or options it may have,
+// which can hold secrets such as API keys. Built-in models, aliases and Claude
+// models keep their name, files are named by their base name, and remote
+// tokenizers by their host.
+func publicName(model string) string {
+	prefix, spec, ok := strings.Cut(model, ":")
+	if !ok {
+		return model
+	}
+
+	switch prefix {
+	case "claude":
+		return model
+	case "llama", "llama3":
+		return prefix + ":" + filepath.Base(spec)
+	case "remote":
+		if u, err := url.Parse(spec); err == nil && u.Host != "" {
+			return prefix + ":" + u.Host
+		}
+	}
+	return prefix
+}
+
+// tokenizer returns the warm tokenizer for a model, named by its public name
+// or its spec, and falling back to the first configured model. The public
+// name is returned for responses.
+func (s *Server) tokenizer(model string) (tokenizers.Tokenizer, string, error) {
+	if model == "" {
+		if len(s.models) == 0 {
+			return nil, "", fmt.Errorf("no models configured")
+		}
+		model = s.models[0]
+	}
+
+	spec, ok := s.specs[model]
+	if !ok {
+		spec = model
+	}
+
+	tokenizer, ok := s.tokenizers[spec]
+	if !ok {
+		return nil, "", fmt.Errorf("model not available: %s", model)
+	}
+
+	name := model
+	if i := slices.Index(s.models, spec); i >= 0 {
+		name = s.names[i]
+	}
+
+	return tokenizer, name, nil
+}
+
+// encodeAll tokenizes text with each of the requested models
+func (s *Server) encodeAll(ctx context.Context, models []string, text string) ([]*tokenizers.TokenizationResult, error) {
+	results := make([]*tokenizers.TokenizationResult, 0, len(models))
+	for _, model := range models {
+		tokenizer, _, err := s.tokenizer(model)
+		if err != nil {
+			return nil, err
+		}
+
+		result, err := tokenizer.Encode(ctx, text)
+		if err != nil {
+			return nil, fmt.Errorf("tokenization failed for %s: %w", model, err)
+		}
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// render renders results in one of the CLI output formats. Requests can only
+// name built-in themes, never theme files on the server.
+func (s *Server) render(format, themeName string, showIDs, showBoundaries bool, results []*tokenizers.TokenizationResult) (string, string, error) {
+	theme := s.theme
+	if themeName != "" {
+		var err error
+		if theme, err = output.NewTheme(themeName); err != nil {
+			return "", "", err
+		}
+	}
+
+	renderer, err := output.New(format, output.Options{ShowIDs: showIDs, ShowBoundaries: showBoundaries, Theme: theme})
+	if err != nil {
+		return "", "", err
+	}
+
+	var buf bytes.Buffer
+	if len(results) == 1 {
+		err = renderer.Single(&buf, results[0])
+	} else {
+		err = renderer.Comparison(&buf, results)
+	}
+	if err != nil {
+		return "", "", err
+	}
+
+	return buf.String(), renderer.ContentType(), nil
+}
+
+// decodeRequest reads a size-limited JSON request body into v, rejecting unknown fields
+func (s *Server) decodeRequest(w http.ResponseWriter, r *http.Request, v any) bool {
+	return s.decodeBody(w, r, v, true)
+}
+
+// decodeCompatRequest reads a request body of the vLLM and llama.cpp APIs into
+// v. Their clients send options this server has no use for, so unknown fields
+// are ignored.
+func (s *Server) decodeCompatRequest(w http.ResponseWriter, r *http.Request, v any) bool {
+	return s.decodeBody(w, r, v, false)
+}
+
+// decodeBody reads a size-limited JSON request body into v
+func (s *Server) decodeBody(w http.ResponseWriter, r *http.Request, v any, strict bool) bool {
+	r.Body = http.MaxBytesReader(w, r.Body, s.maxBodyBytes)
+
+	decoder := json.NewDecoder(r.Body)
+	if strict {
+		decoder.DisallowUnknownFields()
+	}
+	if err := decoder.Decode(v); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			writeError(w, http.StatusRequestEntityTooLarge, fmt.Errorf("request body exceeds %d bytes", maxBytesErr.Limit))
+			return false
+		}
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return false
+	}
+
+	return true
+}
+
+// writeJSON writes v as a JSON response with the given status code
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes an error as a JSON response
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
+
+// writeRendered writes pre-rendered output with its content type
+func writeRendered(w http.ResponseWriter, body, contentType string) {
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte(body))
+}
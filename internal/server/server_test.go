@@ -0,0 +1,159 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/spandigital/token-visualizer/internal/tokenizers"
+)
+
+// byteTokenizer makes every byte a token, with the byte value as its ID
+type byteTokenizer struct{}
+
+func (byteTokenizer) Name() string { return "bytes" }
+
+func (byteTokenizer) Encode(ctx context.Context, text string) (*tokenizers.TokenizationResult, error) {
+	tokens := make([]tokenizers.Token, len(text))
+	for i := range len(text) {
+		tokens[i] = tokenizers.Token{Text: text[i : i+1], ID: int(text[i]), Start: i, End: i + 1}
+	}
+	return &tokenizers.TokenizationResult{Tokens: tokens, TotalCount: len(tokens), Text: text, Model: "bytes"}, nil
+}
+
+func (byteTokenizer) CountTokens(ctx context.Context, text string) (int, error) {
+	return len(text), nil
+}
+
+func (byteTokenizer) SupportsTokenIDs() bool { return true }
+
+func (byteTokenizer) SupportsDecoding() bool { return true }
+
+func (byteTokenizer) Decode(ctx context.Context, ids []int) (string, error) {
+	b := make([]byte, len(ids))
+	for i, id := range ids {
+		if id < 0 || id > 255 {
+			return "", fmt.Errorf("unknown token %d", id)
+		}
+		b[i] = byte(id)
+	}
+	return string(b), nil
+}
+
+// newTestServer returns a server with the byte tokenizer loaded as "bytes"
+func newTestServer() *Server {
+	return New(Config{
+		Models:     []string{"bytes"},
+		Tokenizers: map[string]tokenizers.Tokenizer{"bytes": byteTokenizer{}},
+	})
+}
+
+// post sends a JSON body to path and returns the response
+func post(t *testing.T, s *Server, path, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, req)
+	return rec
+}
+
+func TestUnknownFields(t *testing.T) {
+	tests := []struct {
+		name   string
+		path   string
+		body   string
+		status int
+	}{
+		{"native", "/v1/tokenize", `{"text": "hi", "txt": "typo"}`, http.StatusBadRequest},
+		{"native without unknown fields", "/v1/tokenize", `{"text": "hi"}`, http.StatusOK},
+		{"llama.cpp", "/tokenize", `{"content": "hi", "n_probs": 5}`, http.StatusOK},
+		{"vLLM", "/tokenize", `{"prompt": "hi", "add_generation_prompt": true}`, http.StatusOK},
+		{"detokenize", "/detokenize", `{"tokens": [104, 105], "slot_id": 0}`, http.StatusOK},
+	}
+	s := newTestServer()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := post(t, s, tt.path, tt.body)
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+		})
+	}
+}
+
+func TestCompatTokenize(t *testing.T) {
+	s := newTestServer()
+
+	t.Run("vLLM token strings", func(t *testing.T) {
+		rec := post(t, s, "/tokenize", `{"prompt": "a é", "return_token_strs": true}`)
+		var response vllmTokenizeResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+			t.Fatal(err)
+		}
+		// Byte-level spellings of "a", " " and the two bytes of "é"
+		want := []string{"a", "Ġ", "Ã", "©"}
+		if strings.Join(response.TokenStrs, "|") != strings.Join(want, "|") {
+			t.Errorf("token_strs = %q, want %q", response.TokenStrs, want)
+		}
+		if response.Count != 4 {
+			t.Errorf("count = %d, want 4", response.Count)
+		}
+	})
+
+	t.Run("llama.cpp pieces", func(t *testing.T) {
+		rec := post(t, s, "/tokenize", `{"content": "a é", "with_pieces": true}`)
+		want := `{"tokens":[{"id":97,"piece":"a"},{"id":32,"piece":" "},{"id":195,"piece":[195]},{"id":169,"piece":[169]}]}`
+		if got := strings.TrimSpace(rec.Body.String()); got != want {
+			t.Errorf("response = %s, want %s", got, want)
+		}
+	})
+}
+
+func TestHealthModelNames(t *testing.T) {
+	specs := []string{
+		"gpt4",
+		"claude:claude-3-5-sonnet-20241022",
+		"llama3:/models/llama-3-8b/tokenizer.json",
+		"llama3:/models/llama-3-70b/tokenizer.json",
+		"remote:http://gpu:8000/tokenize#style=vllm&header=Authorization:Bearer%20secret",
+	}
+	loaded := map[string]tokenizers.Tokenizer{}
+	for _, spec := range specs {
+		loaded[spec] = byteTokenizer{}
+	}
+	s := New(Config{Models: specs, Tokenizers: loaded})
+
+	req := httptest.NewRequest(http.MethodGet, "/health", nil)
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, req)
+
+	var health healthResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &health); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"gpt4",
+		"claude:claude-3-5-sonnet-20241022",
+		"llama3:tokenizer.json",
+		"llama3:tokenizer.json (2)",
+		"remote:gpu:8000",
+	}
+	if strings.Join(health.Models, "|") != strings.Join(want, "|") {
+		t.Errorf("models = %q, want %q", health.Models, want)
+	}
+
+	// Models can be named by their public name or their spec, and responses use the public name
+	for _, model := range []string{"remote:gpu:8000", specs[4]} {
+		rec := post(t, s, "/v1/count", fmt.Sprintf(`{"text": "hi", "models": [%q]}`, model))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("count with %s: status %d: %s", model, rec.Code, rec.Body)
+		}
+		if strings.Contains(rec.Body.String(), "secret") {
+			t.Errorf("response to %s contains the spec: %s", model, rec.Body)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package tokenizers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// byteLevelDecoder maps the printable characters used by GPT-2 style byte-level
+// BPE vocabularies (e.g. "Ġ" for a space) back to the bytes they represent
+var byteLevelDecoder = func() map[rune]byte {
+	decoder := make(map[rune]byte, 256)
+	n := 0
+	for b := 0; b < 256; b++ {
+		printable := (b >= '!' && b <= '~') || (b >= 0xA1 && b <= 0xAC) || (b >= 0xAE && b <= 0xFF)
+		if printable {
+			decoder[rune(b)] = byte(b)
+		} else {
+			decoder[rune(256+n)] = byte(b)
+			n++
+		}
+	}
+	return decoder
+}()
+
+// byteLevelEncoder maps bytes to the characters of byte-level BPE vocabularies
+var byteLevelEncoder = func() map[byte]rune {
+	encoder := make(map[byte]rune, len(byteLevelDecoder))
+	for r, b := range byteLevelDecoder {
+		encoder[b] = r
+	}
+	return encoder
+}()
+
+// VocabString returns the vocabulary entry of a token ID. Tokenizers without
+// their own vocabulary strings get the byte-level BPE form of the token's
+// bytes, which is how GPT style vocabularies spell them.
+func VocabString(ctx context.Context, tokenizer Tokenizer, id int) (string, error) {
+	if stringer, ok := tokenizer.(VocabStringer); ok {
+		if s, ok := stringer.VocabString(id); ok {
+			return s, nil
+		}
+		return "", fmt.Errorf("token ID %d is not in the vocabulary of %s", id, tokenizer.Name())
+	}
+
+	var b []byte
+	if reader, ok := tokenizer.(VocabReader); ok {
+		b, ok = reader.TokenBytes(id)
+		if !ok {
+			return "", fmt.Errorf("token ID %d is not in the vocabulary of %s", id, tokenizer.Name())
+		}
+	} else {
+		decoded, err := tokenizer.Decode(ctx, []int{id})
+		if err != nil {
+			return "", err
+		}
+		b = []byte(decoded)
+	}
+
+	var s strings.Builder
+	for _, c := range b {
+		s.WriteRune(byteLevelEncoder[c])
+	}
+	return s.String(), nil
+}
+
+// decodeVocabString converts a raw vocabulary entry into the text it represents.
+// Byte-level BPE entries ("Ġworld") are mapped back to bytes, SentencePiece
+// entries ("▁world", "<0x0A>") have their markers replaced, and anything else
+// is returned unchanged.
+func decodeVocabString(s string) string {
+	if b, ok := decodeByteFallback(s); ok {
+		return string([]byte{b})
+	}
+
+	if strings.ContainsRune(s, '▁') {
+		return strings.ReplaceAll(s, "▁", " ")
+	}
+
+	decoded := make([]byte, 0, len(s))
+	for _, r := range s {
+		b, ok := byteLevelDecoder[r]
+		if !ok {
+			return s
+		}
+		decoded = append(decoded, b)
+	}
+	return string(decoded)
+}
+
+// decodeByteFallback parses a SentencePiece byte-fallback piece such as "<0x0A>"
+func decodeByteFallback(s string) (byte, bool) {
+	if len(s) != 6 || !strings.HasPrefix(s, "<0x") || !strings.HasSuffix(s, ">") {
+		return 0, false
+	}
+
+	value, err := strconv.ParseUint(s[3:5], 16, 8)
+	if err != nil {
+		return 0, false
+	}
+	return byte(value), true
+}
@@ -162,3 +162,8 @@ func (c *ClaudeTokenizer) SupportsTokenIDs() bool {
 func (c *ClaudeTokenizer) SupportsDecoding() bool {
 	return false
 }
+
+// Decode is not supported (Claude API doesn't provide token decoding)
+func (c *ClaudeTokenizer) Decode(ctx context.Context, ids []int) (string, error) {
+	return "", fmt.Errorf("decoding is not supported for %s", c.Name())
+}
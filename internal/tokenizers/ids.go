@@ -0,0 +1,61 @@
+package tokenizers
+
+import (
+	"context"
+	"fmt"
+)
+
+// DecodeIDs rebuilds a tokenization result from token IDs. Each ID is decoded
+// on its own and the texts are joined, so every token gets the byte offsets of
+// its text in the reconstructed input.
+func DecodeIDs(ctx context.Context, tokenizer Tokenizer, ids []int) (*TokenizationResult, error) {
+	if !tokenizer.SupportsTokenIDs() || !tokenizer.SupportsDecoding() {
+		return nil, fmt.Errorf("%s cannot decode token IDs", tokenizer.Name())
+	}
+
+	// Vocabulary readers report unknown IDs themselves
+	reader, direct := tokenizer.(VocabReader)
+	size := 0
+	if !direct {
+		size = VocabSize(tokenizer)
+	}
+
+	tokens := make([]Token, 0, len(ids))
+	var text []byte
+
+	for _, id := range ids {
+		if id < 0 || (size > 0 && id >= size) {
+			return nil, fmt.Errorf("token ID %d is out of range for %s", id, tokenizer.Name())
+		}
+
+		var b []byte
+		if direct {
+			var ok bool
+			b, ok = reader.TokenBytes(id)
+			if !ok {
+				return nil, fmt.Errorf("token ID %d is not in the vocabulary of %s", id, tokenizer.Name())
+			}
+		} else {
+			decoded, err := tokenizer.Decode(ctx, []int{id})
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode token %d: %w", id, err)
+			}
+			b = []byte(decoded)
+		}
+
+		tokens = append(tokens, Token{
+			Text:  string(b),
+			ID:    id,
+			Start: len(text),
+			End:   len(text) + len(b),
+		})
+		text = append(text, b...)
+	}
+
+	return &TokenizationResult{
+		Tokens:     tokens,
+		TotalCount: len(tokens),
+		Text:       string(text),
+		Model:      tokenizer.Name(),
+	}, nil
+}
@@ -34,4 +34,41 @@ type Tokenizer interface {
 
 	// SupportsDecoding returns true if this tokenizer can decode tokens back to text
 	SupportsDecoding() bool
+
+	// Decode converts a list of token IDs back into text
+	Decode(ctx context.Context, ids []int) (string, error)
+}
+
+// VocabSizer is implemented by tokenizers that know the size of their vocabulary
+type VocabSizer interface {
+	// VocabSize returns the number of token IDs, or 0 if it can't be determined
+	VocabSize() int
+}
+
+// VocabSize returns the number of token IDs of a tokenizer, or 0 if it's unknown
+func VocabSize(tokenizer Tokenizer) int {
+	if sizer, ok := tokenizer.(VocabSizer); ok {
+		return sizer.VocabSize()
+	}
+	return 0
+}
+
+// VocabReader is implemented by tokenizers that can look up vocabulary entries directly
+type VocabReader interface {
+	// TokenBytes returns the raw bytes of a token ID, and false if the ID is unused
+	TokenBytes(id int) ([]byte, bool)
+}
+
+// VocabStringer is implemented by tokenizers that can return the raw
+// vocabulary entry of a token, such as "Ġworld" or "<0x0A>"
+type VocabStringer interface {
+	// VocabString returns the vocabulary entry of a token ID, and false if the ID is unused
+	VocabString(id int) (string, bool)
+}
+
+// SpecialTokenEncoder is implemented by tokenizers that can add model-specific
+// special tokens (such as a beginning-of-sequence token) while encoding
+type SpecialTokenEncoder interface {
+	// EncodeWithSpecial converts text into tokens, adding special tokens if addSpecial is true
+	EncodeWithSpecial(ctx context.Context, text string, addSpecial bool) (*TokenizationResult, error)
 }
@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/lwch/sentencepiece"
 )
@@ -43,25 +45,54 @@ func (l *LLaMATokenizer) Name() string {
 
 // Encode converts text into tokens
 func (l *LLaMATokenizer) Encode(ctx context.Context, text string) (*TokenizationResult, error) {
+	return l.encode(text, true, true), nil
+}
+
+// EncodeWithSpecial converts text into tokens, prepending the BOS token if addSpecial is true
+func (l *LLaMATokenizer) EncodeWithSpecial(ctx context.Context, text string, addSpecial bool) (*TokenizationResult, error) {
+	return l.encode(text, addSpecial, false), nil
+}
+
+// encode converts text into tokens with optional BOS and EOS tokens
+func (l *LLaMATokenizer) encode(text string, bos, eos bool) *TokenizationResult {
 	// Encode text to token IDs
-	tokenIDs := l.model.Encode(text, true, true)
+	tokenIDs := l.model.Encode(text, bos, eos)
+
+	// The model matches pieces against the text with spaces replaced by "▁",
+	// so offsets are counted in that text and mapped back to the input
+	marked := strings.ReplaceAll(text, " ", "▁")
+	offsets := spaceMarkerOffsets(text)
+	matched := 0
 
 	// Build token list with details
 	tokens := make([]Token, 0, len(tokenIDs))
-	currentPos := 0
 
 	for _, id := range tokenIDs {
 		// Decode this single token to get its text
 		tokenText := l.model.Decode([]uint64{id})
 
-		token := Token{
+		// Decoding turns every "▁" back into a space
+		piece := strings.ReplaceAll(tokenText, " ", "▁")
+		var width int
+		switch {
+		case int64(id) == l.model.Bos() || int64(id) == l.model.Eos():
+			// Control tokens don't correspond to any input text
+			width = 0
+		case strings.HasPrefix(marked[matched:], piece):
+			width = len(piece)
+		default:
+			// An unknown token stands for the single byte it replaced
+			width = min(1, len(marked)-matched)
+		}
+
+		start := offsets[matched]
+		matched += width
+		tokens = append(tokens, Token{
 			Text:  tokenText,
 			ID:    int(id),
-			Start: currentPos,
-			End:   currentPos + len(tokenText),
-		}
-		tokens = append(tokens, token)
-		currentPos += len(tokenText)
+			Start: start,
+			End:   offsets[matched],
+		})
 	}
 
 	return &TokenizationResult{
@@ -69,7 +100,21 @@ func (l *LLaMATokenizer) Encode(ctx context.Context, text string) (*Tokenization
 		TotalCount: len(tokenIDs),
 		Text:       text,
 		Model:      "LLaMA",
-	}, nil
+	}
+}
+
+// spaceMarkerOffsets maps each byte offset of text with spaces replaced by
+// "▁" to the offset in text. An offset inside a "▁" maps to the end of its
+// space, so a byte piece holding part of a "▁" after the first covers nothing.
+func spaceMarkerOffsets(text string) []int {
+	offsets := make([]int, 0, len(text)+1)
+	for i := 0; i < len(text); i++ {
+		offsets = append(offsets, i)
+		if text[i] == ' ' {
+			offsets = append(offsets, i+1, i+1)
+		}
+	}
+	return append(offsets, len(text))
 }
 
 // CountTokens returns just the token count
@@ -87,3 +132,42 @@ func (l *LLaMATokenizer) SupportsTokenIDs() bool {
 func (l *LLaMATokenizer) SupportsDecoding() bool {
 	return true
 }
+
+// Decode converts token IDs back into text
+func (l *LLaMATokenizer) Decode(ctx context.Context, ids []int) (string, error) {
+	tokenIDs := make([]uint64, len(ids))
+	for i, id := range ids {
+		if id < 0 {
+			return "", fmt.Errorf("invalid token ID: %d", id)
+		}
+		tokenIDs[i] = uint64(id)
+	}
+	return l.model.Decode(tokenIDs), nil
+}
+
+// VocabSize returns the number of pieces in the SentencePiece model
+func (l *LLaMATokenizer) VocabSize() int {
+	return l.model.Count()
+}
+
+// VocabString returns the SentencePiece piece of a token ID, with "▁" for a
+// space and "<0xXX>" for a byte-fallback piece
+func (l *LLaMATokenizer) VocabString(id int) (string, bool) {
+	b, ok := l.TokenBytes(id)
+	if !ok {
+		return "", false
+	}
+	if len(b) == 1 && (b[0] < ' ' || b[0] >= utf8.RuneSelf) {
+		return fmt.Sprintf("<0x%02X>", b[0]), true
+	}
+	return strings.ReplaceAll(string(b), " ", "▁"), true
+}
+
+// TokenBytes returns the raw bytes of a token ID, with "▁" shown as a space
+func (l *LLaMATokenizer) TokenBytes(id int) ([]byte, bool) {
+	if id < 0 || id >= l.model.Count() {
+		return nil, false
+	}
+	decoded := l.model.Decode([]uint64{uint64(id)})
+	return []byte(decoded), decoded != ""
+}
@@ -2,10 +2,13 @@ package tokenizers
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 
 	"github.com/sugarme/tokenizer"
+	"github.com/sugarme/tokenizer/model/bpe"
+	"github.com/sugarme/tokenizer/normalizer"
 )
 
 // LLaMA3Tokenizer implements the Tokenizer interface using HuggingFace tokenizers
@@ -13,6 +16,9 @@ import (
 type LLaMA3Tokenizer struct {
 	tokenizer *tokenizer.Tokenizer
 	modelName string
+	// ignoreMerges makes chunks that are vocabulary entries single tokens
+	// without merging, as with ignore_merges in tokenizer.json (LLaMA 3)
+	ignoreMerges bool
 }
 
 // NewLLaMA3Tokenizer creates a new LLaMA 3+ tokenizer from a tokenizer.json file.
@@ -32,9 +38,24 @@ func NewLLaMA3Tokenizer(tokenizerPath string) (*LLaMA3Tokenizer, error) {
 		return nil, fmt.Errorf("failed to load LLaMA 3 tokenizer from %s", tokenizerPath)
 	}
 
+	// The BPE model doesn't read ignore_merges, so it's applied here
+	data, err := os.ReadFile(tokenizerPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tokenizer file: %w", err)
+	}
+	var config struct {
+		Model struct {
+			IgnoreMerges bool `json:"ignore_merges"`
+		} `json:"model"`
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse tokenizer file: %w", err)
+	}
+
 	return &LLaMA3Tokenizer{
-		tokenizer: tk,
-		modelName: "llama3",
+		tokenizer:    tk,
+		modelName:    "llama3",
+		ignoreMerges: config.Model.IgnoreMerges,
 	}, nil
 }
 
@@ -45,12 +66,18 @@ func (t *LLaMA3Tokenizer) Name() string {
 
 // Encode tokenizes the input text and returns a TokenizationResult.
 func (t *LLaMA3Tokenizer) Encode(ctx context.Context, text string) (*TokenizationResult, error) {
+	return t.EncodeWithSpecial(ctx, text, false)
+}
+
+// EncodeWithSpecial tokenizes the input text, letting the tokenizer's post-processor
+// add special tokens such as <|begin_of_text|> if addSpecial is true.
+func (t *LLaMA3Tokenizer) EncodeWithSpecial(ctx context.Context, text string, addSpecial bool) (*TokenizationResult, error) {
 	// Create input sequence
 	input := tokenizer.NewInputSequence(text)
 	encodeInput := tokenizer.NewSingleEncodeInput(input)
 
 	// Encode the text
-	encoding, err := t.tokenizer.Encode(encodeInput, false)
+	encoding, err := t.tokenizer.Encode(encodeInput, addSpecial)
 	if err != nil {
 		return nil, fmt.Errorf("failed to encode text: %w", err)
 	}
@@ -60,17 +87,26 @@ func (t *LLaMA3Tokenizer) Encode(ctx context.Context, text string) (*Tokenizatio
 	tokenStrings := encoding.GetTokens()
 	offsets := encoding.GetOffsets()
 
-	// Convert to our Token structure
+	// Convert to our Token structure. GetTokens returns the byte-level
+	// vocabulary spelling ("Ġworld", "Ċ"), so it's decoded to the input bytes
+	// the token covers, as the renderers and other tokenizers expect.
 	tokens := make([]Token, len(tokenIDs))
 	for i := range tokenIDs {
 		tokens[i] = Token{
-			Text:  tokenStrings[i],
+			Text:  decodeVocabString(tokenStrings[i]),
 			ID:    tokenIDs[i],
 			Start: offsets[i][0],
 			End:   offsets[i][1],
 		}
 	}
 
+	if t.ignoreMerges {
+		tokens, err = t.wholeChunks(text, tokens)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return &TokenizationResult{
 		Tokens:     tokens,
 		TotalCount: len(tokens),
@@ -79,8 +115,55 @@ func (t *LLaMA3Tokenizer) Encode(ctx context.Context, text string) (*Tokenizatio
 	}, nil
 }
 
+// wholeChunks replaces the tokens of every chunk that is a vocabulary entry
+// with the entry, which is what ignore_merges does
+func (t *LLaMA3Tokenizer) wholeChunks(text string, tokens []Token) ([]Token, error) {
+	chunks, err := t.chunks(text)
+	if err != nil {
+		return nil, err
+	}
+
+	var wholes []Token
+	for _, chunk := range chunks {
+		if id, ok := t.tokenizer.TokenToId(chunk.Text); ok {
+			wholes = append(wholes, Token{Text: decodeVocabString(chunk.Text), ID: id, Start: chunk.Start, End: chunk.End})
+		}
+	}
+
+	result := make([]Token, 0, len(tokens))
+	next, emitted := 0, -1
+	for _, token := range tokens {
+		// Special tokens added by the post-processor cover no text
+		if token.End == token.Start {
+			result = append(result, token)
+			continue
+		}
+
+		for next < len(wholes) && wholes[next].End <= token.Start {
+			next++
+		}
+		if next < len(wholes) && token.Start >= wholes[next].Start && token.End <= wholes[next].End {
+			if emitted != next {
+				result = append(result, wholes[next])
+				emitted = next
+			}
+			continue
+		}
+		result = append(result, token)
+	}
+	return result, nil
+}
+
 // CountTokens returns just the count of tokens without full tokenization details.
 func (t *LLaMA3Tokenizer) CountTokens(ctx context.Context, text string) (int, error) {
+	if t.ignoreMerges {
+		result, err := t.Encode(ctx, text)
+		if err != nil {
+			return 0, err
+		}
+		return result.TotalCount, nil
+	}
+
 	// Create input sequence
 	input := tokenizer.NewInputSequence(text)
 	encodeInput := tokenizer.NewSingleEncodeInput(input)
@@ -103,3 +186,152 @@ func (t *LLaMA3Tokenizer) SupportsTokenIDs() bool {
 func (t *LLaMA3Tokenizer) SupportsDecoding() bool {
 	return true
 }
+
+// Decode converts token IDs back into text.
+func (t *LLaMA3Tokenizer) Decode(ctx context.Context, ids []int) (string, error) {
+	return t.tokenizer.Decode(ids, false), nil
+}
+
+// VocabSize returns the number of token IDs, including added tokens.
+func (t *LLaMA3Tokenizer) VocabSize() int {
+	return t.tokenizer.GetVocabSize(true)
+}
+
+// TokenBytes returns the raw bytes of a token ID, decoding byte-level and
+// SentencePiece vocabulary entries.
+func (t *LLaMA3Tokenizer) TokenBytes(id int) ([]byte, bool) {
+	token, ok := t.tokenizer.IdToToken(id)
+	if !ok {
+		return nil, false
+	}
+	return []byte(decodeVocabString(token)), true
+}
+
+// VocabString returns the vocabulary entry of a token ID as it appears in tokenizer.json
+func (t *LLaMA3Tokenizer) VocabString(id int) (string, bool) {
+	return t.tokenizer.IdToToken(id)
+}
+
+// TraceMerges normalizes and pre-tokenizes text with the tokenizer's own
+// components, then replays the BPE merges of every chunk starting from single
+// characters. Added tokens are not split out first, so text containing special
+// tokens is traced as ordinary text.
+func (t *LLaMA3Tokenizer) TraceMerges(ctx context.Context, text string) ([]MergeTrace, error) {
+	model, ok := t.tokenizer.GetModel().(*bpe.BPE)
+	if !ok {
+		return nil, fmt.Errorf("%s does not use a BPE model", t.modelName)
+	}
+
+	chunks, err := t.chunks(text)
+	if err != nil {
+		return nil, err
+	}
+
+	vocab := *model.Vocab
+	merges := *model.Merges
+	unknown := -1
+	if model.UnkToken != nil {
+		if id, ok := vocab[*model.UnkToken]; ok {
+			unknown = id
+		}
+	}
+
+	traces := make([]MergeTrace, 0, len(chunks))
+	for _, chunk := range chunks {
+		// With ignore_merges a chunk in the vocabulary is never merged
+		if id, ok := vocab[chunk.Text]; ok && t.ignoreMerges {
+			whole := []bpeSymbol{{text: decodeVocabString(chunk.Text), id: id}}
+			chunk.Text = decodeVocabString(chunk.Text)
+			traces = append(traces, traceBPE(chunk, whole, nil))
+			continue
+		}
+
+		runes := []rune(chunk.Text)
+		symbols := make([]bpeSymbol, len(runes))
+		for i, r := range runes {
+			key := string(r)
+			if i > 0 && model.ContinuingSubwordPrefix != nil {
+				key = *model.ContinuingSubwordPrefix + key
+			}
+			if i == len(runes)-1 && model.EndOfWordSuffix != nil {
+				key += *model.EndOfWordSuffix
+			}
+
+			id, ok := vocab[key]
+			if !ok {
+				id = unknown
+			}
+			symbols[i] = bpeSymbol{text: decodeVocabString(string(r)), id: id}
+		}
+
+		chunk.Text = decodeVocabString(chunk.Text)
+		traces = append(traces, traceBPE(chunk, symbols, func(left, right bpeSymbol) (int, int, bool) {
+			merge, ok := merges[bpe.Pair{C1: left.id, C2: right.id}]
+			return merge.Rank, merge.NewId, ok
+		}))
+	}
+
+	return traces, nil
+}
+
+// Stages runs the normalizer and pre-tokenizer over text, then encodes it.
+// Added tokens are not split out before the first two layers.
+func (t *LLaMA3Tokenizer) Stages(ctx context.Context, text string) (*Stages, error) {
+	normalized, err := t.normalize(text)
+	if err != nil {
+		return nil, err
+	}
+
+	chunks, err := t.chunks(text)
+	if err != nil {
+		return nil, err
+	}
+	for i := range chunks {
+		chunks[i].Text = decodeVocabString(chunks[i].Text)
+	}
+
+	result, err := t.Encode(ctx, text)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Stages{Normalized: normalized.GetNormalized(), Chunks: chunks, Result: result}, nil
+}
+
+// normalize runs the tokenizer's normalizer, if any, over text
+func (t *LLaMA3Tokenizer) normalize(text string) (*normalizer.NormalizedString, error) {
+	normalized := normalizer.NewNormalizedFrom(text)
+	if n := t.tokenizer.GetNormalizer(); n != nil {
+		var err error
+		normalized, err = n.Normalize(normalized)
+		if err != nil {
+			return nil, fmt.Errorf("failed to normalize text: %w", err)
+		}
+	}
+	return normalized, nil
+}
+
+// chunks runs the normalizer and pre-tokenizer over text. Chunk text is in
+// the vocabulary's alphabet (e.g. "Ġworld" for byte-level BPE).
+func (t *LLaMA3Tokenizer) chunks(text string) ([]Chunk, error) {
+	normalized, err := t.normalize(text)
+	if err != nil {
+		return nil, err
+	}
+
+	pretokenized := tokenizer.NewPreTokenizedStringFromNS(normalized)
+	if p := t.tokenizer.GetPreTokenizer(); p != nil {
+		pretokenized, err = p.PreTokenize(pretokenized)
+		if err != nil {
+			return nil, fmt.Errorf("failed to pre-tokenize text: %w", err)
+		}
+	}
+
+	splits := pretokenized.GetSplits(normalizer.OriginalTarget, tokenizer.Byte)
+	chunks := make([]Chunk, len(splits))
+	for i, split := range splits {
+		chunks[i] = Chunk{Text: split.Value, Start: split.Offsets[0], End: split.Offsets[1]}
+	}
+
+	return chunks, nil
+}
@@ -0,0 +1,188 @@
+package tokenizers
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/sugarme/tokenizer"
+	"github.com/sugarme/tokenizer/model"
+	"github.com/sugarme/tokenizer/model/bpe"
+	"github.com/sugarme/tokenizer/normalizer"
+	"github.com/sugarme/tokenizer/pretokenizer"
+)
+
+// newTestLLaMA3 builds a byte-level BPE tokenizer like LLaMA 3's, with a
+// vocabulary of the byte-level pieces and merges of each pair of pieces
+func newTestLLaMA3(t *testing.T, pieces []string, merges [][2]string) *LLaMA3Tokenizer {
+	t.Helper()
+
+	vocab := model.Vocab{}
+	for i, piece := range pieces {
+		vocab[piece] = i
+	}
+	ranks := bpe.Merges{}
+	for rank, merge := range merges {
+		ranks[bpe.Pair{C1: vocab[merge[0]], C2: vocab[merge[1]]}] = bpe.PairVal{Rank: rank, NewId: vocab[merge[0]+merge[1]]}
+	}
+
+	builder := bpe.NewBpeBuilder()
+	builder.VocabAndMerges(vocab, ranks)
+	bpeModel, err := builder.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	byteLevel := pretokenizer.NewByteLevel()
+	byteLevel.SetAddPrefixSpace(false)
+	tk := tokenizer.NewTokenizer(bpeModel)
+	tk.WithPreTokenizer(byteLevel)
+
+	return &LLaMA3Tokenizer{tokenizer: tk, modelName: "llama3"}
+}
+
+func TestLLaMA3TokenText(t *testing.T) {
+	// "Ġ" is the byte-level spelling of a space, "Ċ" of a line break, and "Ã"
+	// and "©" of the two bytes of "é"
+	tokenizer := newTestLLaMA3(t,
+		[]string{"H", "i", "Ġ", "Ċ", "Hi", "ĠHi", "Ã", "©", "Ã©"},
+		[][2]string{{"H", "i"}, {"Ġ", "Hi"}, {"Ã", "©"}})
+
+	result, err := tokenizer.Encode(context.Background(), "Hi Hi\né")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Token text is the input bytes the token covers, as for every other
+	// tokenizer, not the vocabulary spelling
+	checkTokens(t, result, []Token{
+		{ID: 4, Text: "Hi", Start: 0, End: 2},
+		{ID: 5, Text: " Hi", Start: 2, End: 5},
+		{ID: 3, Text: "\n", Start: 5, End: 6},
+		{ID: 8, Text: "é", Start: 6, End: 8},
+	})
+	for _, token := range result.Tokens {
+		if got := result.Text[token.Start:token.End]; got != token.Text {
+			t.Errorf("token %d text %q, but it covers %q", token.ID, token.Text, got)
+		}
+	}
+}
+
+func TestLLaMA3TraceMerges(t *testing.T) {
+	tokenizer := newTestLLaMA3(t,
+		[]string{"H", "i", "Ġ", "Hi", "ĠHi"},
+		[][2]string{{"H", "i"}, {"Ġ", "Hi"}})
+
+	traces, err := tokenizer.TraceMerges(context.Background(), "Hi Hi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(traces) != 2 {
+		t.Fatalf("got %d traces, want 2", len(traces))
+	}
+
+	// Chunks and parts are raw bytes, not byte-level spellings
+	want := [][]string{{"0@0:Hi"}, {"0@1: |Hi", "1@0: Hi"}}
+	for i, trace := range traces {
+		if got := steps(trace); !slices.Equal(got, want[i]) {
+			t.Errorf("chunk %q steps = %q, want %q", trace.Chunk.Text, got, want[i])
+		}
+	}
+	checkTokens(t, &TokenizationResult{Tokens: append(traces[0].Tokens, traces[1].Tokens...)}, []Token{
+		{ID: 3, Text: "Hi", Start: 0, End: 2},
+		{ID: 4, Text: " Hi", Start: 2, End: 5},
+	})
+}
+
+func TestLLaMA3IgnoreMerges(t *testing.T) {
+	// The merges make "abc" into "a" and "bc", but "abc" is in the vocabulary
+	pieces := []string{"a", "b", "c", "Ġ", "bc", "abc"}
+	merges := [][2]string{{"b", "c"}}
+
+	tests := []struct {
+		name         string
+		ignoreMerges bool
+		steps        [][]string
+		tokens       []Token
+	}{
+		{
+			name:  "merges",
+			steps: [][]string{{"0@1:a|bc"}, {"0@1: |bc"}},
+			tokens: []Token{
+				{ID: 0, Text: "a", Start: 0, End: 1},
+				{ID: 4, Text: "bc", Start: 1, End: 3},
+				{ID: 3, Text: " ", Start: 3, End: 4},
+				{ID: 4, Text: "bc", Start: 4, End: 6},
+			},
+		},
+		{
+			// "abc" is looked up whole, " bc" isn't in the vocabulary and is merged
+			name:         "ignore merges",
+			ignoreMerges: true,
+			steps:        [][]string{nil, {"0@1: |bc"}},
+			tokens: []Token{
+				{ID: 5, Text: "abc", Start: 0, End: 3},
+				{ID: 3, Text: " ", Start: 3, End: 4},
+				{ID: 4, Text: "bc", Start: 4, End: 6},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokenizer := newTestLLaMA3(t, pieces, merges)
+			tokenizer.ignoreMerges = tt.ignoreMerges
+			ctx := context.Background()
+
+			result, err := tokenizer.Encode(ctx, "abc bc")
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkTokens(t, result, tt.tokens)
+			if count, err := tokenizer.CountTokens(ctx, "abc bc"); err != nil || count != len(tt.tokens) {
+				t.Errorf("CountTokens = %d, %v, want %d", count, err, len(tt.tokens))
+			}
+
+			// The trace ends in the tokens the encoder produced
+			traces, err := tokenizer.TraceMerges(ctx, "abc bc")
+			if err != nil {
+				t.Fatal(err)
+			}
+			var traced []Token
+			for i, trace := range traces {
+				if got := steps(trace); !slices.Equal(got, tt.steps[i]) {
+					t.Errorf("chunk %q steps = %q, want %q", trace.Chunk.Text, got, tt.steps[i])
+				}
+				traced = append(traced, trace.Tokens...)
+			}
+			checkTokens(t, &TokenizationResult{Tokens: traced}, tt.tokens)
+		})
+	}
+}
+
+func TestLLaMA3Stages(t *testing.T) {
+	tokenizer := newTestLLaMA3(t,
+		[]string{"h", "i", "Ġ", "Ċ", "hi", "Ġhi"},
+		[][2]string{{"h", "i"}, {"Ġ", "hi"}})
+	tokenizer.tokenizer.WithNormalizer(normalizer.Lowercase())
+
+	stages, err := tokenizer.Stages(context.Background(), "Hi HI\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stages.Normalized != "hi hi\n" {
+		t.Errorf("normalized = %q, want %q", stages.Normalized, "hi hi\n")
+	}
+
+	// Chunks are decoded from the byte-level alphabet and keep the offsets of the input
+	want := []Chunk{{Text: "hi", Start: 0, End: 2}, {Text: " hi", Start: 2, End: 5}, {Text: "\n", Start: 5, End: 6}}
+	if !slices.Equal(stages.Chunks, want) {
+		t.Errorf("chunks = %q, want %q", stages.Chunks, want)
+	}
+
+	checkTokens(t, stages.Result, []Token{
+		{ID: 4, Text: "hi", Start: 0, End: 2},
+		{ID: 5, Text: " hi", Start: 2, End: 5},
+		{ID: 3, Text: "\n", Start: 5, End: 6},
+	})
+}
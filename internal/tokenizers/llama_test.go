@@ -0,0 +1,97 @@
+package tokenizers
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/lwch/sentencepiece"
+	"google.golang.org/protobuf/proto"
+)
+
+// newTestLLaMA writes a SentencePiece model with the given normal pieces, plus
+// the unknown, BOS and EOS pieces, and loads it
+func newTestLLaMA(t *testing.T, pieces ...string) *LLaMATokenizer {
+	t.Helper()
+
+	piece := func(text string, kind sentencepiece.ModelProto_SentencePiece_Type) *sentencepiece.ModelProto_SentencePiece {
+		return &sentencepiece.ModelProto_SentencePiece{Piece: proto.String(text), Score: proto.Float32(0), Type: kind.Enum()}
+	}
+	model := &sentencepiece.ModelProto{Pieces: []*sentencepiece.ModelProto_SentencePiece{
+		piece("<unk>", sentencepiece.ModelProto_SentencePiece_UNKNOWN),
+		piece("<s>", sentencepiece.ModelProto_SentencePiece_CONTROL),
+		piece("</s>", sentencepiece.ModelProto_SentencePiece_CONTROL),
+	}}
+	for _, text := range pieces {
+		model.Pieces = append(model.Pieces, piece(text, sentencepiece.ModelProto_SentencePiece_NORMAL))
+	}
+
+	data, err := proto.Marshal(model)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "tokenizer.model")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tokenizer, err := NewLLaMATokenizer(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tokenizer
+}
+
+func TestLLaMAOffsets(t *testing.T) {
+	tokenizer := newTestLLaMA(t, "Hello", "▁world", "▁", "!")
+
+	tests := []struct {
+		name string
+		text string
+		want []Token
+	}{
+		{
+			name: "spaces",
+			text: "Hello world!",
+			want: []Token{
+				{ID: 1, Text: "<s>", Start: 0, End: 0},
+				{ID: 3, Text: "Hello", Start: 0, End: 5},
+				{ID: 4, Text: " world", Start: 5, End: 11},
+				{ID: 6, Text: "!", Start: 11, End: 12},
+				{ID: 2, Text: "</s>", Start: 12, End: 12},
+			},
+		},
+		{
+			name: "unknown bytes",
+			text: "Hello?!",
+			want: []Token{
+				{ID: 1, Text: "<s>", Start: 0, End: 0},
+				{ID: 3, Text: "Hello", Start: 0, End: 5},
+				{ID: 0, Text: "<unk>", Start: 5, End: 6},
+				{ID: 6, Text: "!", Start: 6, End: 7},
+				{ID: 2, Text: "</s>", Start: 7, End: 7},
+			},
+		},
+		{
+			name: "space marker in the input",
+			text: "Hello▁world ",
+			want: []Token{
+				{ID: 1, Text: "<s>", Start: 0, End: 0},
+				{ID: 3, Text: "Hello", Start: 0, End: 5},
+				{ID: 4, Text: " world", Start: 5, End: 13},
+				{ID: 5, Text: " ", Start: 13, End: 14},
+				{ID: 2, Text: "</s>", Start: 14, End: 14},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := tokenizer.Encode(context.Background(), tt.text)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkTokens(t, result, tt.want)
+		})
+	}
+}
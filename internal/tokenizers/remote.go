@@ -0,0 +1,405 @@
+package tokenizers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spandigital/token-visualizer/internal/cache"
+)
+
+// Remote API styles
+const (
+	RemoteStyleLlamaCpp = "llamacpp"
+	RemoteStyleVLLM     = "vllm"
+)
+
+// RemoteConfig configures a RemoteTokenizer
+type RemoteConfig struct {
+	URL     string            // URL of the /tokenize endpoint
+	Style   string            // API style: "llamacpp" or "vllm"
+	Model   string            // Model name sent to vLLM servers
+	Headers map[string]string // Extra request headers, e.g. Authorization
+	Timeout time.Duration     // Per-request timeout
+	Client  *http.Client      // Optional HTTP client (a default one is created if nil)
+}
+
+// RemoteTokenizer implements the Tokenizer interface by calling the /tokenize and
+// /detokenize endpoints of a running llama.cpp or vLLM server
+type RemoteTokenizer struct {
+	config        RemoteConfig
+	detokenizeURL string
+	client        *http.Client
+	cache         *cache.Cache
+}
+
+type remoteLlamaCppTokenizeRequest struct {
+	Content    string `json:"content"`
+	AddSpecial bool   `json:"add_special"`
+	WithPieces bool   `json:"with_pieces"`
+}
+
+type remoteLlamaCppPiece struct {
+	ID    int             `json:"id"`
+	Piece json.RawMessage `json:"piece"`
+}
+
+type remoteLlamaCppTokenizeResponse struct {
+	Tokens []remoteLlamaCppPiece `json:"tokens"`
+}
+
+type remoteVLLMTokenizeRequest struct {
+	Model            string `json:"model,omitempty"`
+	Prompt           string `json:"prompt"`
+	AddSpecialTokens bool   `json:"add_special_tokens"`
+	ReturnTokenStrs  bool   `json:"return_token_strs"`
+}
+
+type remoteVLLMTokenizeResponse struct {
+	Tokens    []int    `json:"tokens"`
+	TokenStrs []string `json:"token_strs"`
+}
+
+type remoteDetokenizeRequest struct {
+	Model  string `json:"model,omitempty"`
+	Tokens []int  `json:"tokens"`
+}
+
+type remoteDetokenizeResponse struct {
+	Content string `json:"content"` // llama.cpp
+	Prompt  string `json:"prompt"`  // vLLM
+}
+
+// remotePiece is a cached token ID with the bytes of its text. The bytes of a
+// token can be part of a UTF-8 sequence, which a JSON string can't hold, so
+// they are cached as a byte slice.
+type remotePiece struct {
+	ID    int
+	Bytes []byte
+}
+
+// ParseRemoteSpec parses the part of a "remote:" model spec after the prefix.
+// The spec is the URL of the /tokenize endpoint; options go in the URL fragment, e.g.
+// http://gpu:8000/tokenize#style=vllm&model=meta-llama/Llama-3-8B&timeout=5s&header=Authorization:Bearer%20$VLLM_API_KEY
+// Header values are expanded with environment variables if expandEnv is set;
+// specs from files the user didn't write should be parsed without it, so they
+// can't send the user's secrets to a server of their choosing.
+func ParseRemoteSpec(spec string, expandEnv bool) (RemoteConfig, error) {
+	u, err := url.Parse(spec)
+	if err != nil {
+		return RemoteConfig{}, fmt.Errorf("invalid remote URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return RemoteConfig{}, fmt.Errorf("remote URL must use http or https: %s", spec)
+	}
+
+	options, err := url.ParseQuery(u.Fragment)
+	if err != nil {
+		return RemoteConfig{}, fmt.Errorf("invalid remote options: %w", err)
+	}
+	u.Fragment = ""
+
+	cfg := RemoteConfig{
+		URL:     u.String(),
+		Style:   options.Get("style"),
+		Model:   options.Get("model"),
+		Headers: make(map[string]string),
+		Timeout: 30 * time.Second,
+	}
+
+	if cfg.Style == "" {
+		cfg.Style = RemoteStyleLlamaCpp
+	}
+
+	if timeout := options.Get("timeout"); timeout != "" {
+		cfg.Timeout, err = time.ParseDuration(timeout)
+		if err != nil {
+			return RemoteConfig{}, fmt.Errorf("invalid remote timeout: %w", err)
+		}
+	}
+
+	for _, header := range options["header"] {
+		name, value, ok := strings.Cut(header, ":")
+		if !ok {
+			return RemoteConfig{}, fmt.Errorf("remote header must be in Name:Value format: %s", header)
+		}
+		if expandEnv {
+			value = os.ExpandEnv(value)
+		}
+		cfg.Headers[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
+
+	return cfg, nil
+}
+
+// NewRemoteTokenizer creates a tokenizer backed by a remote /tokenize endpoint
+func NewRemoteTokenizer(cfg RemoteConfig, useCache bool) (*RemoteTokenizer, error) {
+	if cfg.Style != RemoteStyleLlamaCpp && cfg.Style != RemoteStyleVLLM {
+		return nil, fmt.Errorf("unknown remote style: %s (expected %s or %s)", cfg.Style, RemoteStyleLlamaCpp, RemoteStyleVLLM)
+	}
+
+	if cfg.Style == RemoteStyleVLLM && cfg.Model == "" {
+		return nil, fmt.Errorf("vllm remote requires a model option, e.g. #style=vllm&model=model-name")
+	}
+
+	if !strings.HasSuffix(cfg.URL, "/tokenize") {
+		return nil, fmt.Errorf("remote URL must point to a /tokenize endpoint: %s", cfg.URL)
+	}
+
+	client := cfg.Client
+	if client == nil {
+		client = &http.Client{Timeout: cfg.Timeout}
+	}
+
+	var c *cache.Cache
+	var err error
+	if useCache {
+		c, err = cache.NewCache("")
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize cache: %w", err)
+		}
+	}
+
+	return &RemoteTokenizer{
+		config:        cfg,
+		detokenizeURL: strings.TrimSuffix(cfg.URL, "/tokenize") + "/detokenize",
+		client:        client,
+		cache:         c,
+	}, nil
+}
+
+// Name returns the name of this tokenizer
+func (r *RemoteTokenizer) Name() string {
+	return fmt.Sprintf("Remote (%s)", r.modelName())
+}
+
+// modelName returns the configured model name, or the server host if none is set
+func (r *RemoteTokenizer) modelName() string {
+	if r.config.Model != "" {
+		return r.config.Model
+	}
+	if u, err := url.Parse(r.config.URL); err == nil {
+		return u.Host
+	}
+	return r.config.URL
+}
+
+// Encode converts text into tokens using the remote server
+func (r *RemoteTokenizer) Encode(ctx context.Context, text string) (*TokenizationResult, error) {
+	pieces, err := r.tokenize(ctx, text)
+	if err != nil {
+		return nil, err
+	}
+
+	// Build token list with details
+	tokens := make([]Token, 0, len(pieces))
+	currentPos := 0
+
+	for _, piece := range pieces {
+		start, end := matchPiece(text, currentPos, piece.Bytes)
+		tokens = append(tokens, Token{
+			Text:  string(piece.Bytes),
+			ID:    piece.ID,
+			Start: start,
+			End:   end,
+		})
+		currentPos = end
+	}
+
+	return &TokenizationResult{
+		Tokens:     tokens,
+		TotalCount: len(tokens),
+		Text:       text,
+		Model:      r.modelName(),
+	}, nil
+}
+
+// matchPiece returns the span of piece in text at pos. Servers may add a space
+// before the first word, which is left out of the span, and special tokens or
+// pieces of normalized text that don't match the text get an empty span at
+// pos, so spans never go past the end of text.
+func matchPiece(text string, pos int, piece []byte) (start, end int) {
+	rest := text[pos:]
+	switch {
+	case strings.HasPrefix(rest, string(piece)):
+		return pos, pos + len(piece)
+	case piece[0] == ' ' && strings.HasPrefix(rest, string(piece[1:])):
+		return pos, pos + len(piece) - 1
+	default:
+		return pos, pos
+	}
+}
+
+// CountTokens returns the token count from the remote server
+func (r *RemoteTokenizer) CountTokens(ctx context.Context, text string) (int, error) {
+	pieces, err := r.tokenize(ctx, text)
+	if err != nil {
+		return 0, err
+	}
+	return len(pieces), nil
+}
+
+// SupportsTokenIDs returns true
+func (r *RemoteTokenizer) SupportsTokenIDs() bool {
+	return true
+}
+
+// SupportsDecoding returns true
+func (r *RemoteTokenizer) SupportsDecoding() bool {
+	return true
+}
+
+// Decode converts token IDs back into text using the remote /detokenize endpoint
+func (r *RemoteTokenizer) Decode(ctx context.Context, ids []int) (string, error) {
+	reqBody := remoteDetokenizeRequest{Tokens: ids}
+	if r.config.Style == RemoteStyleVLLM {
+		reqBody.Model = r.config.Model
+	}
+
+	var response remoteDetokenizeResponse
+	if err := r.post(ctx, r.detokenizeURL, reqBody, &response); err != nil {
+		return "", err
+	}
+
+	if r.config.Style == RemoteStyleVLLM {
+		return response.Prompt, nil
+	}
+	return response.Content, nil
+}
+
+// tokenize returns the token IDs and texts for text, using the cache when enabled
+func (r *RemoteTokenizer) tokenize(ctx context.Context, text string) ([]remotePiece, error) {
+	// Check cache first
+	cacheKey := fmt.Sprintf("remote:%s:%s:%s", r.config.URL, r.config.Model, text)
+	if r.cache != nil {
+		var pieces []remotePiece
+		if err := r.cache.Get(cacheKey, &pieces); err == nil {
+			return pieces, nil
+		}
+	}
+
+	var pieces []remotePiece
+	var err error
+	switch r.config.Style {
+	case RemoteStyleVLLM:
+		pieces, err = r.tokenizeVLLM(ctx, text)
+	default:
+		pieces, err = r.tokenizeLlamaCpp(ctx, text)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Cache the result
+	if r.cache != nil {
+		_ = r.cache.Set(cacheKey, pieces)
+	}
+
+	return pieces, nil
+}
+
+// tokenizeLlamaCpp calls a llama.cpp /tokenize endpoint with pieces enabled
+func (r *RemoteTokenizer) tokenizeLlamaCpp(ctx context.Context, text string) ([]remotePiece, error) {
+	reqBody := remoteLlamaCppTokenizeRequest{
+		Content:    text,
+		WithPieces: true,
+	}
+
+	var response remoteLlamaCppTokenizeResponse
+	if err := r.post(ctx, r.config.URL, reqBody, &response); err != nil {
+		return nil, err
+	}
+
+	pieces := make([]remotePiece, len(response.Tokens))
+	for i, token := range response.Tokens {
+		// Pieces are strings, or arrays of byte values when they are not valid UTF-8
+		var text string
+		if err := json.Unmarshal(token.Piece, &text); err == nil {
+			pieces[i] = remotePiece{ID: token.ID, Bytes: []byte(text)}
+			continue
+		}
+		var values []int
+		if err := json.Unmarshal(token.Piece, &values); err != nil {
+			return nil, fmt.Errorf("failed to decode piece for token %d: %w", token.ID, err)
+		}
+		piece := make([]byte, len(values))
+		for j, v := range values {
+			piece[j] = byte(v)
+		}
+		pieces[i] = remotePiece{ID: token.ID, Bytes: piece}
+	}
+
+	return pieces, nil
+}
+
+// tokenizeVLLM calls a vLLM /tokenize endpoint with token strings enabled
+func (r *RemoteTokenizer) tokenizeVLLM(ctx context.Context, text string) ([]remotePiece, error) {
+	reqBody := remoteVLLMTokenizeRequest{
+		Model:           r.config.Model,
+		Prompt:          text,
+		ReturnTokenStrs: true,
+	}
+
+	var response remoteVLLMTokenizeResponse
+	if err := r.post(ctx, r.config.URL, reqBody, &response); err != nil {
+		return nil, err
+	}
+
+	if len(response.TokenStrs) != len(response.Tokens) {
+		return nil, fmt.Errorf("remote server did not return token strings (requires a vLLM version supporting return_token_strs)")
+	}
+
+	pieces := make([]remotePiece, len(response.Tokens))
+	for i, id := range response.Tokens {
+		pieces[i] = remotePiece{ID: id, Bytes: []byte(decodeVocabString(response.TokenStrs[i]))}
+	}
+
+	return pieces, nil
+}
+
+// post sends a JSON request and decodes the JSON response into out
+func (r *RemoteTokenizer) post(ctx context.Context, endpoint string, body, out any) error {
+	jsonData, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	// Create HTTP request
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("content-type", "application/json")
+	for name, value := range r.config.Headers {
+		req.Header.Set(name, value)
+	}
+
+	// Send request
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("remote request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	// Parse response
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,339 @@
+package tokenizers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/spandigital/token-visualizer/internal/cache"
+)
+
+// newTestRemote starts a server with handler and returns a tokenizer for its
+// /tokenize endpoint, configured by the URL fragment options
+func newTestRemote(t *testing.T, options string, handler http.HandlerFunc) *RemoteTokenizer {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	cfg, err := ParseRemoteSpec(server.URL+"/tokenize"+options, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokenizer, err := NewRemoteTokenizer(cfg, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tokenizer
+}
+
+// writeJSON writes v as the JSON response
+func writeJSON(t *testing.T, w http.ResponseWriter, v any) {
+	t.Helper()
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		t.Error(err)
+	}
+}
+
+// checkTokens compares the IDs, text bytes and offsets of tokens
+func checkTokens(t *testing.T, result *TokenizationResult, want []Token) {
+	t.Helper()
+	if len(result.Tokens) != len(want) {
+		t.Fatalf("got %d tokens, want %d: %+v", len(result.Tokens), len(want), result.Tokens)
+	}
+	for i, token := range result.Tokens {
+		if token.ID != want[i].ID || token.Text != want[i].Text || token.Start != want[i].Start || token.End != want[i].End {
+			t.Errorf("token %d = {%d %q %d-%d}, want {%d %q %d-%d}", i,
+				token.ID, token.Text, token.Start, token.End,
+				want[i].ID, want[i].Text, want[i].Start, want[i].End)
+		}
+	}
+}
+
+func TestParseRemoteSpec(t *testing.T) {
+	t.Setenv("REMOTE_TEST_TOKEN", "secret")
+
+	tests := []struct {
+		name      string
+		spec      string
+		expandEnv bool
+		want      RemoteConfig
+		err       string
+	}{
+		{
+			name: "defaults",
+			spec: "http://localhost:8080/tokenize",
+			want: RemoteConfig{URL: "http://localhost:8080/tokenize", Style: RemoteStyleLlamaCpp, Timeout: 30 * time.Second},
+		},
+		{
+			name: "vllm options",
+			spec: "https://gpu:8000/tokenize#style=vllm&model=meta-llama/Llama-3-8B&timeout=5s",
+			want: RemoteConfig{URL: "https://gpu:8000/tokenize", Style: RemoteStyleVLLM, Model: "meta-llama/Llama-3-8B", Timeout: 5 * time.Second},
+		},
+		{
+			name:      "expanded header",
+			spec:      "http://localhost:8080/tokenize#header=Authorization:Bearer%20$REMOTE_TEST_TOKEN",
+			expandEnv: true,
+			want:      RemoteConfig{URL: "http://localhost:8080/tokenize", Style: RemoteStyleLlamaCpp, Timeout: 30 * time.Second, Headers: map[string]string{"Authorization": "Bearer secret"}},
+		},
+		{
+			name: "unexpanded header",
+			spec: "http://localhost:8080/tokenize#header=Authorization:Bearer%20$REMOTE_TEST_TOKEN",
+			want: RemoteConfig{URL: "http://localhost:8080/tokenize", Style: RemoteStyleLlamaCpp, Timeout: 30 * time.Second, Headers: map[string]string{"Authorization": "Bearer $REMOTE_TEST_TOKEN"}},
+		},
+		{name: "unsupported scheme", spec: "file:///tokenize", err: "must use http or https"},
+		{name: "bad timeout", spec: "http://localhost/tokenize#timeout=soon", err: "invalid remote timeout"},
+		{name: "bad header", spec: "http://localhost/tokenize#header=Authorization", err: "Name:Value"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRemoteSpec(tt.spec, tt.expandEnv)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("error = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.URL != tt.want.URL || got.Style != tt.want.Style || got.Model != tt.want.Model || got.Timeout != tt.want.Timeout {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+			if len(got.Headers) != len(tt.want.Headers) {
+				t.Errorf("headers = %v, want %v", got.Headers, tt.want.Headers)
+			}
+			for name, value := range tt.want.Headers {
+				if got.Headers[name] != value {
+					t.Errorf("header %s = %q, want %q", name, got.Headers[name], value)
+				}
+			}
+		})
+	}
+}
+
+func TestRemoteLlamaCpp(t *testing.T) {
+	tokenizer := newTestRemote(t, "#header=X-Api-Key:abc", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("X-Api-Key"); got != "abc" {
+			t.Errorf("X-Api-Key = %q, want abc", got)
+		}
+		switch r.URL.Path {
+		case "/tokenize":
+			var request remoteLlamaCppTokenizeRequest
+			if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+				t.Error(err)
+			}
+			if !request.WithPieces || request.Content != "hi é" {
+				t.Errorf("request = %+v", request)
+			}
+			// "é" is split into its two bytes, which are sent as byte arrays
+			_, _ = w.Write([]byte(`{"tokens":[{"id":1,"piece":"hi"},{"id":2,"piece":" "},{"id":3,"piece":[195]},{"id":4,"piece":[169]}]}`))
+		case "/detokenize":
+			writeJSON(t, w, map[string]string{"content": "hi é"})
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	result, err := tokenizer.Encode(context.Background(), "hi é")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkTokens(t, result, []Token{
+		{ID: 1, Text: "hi", Start: 0, End: 2},
+		{ID: 2, Text: " ", Start: 2, End: 3},
+		{ID: 3, Text: "\xc3", Start: 3, End: 4},
+		{ID: 4, Text: "\xa9", Start: 4, End: 5},
+	})
+
+	text, err := tokenizer.Decode(context.Background(), []int{1, 2, 3, 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != "hi é" {
+		t.Errorf("Decode = %q, want %q", text, "hi é")
+	}
+}
+
+func TestRemoteVLLM(t *testing.T) {
+	tokenizer := newTestRemote(t, "#style=vllm&model=test-model", func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/tokenize":
+			var request remoteVLLMTokenizeRequest
+			if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+				t.Error(err)
+			}
+			if request.Model != "test-model" || !request.ReturnTokenStrs {
+				t.Errorf("request = %+v", request)
+			}
+			// Byte-level BPE strings, with "Ġ" for a space and "Ã" for the first byte of "é"
+			writeJSON(t, w, remoteVLLMTokenizeResponse{Tokens: []int{10, 11, 12}, TokenStrs: []string{"hi", "ĠÃ", "©"}})
+		case "/detokenize":
+			var request remoteDetokenizeRequest
+			if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+				t.Error(err)
+			}
+			if request.Model != "test-model" {
+				t.Errorf("detokenize model = %q", request.Model)
+			}
+			writeJSON(t, w, map[string]string{"prompt": "hi é"})
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	result, err := tokenizer.Encode(context.Background(), "hi é")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkTokens(t, result, []Token{
+		{ID: 10, Text: "hi", Start: 0, End: 2},
+		{ID: 11, Text: " \xc3", Start: 2, End: 4},
+		{ID: 12, Text: "\xa9", Start: 4, End: 5},
+	})
+
+	text, err := tokenizer.Decode(context.Background(), []int{10, 11, 12})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != "hi é" {
+		t.Errorf("Decode = %q, want %q", text, "hi é")
+	}
+}
+
+func TestRemoteSpans(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  string
+		response any
+		want     []Token
+	}{
+		{
+			name: "leading space and special tokens",
+			response: map[string]any{"tokens": []map[string]any{
+				{"id": 1, "piece": "<s>"}, {"id": 2, "piece": " hi"}, {"id": 3, "piece": " there"}, {"id": 4, "piece": "</s>"},
+			}},
+			want: []Token{
+				{ID: 1, Text: "<s>", Start: 0, End: 0},
+				{ID: 2, Text: " hi", Start: 0, End: 2},
+				{ID: 3, Text: " there", Start: 2, End: 8},
+				{ID: 4, Text: "</s>", Start: 8, End: 8},
+			},
+		},
+		{
+			name:     "leading space piece",
+			options:  "#style=vllm&model=test-model",
+			response: remoteVLLMTokenizeResponse{Tokens: []int{5, 6, 7}, TokenStrs: []string{"▁", "hi", "▁there"}},
+			want: []Token{
+				{ID: 5, Text: " ", Start: 0, End: 0},
+				{ID: 6, Text: "hi", Start: 0, End: 2},
+				{ID: 7, Text: " there", Start: 2, End: 8},
+			},
+		},
+		{
+			name: "normalized text",
+			response: map[string]any{"tokens": []map[string]any{
+				{"id": 1, "piece": "hi"}, {"id": 2, "piece": " THERE"}, {"id": 3, "piece": " and more"},
+			}},
+			want: []Token{
+				{ID: 1, Text: "hi", Start: 0, End: 2},
+				{ID: 2, Text: " THERE", Start: 2, End: 2},
+				{ID: 3, Text: " and more", Start: 2, End: 2},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokenizer := newTestRemote(t, tt.options, func(w http.ResponseWriter, r *http.Request) {
+				writeJSON(t, w, tt.response)
+			})
+			result, err := tokenizer.Encode(context.Background(), "hi there")
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkTokens(t, result, tt.want)
+		})
+	}
+}
+
+func TestRemoteVLLMWithoutTokenStrs(t *testing.T) {
+	tokenizer := newTestRemote(t, "#style=vllm&model=test-model", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, remoteVLLMTokenizeResponse{Tokens: []int{10, 11}})
+	})
+
+	if _, err := tokenizer.Encode(context.Background(), "hi"); err == nil || !strings.Contains(err.Error(), "return_token_strs") {
+		t.Errorf("error = %v, want a missing token strings error", err)
+	}
+}
+
+func TestRemoteErrors(t *testing.T) {
+	t.Run("status", func(t *testing.T) {
+		tokenizer := newTestRemote(t, "", func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "model not loaded", http.StatusServiceUnavailable)
+		})
+		_, err := tokenizer.Encode(context.Background(), "hi")
+		if err == nil || !strings.Contains(err.Error(), "503") || !strings.Contains(err.Error(), "model not loaded") {
+			t.Errorf("error = %v, want the status and body", err)
+		}
+	})
+
+	t.Run("bad piece", func(t *testing.T) {
+		tokenizer := newTestRemote(t, "", func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"tokens":[{"id":1,"piece":{"text":"hi"}}]}`))
+		})
+		if _, err := tokenizer.Encode(context.Background(), "hi"); err == nil || !strings.Contains(err.Error(), "token 1") {
+			t.Errorf("error = %v, want a piece decoding error", err)
+		}
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		release := make(chan struct{})
+		tokenizer := newTestRemote(t, "#timeout=50ms", func(w http.ResponseWriter, r *http.Request) {
+			<-release
+		})
+		// Runs before the server is closed, which waits for the handler
+		t.Cleanup(func() { close(release) })
+
+		start := time.Now()
+		if _, err := tokenizer.Encode(context.Background(), "hi"); err == nil {
+			t.Error("expected a timeout error")
+		}
+		if elapsed := time.Since(start); elapsed > 2*time.Second {
+			t.Errorf("request took %v, want it to time out after 50ms", elapsed)
+		}
+	})
+}
+
+func TestRemoteCache(t *testing.T) {
+	var calls atomic.Int32
+	tokenizer := newTestRemote(t, "", func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		_, _ = w.Write([]byte(`{"tokens":[{"id":1,"piece":[226,130]},{"id":2,"piece":[172]},{"id":3,"piece":"!"}]}`))
+	})
+	c, err := cache.NewCache(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokenizer.cache = c
+
+	want := []Token{
+		{ID: 1, Text: "\xe2\x82", Start: 0, End: 2},
+		{ID: 2, Text: "\xac", Start: 2, End: 3},
+		{ID: 3, Text: "!", Start: 3, End: 4},
+	}
+	for i := range 2 {
+		result, err := tokenizer.Encode(context.Background(), "€!")
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkTokens(t, result, want)
+		if i == 1 && calls.Load() != 1 {
+			t.Errorf("server called %d times, want the second encode served from the cache", calls.Load())
+		}
+	}
+}
@@ -3,7 +3,10 @@ package tokenizers
 import (
 	"context"
 	"fmt"
+	"sync"
+	"unicode/utf8"
 
+	"github.com/dlclark/regexp2"
 	"github.com/pkoukk/tiktoken-go"
 )
 
@@ -11,6 +14,12 @@ import (
 type TikTokenizer struct {
 	encoding string
 	encoder  *tiktoken.Tiktoken
+
+	// The ranks and pattern of the encoding, loaded on first use
+	specOnce  sync.Once
+	spec      *tiktoken.Encoding
+	specErr   error
+	vocabSize int
 }
 
 // NewTikTokenizer creates a new tiktoken-based tokenizer
@@ -27,6 +36,20 @@ func NewTikTokenizer(encoding string) (*TikTokenizer, error) {
 	}, nil
 }
 
+// loadSpec loads the ranks, pattern and special tokens of the encoding, which
+// only the vocabulary, merge traces and stages need
+func (t *TikTokenizer) loadSpec() (*tiktoken.Encoding, error) {
+	t.specOnce.Do(func() {
+		t.spec, t.specErr = loadTiktokenEncoding(t.encoding)
+		if t.specErr != nil {
+			t.specErr = fmt.Errorf("failed to load tiktoken encoding %s: %w", t.encoding, t.specErr)
+			return
+		}
+		t.vocabSize = tiktokenVocabSize(t.spec)
+	})
+	return t.spec, t.specErr
+}
+
 // Name returns the name of this tokenizer
 func (t *TikTokenizer) Name() string {
 	return fmt.Sprintf("OpenAI (%s)", t.encoding)
@@ -78,3 +101,108 @@ func (t *TikTokenizer) SupportsTokenIDs() bool {
 func (t *TikTokenizer) SupportsDecoding() bool {
 	return true
 }
+
+// Decode converts token IDs back into text
+func (t *TikTokenizer) Decode(ctx context.Context, ids []int) (string, error) {
+	return t.encoder.Decode(ids), nil
+}
+
+// VocabSize returns the number of token IDs, including special tokens, or 0
+// if the encoding's rank file can't be loaded
+func (t *TikTokenizer) VocabSize() int {
+	if _, err := t.loadSpec(); err != nil {
+		return 0
+	}
+	return t.vocabSize
+}
+
+// TokenBytes returns the raw bytes of a token ID. IDs the encoding doesn't
+// use decode to nothing.
+func (t *TikTokenizer) TokenBytes(id int) ([]byte, bool) {
+	if id < 0 {
+		return nil, false
+	}
+	decoded := t.encoder.Decode([]int{id})
+	return []byte(decoded), decoded != ""
+}
+
+// TraceMerges splits text with the encoding's pre-tokenizer pattern and
+// replays byte pair merging on every chunk, starting from single bytes
+func (t *TikTokenizer) TraceMerges(ctx context.Context, text string) ([]MergeTrace, error) {
+	chunks, err := t.chunks(text)
+	if err != nil {
+		return nil, err
+	}
+
+	spec, err := t.loadSpec()
+	if err != nil {
+		return nil, err
+	}
+	ranks := spec.MergeableRanks
+	traces := make([]MergeTrace, 0, len(chunks))
+	for _, chunk := range chunks {
+		symbols := make([]bpeSymbol, len(chunk.Text))
+		for i := 0; i < len(chunk.Text); i++ {
+			symbols[i] = bpeSymbol{text: chunk.Text[i : i+1], id: ranks[chunk.Text[i:i+1]]}
+		}
+
+		// In tiktoken the rank of a merge is the ID of the merged token
+		traces = append(traces, traceBPE(chunk, symbols, func(left, right bpeSymbol) (int, int, bool) {
+			rank, ok := ranks[left.text+right.text]
+			return rank, rank, ok
+		}))
+	}
+
+	return traces, nil
+}
+
+// chunks splits text with the encoding's pre-tokenizer pattern
+func (t *TikTokenizer) chunks(text string) ([]Chunk, error) {
+	spec, err := t.loadSpec()
+	if err != nil {
+		return nil, err
+	}
+
+	pattern, err := regexp2.Compile(spec.PatStr, regexp2.None)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile pattern of %s: %w", t.encoding, err)
+	}
+
+	// regexp2 reports rune indices, so map them back to byte offsets
+	offsets := make([]int, 0, len(text)+1)
+	for offset := 0; offset < len(text); {
+		offsets = append(offsets, offset)
+		_, size := utf8.DecodeRuneInString(text[offset:])
+		offset += size
+	}
+	offsets = append(offsets, len(text))
+
+	var chunks []Chunk
+	match, err := pattern.FindStringMatch(text)
+	for match != nil && err == nil {
+		start, end := offsets[match.Index], offsets[match.Index+match.Length]
+		chunks = append(chunks, Chunk{Text: text[start:end], Start: start, End: end})
+		match, err = pattern.FindNextMatch(match)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to split text: %w", err)
+	}
+
+	return chunks, nil
+}
+
+// Stages returns the pre-tokenized chunks and tokens of text. tiktoken has
+// no normalizer, so the normalized text is the input.
+func (t *TikTokenizer) Stages(ctx context.Context, text string) (*Stages, error) {
+	chunks, err := t.chunks(text)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := t.Encode(ctx, text)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Stages{Normalized: text, Chunks: chunks, Result: result}, nil
+}
@@ -0,0 +1,101 @@
+package tokenizers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkoukk/tiktoken-go"
+)
+
+// tiktokenEncodingURLs are the BPE rank files of each encoding
+var tiktokenEncodingURLs = map[string]string{
+	tiktoken.MODEL_O200K_BASE:  "https://openaipublic.blob.core.windows.net/encodings/o200k_base.tiktoken",
+	tiktoken.MODEL_CL100K_BASE: "https://openaipublic.blob.core.windows.net/encodings/cl100k_base.tiktoken",
+	tiktoken.MODEL_P50K_BASE:   "https://openaipublic.blob.core.windows.net/encodings/p50k_base.tiktoken",
+	tiktoken.MODEL_P50K_EDIT:   "https://openaipublic.blob.core.windows.net/encodings/p50k_base.tiktoken",
+	tiktoken.MODEL_R50K_BASE:   "https://openaipublic.blob.core.windows.net/encodings/r50k_base.tiktoken",
+}
+
+// gpt2Pattern is the pre-tokenizer regex shared by the GPT-2 era encodings
+const gpt2Pattern = `'s|'t|'re|'ve|'m|'ll|'d| ?\p{L}+| ?\p{N}+| ?[^\s\p{L}\p{N}]+|\s+(?!\S)|\s+`
+
+// loadTiktokenEncoding loads the ranks, pattern and special tokens of an
+// encoding. tiktoken-go keeps these unexported, so the definitions are mirrored
+// here for the vocabulary, merge traces and stages; encoding and decoding go
+// through tiktoken.GetEncoding.
+func loadTiktokenEncoding(name string) (*tiktoken.Encoding, error) {
+	url, ok := tiktokenEncodingURLs[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown encoding: %s", name)
+	}
+
+	ranks, err := tiktoken.NewDefaultBpeLoader().LoadTiktokenBpe(url)
+	if err != nil {
+		return nil, err
+	}
+
+	encoding := &tiktoken.Encoding{
+		Name:           name,
+		MergeableRanks: ranks,
+	}
+
+	switch name {
+	case tiktoken.MODEL_O200K_BASE:
+		encoding.PatStr = strings.Join([]string{
+			`[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]*[\p{Ll}\p{Lm}\p{Lo}\p{M}]+(?i:'s|'t|'re|'ve|'m|'ll|'d)?`,
+			`[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]+[\p{Ll}\p{Lm}\p{Lo}\p{M}]*(?i:'s|'t|'re|'ve|'m|'ll|'d)?`,
+			`\p{N}{1,3}`,
+			` ?[^\s\p{L}\p{N}]+[\r\n/]*`,
+			`\s*[\r\n]+`,
+			`\s+(?!\S)`,
+			`\s+`,
+		}, "|")
+		encoding.SpecialTokens = map[string]int{
+			tiktoken.ENDOFTEXT:   199999,
+			tiktoken.ENDOFPROMPT: 200018,
+		}
+	case tiktoken.MODEL_CL100K_BASE:
+		encoding.PatStr = `(?i:'s|'t|'re|'ve|'m|'ll|'d)|[^\r\n\p{L}\p{N}]?\p{L}+|\p{N}{1,3}| ?[^\s\p{L}\p{N}]+[\r\n]*|\s*[\r\n]+|\s+(?!\S)|\s+`
+		encoding.SpecialTokens = map[string]int{
+			tiktoken.ENDOFTEXT:   100257,
+			tiktoken.FIM_PREFIX:  100258,
+			tiktoken.FIM_MIDDLE:  100259,
+			tiktoken.FIM_SUFFIX:  100260,
+			tiktoken.ENDOFPROMPT: 100276,
+		}
+	case tiktoken.MODEL_P50K_EDIT:
+		encoding.PatStr = gpt2Pattern
+		encoding.SpecialTokens = map[string]int{
+			tiktoken.ENDOFTEXT:  50256,
+			tiktoken.FIM_PREFIX: 50281,
+			tiktoken.FIM_MIDDLE: 50282,
+			tiktoken.FIM_SUFFIX: 50283,
+		}
+	case tiktoken.MODEL_P50K_BASE:
+		encoding.PatStr = gpt2Pattern
+		encoding.SpecialTokens = map[string]int{tiktoken.ENDOFTEXT: 50256}
+		encoding.ExplicitNVocab = 50281
+	case tiktoken.MODEL_R50K_BASE:
+		encoding.PatStr = gpt2Pattern
+		encoding.SpecialTokens = map[string]int{tiktoken.ENDOFTEXT: 50256}
+		encoding.ExplicitNVocab = 50257
+	}
+
+	return encoding, nil
+}
+
+// tiktokenVocabSize returns the number of token IDs of an encoding, including special tokens
+func tiktokenVocabSize(encoding *tiktoken.Encoding) int {
+	if encoding.ExplicitNVocab > 0 {
+		return encoding.ExplicitNVocab
+	}
+
+	size := 0
+	for _, id := range encoding.MergeableRanks {
+		size = max(size, id+1)
+	}
+	for _, id := range encoding.SpecialTokens {
+		size = max(size, id+1)
+	}
+	return size
+}
@@ -0,0 +1,109 @@
+package tokenizers
+
+import "context"
+
+// Chunk is a piece of text produced by a pre-tokenizer. BPE merges never
+// cross chunk boundaries.
+type Chunk struct {
+	Text  string // Text of the chunk, which may differ from the input if it was normalized
+	Start int    // Start position in original text (bytes)
+	End   int    // End position in original text (bytes)
+}
+
+// MergeStep is a single BPE merge of two adjacent parts
+type MergeStep struct {
+	Rank  int      // Rank of the merge; lower ranks are applied first
+	ID    int      // Token ID of the merged part
+	Index int      // Index of the merged part in Parts
+	Parts []string // Raw bytes of every part after the merge
+}
+
+// MergeTrace records how BPE turns one chunk into tokens
+type MergeTrace struct {
+	Chunk   Chunk
+	Initial []string    // Raw bytes of the parts before any merge
+	Steps   []MergeStep // Merges in the order they were applied
+	Tokens  []Token     // Final tokens of the chunk
+}
+
+// MergeTracer is implemented by BPE tokenizers that can explain their merges
+type MergeTracer interface {
+	// TraceMerges pre-tokenizes text and returns the merge trace of every chunk
+	TraceMerges(ctx context.Context, text string) ([]MergeTrace, error)
+}
+
+// bpeSymbol is a part of a chunk during a traced merge
+type bpeSymbol struct {
+	text string // Raw bytes of the part
+	id   int
+}
+
+// traceBPE repeatedly merges the adjacent pair with the lowest rank, taking
+// the leftmost pair on ties, until no pair can be merged. merge returns the
+// rank and token ID of a pair, and false if the pair is not a merge.
+func traceBPE(chunk Chunk, symbols []bpeSymbol, merge func(left, right bpeSymbol) (rank, id int, ok bool)) MergeTrace {
+	trace := MergeTrace{
+		Chunk:   chunk,
+		Initial: symbolTexts(symbols),
+	}
+
+	for len(symbols) > 1 {
+		best, bestRank, bestID := -1, 0, 0
+		for i := 0; i < len(symbols)-1; i++ {
+			rank, id, ok := merge(symbols[i], symbols[i+1])
+			if ok && (best < 0 || rank < bestRank) {
+				best, bestRank, bestID = i, rank, id
+			}
+		}
+		if best < 0 {
+			break
+		}
+
+		merged := bpeSymbol{text: symbols[best].text + symbols[best+1].text, id: bestID}
+		symbols = append(symbols[:best+1], symbols[best+2:]...)
+		symbols[best] = merged
+
+		trace.Steps = append(trace.Steps, MergeStep{
+			Rank:  bestRank,
+			ID:    bestID,
+			Index: best,
+			Parts: symbolTexts(symbols),
+		})
+	}
+
+	// Offsets can only be split up if the chunk was not changed by normalization
+	exact := len(chunk.Text) == chunk.End-chunk.Start
+	offset := chunk.Start
+	for _, symbol := range symbols {
+		token := Token{Text: symbol.text, ID: symbol.id, Start: chunk.Start, End: chunk.End}
+		if exact {
+			token.Start, token.End = offset, offset+len(symbol.text)
+		}
+		trace.Tokens = append(trace.Tokens, token)
+		offset += len(symbol.text)
+	}
+
+	return trace
+}
+
+// symbolTexts returns the raw bytes of each symbol
+func symbolTexts(symbols []bpeSymbol) []string {
+	texts := make([]string, len(symbols))
+	for i, symbol := range symbols {
+		texts[i] = symbol.text
+	}
+	return texts
+}
+
+// Stages is the text at each layer of the tokenization pipeline
+type Stages struct {
+	Normalized string  // Text after normalization; equal to the input if there is no normalizer
+	Chunks     []Chunk // Pre-tokenized chunks, decoded to raw bytes
+	Result     *TokenizationResult
+}
+
+// StageInspector is implemented by tokenizers that expose their normalizer and pre-tokenizer
+type StageInspector interface {
+	// Stages tokenizes text and returns the intermediate layers along with the final tokens
+	Stages(ctx context.Context, text string) (*Stages, error)
+}
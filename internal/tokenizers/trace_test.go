@@ -0,0 +1,81 @@
+package tokenizers
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// steps formats the merges of a trace as "rank@index:parts"
+func steps(trace MergeTrace) []string {
+	var got []string
+	for _, step := range trace.Steps {
+		got = append(got, fmt.Sprintf("%d@%d:%s", step.Rank, step.Index, strings.Join(step.Parts, "|")))
+	}
+	return got
+}
+
+func TestTraceBPE(t *testing.T) {
+	tests := []struct {
+		name   string
+		chunk  Chunk
+		ranks  map[string]int
+		steps  []string
+		tokens []Token
+	}{
+		{
+			// "bc" is never merged because "cd" has a lower rank
+			name:   "lowest rank first",
+			chunk:  Chunk{Text: "abcd", Start: 2, End: 6},
+			ranks:  map[string]int{"cd": 0, "ab": 1, "abcd": 2, "bc": 3},
+			steps:  []string{"0@2:a|b|cd", "1@0:ab|cd", "2@0:abcd"},
+			tokens: []Token{{Text: "abcd", ID: 2, Start: 2, End: 6}},
+		},
+		{
+			name:  "leftmost on ties",
+			chunk: Chunk{Text: "aaaaa", End: 5},
+			ranks: map[string]int{"aa": 0},
+			steps: []string{"0@0:aa|a|a|a", "0@1:aa|aa|a"},
+			tokens: []Token{
+				{Text: "aa", ID: 0, Start: 0, End: 2},
+				{Text: "aa", ID: 0, Start: 2, End: 4},
+				{Text: "a", ID: -1, Start: 4, End: 5},
+			},
+		},
+		{
+			name:   "no merges",
+			chunk:  Chunk{Text: "xy", Start: 1, End: 3},
+			ranks:  map[string]int{"ab": 0},
+			tokens: []Token{{Text: "x", ID: -1, Start: 1, End: 2}, {Text: "y", ID: -1, Start: 2, End: 3}},
+		},
+		{
+			// A normalized chunk can't be mapped back, so its tokens cover all of it
+			name:   "normalized",
+			chunk:  Chunk{Text: "ab", Start: 0, End: 3},
+			ranks:  map[string]int{"ab": 0},
+			steps:  []string{"0@0:ab"},
+			tokens: []Token{{Text: "ab", ID: 0, Start: 0, End: 3}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			symbols := make([]bpeSymbol, len(tt.chunk.Text))
+			for i := range symbols {
+				symbols[i] = bpeSymbol{text: tt.chunk.Text[i : i+1], id: -1}
+			}
+			trace := traceBPE(tt.chunk, symbols, func(left, right bpeSymbol) (int, int, bool) {
+				rank, ok := tt.ranks[left.text+right.text]
+				return rank, rank, ok
+			})
+
+			if want := strings.Split(tt.chunk.Text, ""); !slices.Equal(trace.Initial, want) {
+				t.Errorf("initial parts = %q, want %q", trace.Initial, want)
+			}
+			if got := steps(trace); !slices.Equal(got, tt.steps) {
+				t.Errorf("steps = %q, want %q", got, tt.steps)
+			}
+			checkTokens(t, &TokenizationResult{Tokens: trace.Tokens}, tt.tokens)
+		})
+	}
+}
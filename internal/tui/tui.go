@@ -0,0 +1,295 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spandigital/token-visualizer/internal/output"
+	"github.com/spandigital/token-visualizer/internal/tokenizers"
+)
+
+// debounce is how long to wait after the last keystroke before re-tokenizing
+const debounce = 150 * time.Millisecond
+
+// sidePanelWidth is the width of the token count panel
+const sidePanelWidth = 32
+
+// styles are the text styles of the UI in a theme
+type styles struct {
+	panel       lipgloss.Style
+	activePanel lipgloss.Style
+	title       lipgloss.Style
+	model       lipgloss.Style
+	count       lipgloss.Style
+	help        lipgloss.Style
+	error       lipgloss.Style
+}
+
+// newStyles builds the UI styles from the colors of a theme
+func newStyles(theme *output.Theme) styles {
+	panel := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(theme.MutedStyle().GetForeground()).
+		Padding(0, 1)
+
+	return styles{
+		panel:       panel,
+		activePanel: panel.BorderForeground(theme.AccentStyle().GetForeground()),
+		title:       theme.AccentStyle().Bold(true),
+		model:       theme.LabelStyle().Bold(true),
+		count:       theme.HighlightStyle().Bold(true),
+		help:        theme.MutedStyle().Italic(true),
+		error:       theme.ErrorStyle(),
+	}
+}
+
+// Model is a loaded tokenizer with the name it was configured with
+type Model struct {
+	Name      string
+	Tokenizer tokenizers.Tokenizer
+}
+
+// tickMsg fires after the debounce delay for a given edit
+type tickMsg struct {
+	seq int
+}
+
+// resultMsg carries the tokenization of the buffer at a given edit
+type resultMsg struct {
+	seq    int
+	result *tokenizers.TokenizationResult
+	counts []int
+	err    error
+}
+
+// model is the Bubble Tea model for the interactive UI
+type model struct {
+	models         []Model
+	theme          *output.Theme
+	styles         styles
+	active         int
+	editor         textarea.Model
+	showIDs        bool
+	showBoundaries bool
+	seq            int
+	result         *tokenizers.TokenizationResult
+	counts         []int
+	err            error
+	width          int
+	height         int
+}
+
+// Run starts the full-screen interactive UI with the given models, initial text and theme
+func Run(models []Model, text string, theme *output.Theme) error {
+	if len(models) == 0 {
+		return fmt.Errorf("at least one model is required")
+	}
+
+	_, err := tea.NewProgram(newModel(models, text, theme), tea.WithAltScreen()).Run()
+	return err
+}
+
+// newModel returns the UI state for the given models, with text in the editor
+func newModel(models []Model, text string, theme *output.Theme) model {
+	editor := textarea.New()
+	editor.Placeholder = "Type or paste text to tokenize..."
+	editor.ShowLineNumbers = false
+	editor.CharLimit = 0
+	editor.SetValue(text)
+	editor.Focus()
+
+	return model{
+		models: models,
+		theme:  theme,
+		styles: newStyles(theme),
+		editor: editor,
+		counts: make([]int, len(models)),
+	}
+}
+
+// Init starts the cursor blinking and tokenizes the initial text
+func (m model) Init() tea.Cmd {
+	return tea.Batch(textarea.Blink, m.tokenize(m.seq))
+}
+
+// Update handles key presses, resizes and tokenization results
+func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		m.editor.SetWidth(m.mainWidth() - 4)
+		m.editor.SetHeight(max(3, (m.height-4)/2-2))
+		return m, nil
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c", "esc":
+			return m, tea.Quit
+		case "ctrl+n":
+			m.active = (m.active + 1) % len(m.models)
+			return m, m.retokenize()
+		case "ctrl+p":
+			m.active = (m.active + len(m.models) - 1) % len(m.models)
+			return m, m.retokenize()
+		case "tab":
+			// The editor ignores the tab key, and expands the tab it's given
+			// to spaces like it does for pasted text
+			m.editor.InsertString("\t")
+			return m, m.retokenize()
+		case "alt+i":
+			m.showIDs = !m.showIDs
+			return m, nil
+		case "alt+o":
+			m.showBoundaries = !m.showBoundaries
+			return m, nil
+		}
+
+		before := m.editor.Value()
+		var cmd tea.Cmd
+		m.editor, cmd = m.editor.Update(msg)
+		if m.editor.Value() != before {
+			return m, tea.Batch(cmd, m.retokenize())
+		}
+		return m, cmd
+
+	case tickMsg:
+		if msg.seq != m.seq {
+			return m, nil
+		}
+		return m, m.tokenize(msg.seq)
+
+	case resultMsg:
+		if msg.seq != m.seq {
+			return m, nil
+		}
+		m.err = msg.err
+		if msg.err == nil {
+			m.result = msg.result
+			m.counts = msg.counts
+		}
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.editor, cmd = m.editor.Update(msg)
+	return m, cmd
+}
+
+// retokenize schedules tokenization after the debounce delay, superseding earlier edits
+func (m *model) retokenize() tea.Cmd {
+	m.seq++
+	seq := m.seq
+	return tea.Tick(debounce, func(time.Time) tea.Msg {
+		return tickMsg{seq: seq}
+	})
+}
+
+// tokenize encodes the buffer with the active model and counts it with every model
+func (m model) tokenize(seq int) tea.Cmd {
+	text := m.editor.Value()
+	active := m.models[m.active]
+	models := m.models
+
+	return func() tea.Msg {
+		ctx := context.Background()
+
+		result, err := active.Tokenizer.Encode(ctx, text)
+		if err != nil {
+			return resultMsg{seq: seq, err: fmt.Errorf("tokenization failed for %s: %w", active.Name, err)}
+		}
+
+		counts := make([]int, len(models))
+		for i, model := range models {
+			count, err := model.Tokenizer.CountTokens(ctx, text)
+			if err != nil {
+				return resultMsg{seq: seq, err: fmt.Errorf("token counting failed for %s: %w", model.Name, err)}
+			}
+			counts[i] = count
+		}
+
+		return resultMsg{seq: seq, result: result, counts: counts}
+	}
+}
+
+// mainWidth is the width available to the editor and token panels
+func (m model) mainWidth() int {
+	return max(20, m.width-sidePanelWidth)
+}
+
+// View renders the editor, token view, count panel and key help
+func (m model) View() string {
+	if m.width == 0 {
+		return ""
+	}
+
+	mainWidth := m.mainWidth()
+	panelHeight := max(3, (m.height-4)/2)
+
+	editor := m.styles.activePanel.
+		Width(mainWidth - 2).
+		Render(m.styles.title.Render("Input") + "\n" + m.editor.View())
+
+	tokens := m.styles.panel.
+		Width(mainWidth - 2).
+		Height(panelHeight).
+		MaxHeight(panelHeight + 2).
+		Render(m.tokenView(mainWidth - 4))
+
+	main := lipgloss.JoinVertical(lipgloss.Left, editor, tokens)
+
+	side := m.styles.panel.
+		Width(sidePanelWidth - 4).
+		Height(lipgloss.Height(main) - 2).
+		Render(m.countView())
+
+	help := m.styles.help.Render("ctrl+n/ctrl+p: switch model • alt+i: toggle IDs • alt+o: toggle boundaries • esc: quit")
+
+	return lipgloss.JoinVertical(lipgloss.Left,
+		lipgloss.JoinHorizontal(lipgloss.Top, main, side),
+		help,
+	)
+}
+
+// tokenView renders the colored tokens of the active model, wrapped to width
+func (m model) tokenView(width int) string {
+	if m.err != nil {
+		return m.styles.error.Width(width).Render(m.err.Error())
+	}
+	if m.result == nil {
+		return m.styles.help.Render("Tokenizing...")
+	}
+
+	renderer := output.NewTerminalRenderer(m.showIDs, m.showBoundaries).WithTheme(m.theme)
+	rendered := strings.TrimRight(renderer.RenderSingle(m.result), "\n")
+
+	return lipgloss.NewStyle().Width(width).Render(rendered)
+}
+
+// countView renders the token count for every model, marking the active one
+func (m model) countView() string {
+	var content strings.Builder
+
+	content.WriteString(m.styles.title.Render("📊 Token Counts"))
+	content.WriteString("\n\n")
+
+	for i, model := range m.models {
+		marker := "  "
+		if i == m.active {
+			marker = "▶ "
+		}
+
+		content.WriteString(marker)
+		content.WriteString(m.styles.model.Render(model.Name))
+		content.WriteString("\n  ")
+		content.WriteString(m.styles.count.Render(fmt.Sprintf("%d tokens", m.counts[i])))
+		content.WriteString("\n")
+	}
+
+	return content.String()
+}
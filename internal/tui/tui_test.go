@@ -0,0 +1,49 @@
+package tui
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spandigital/token-visualizer/internal/output"
+)
+
+// press sends a key to m and returns the updated model
+func press(t *testing.T, m model, key tea.KeyMsg) model {
+	t.Helper()
+	updated, _ := m.Update(key)
+	return updated.(model)
+}
+
+func TestKeys(t *testing.T) {
+	models := []Model{{Name: "a"}, {Name: "b"}, {Name: "c"}}
+	theme, err := output.NewTheme(output.ThemeMonochrome)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := newModel(models, "x", theme)
+
+	steps := []struct {
+		key    tea.KeyMsg
+		active int
+	}{
+		{tea.KeyMsg{Type: tea.KeyCtrlN}, 1},
+		{tea.KeyMsg{Type: tea.KeyCtrlN}, 2},
+		{tea.KeyMsg{Type: tea.KeyCtrlN}, 0},
+		{tea.KeyMsg{Type: tea.KeyCtrlP}, 2},
+	}
+	for _, step := range steps {
+		m = press(t, m, step.key)
+		if m.active != step.active {
+			t.Errorf("after %s active model = %d, want %d", step.key, m.active, step.active)
+		}
+	}
+
+	// Tab edits the text instead of switching models
+	m = press(t, m, tea.KeyMsg{Type: tea.KeyTab})
+	if m.active != 2 {
+		t.Errorf("after tab active model = %d, want 2", m.active)
+	}
+	if got := m.editor.Value(); got == "x" {
+		t.Errorf("after tab editor = %q, want the tab inserted", got)
+	}
+}
@@ -0,0 +1,159 @@
+package vocab
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/spandigital/token-visualizer/internal/tokenizers"
+)
+
+// Entry is a single vocabulary entry
+type Entry struct {
+	ID    int
+	Bytes []byte
+}
+
+// Text returns the entry as a string, which may not be valid UTF-8
+func (e Entry) Text() string {
+	return string(e.Bytes)
+}
+
+// Escaped returns the entry as a quoted Go string literal, escaping
+// whitespace, control characters and invalid UTF-8
+func (e Entry) Escaped() string {
+	return strconv.Quote(string(e.Bytes))
+}
+
+// Hex returns the bytes of the entry as space-separated hex pairs
+func (e Entry) Hex() string {
+	parts := make([]string, len(e.Bytes))
+	for i, b := range e.Bytes {
+		parts[i] = fmt.Sprintf("%02x", b)
+	}
+	return strings.Join(parts, " ")
+}
+
+// Query selects vocabulary entries
+type Query struct {
+	From  int            // First ID to scan
+	To    int            // Last ID to scan, or -1 for the end of the vocabulary
+	Regex *regexp.Regexp // Only entries matching the regex, if set
+}
+
+// Scan calls fn for every entry matching the query, in ID order. Tokenizers
+// that implement VocabReader are read directly; others decode one ID at a time.
+func Scan(ctx context.Context, tokenizer tokenizers.Tokenizer, query Query, fn func(Entry) error) error {
+	if !tokenizer.SupportsTokenIDs() || !tokenizer.SupportsDecoding() {
+		return fmt.Errorf("%s does not expose token IDs", tokenizer.Name())
+	}
+
+	to := query.To
+	if to < 0 {
+		size := tokenizers.VocabSize(tokenizer)
+		if size == 0 {
+			return fmt.Errorf("vocabulary size of %s is unknown, give an ID range instead", tokenizer.Name())
+		}
+		to = size - 1
+	}
+
+	reader, direct := tokenizer.(tokenizers.VocabReader)
+
+	for id := max(query.From, 0); id <= to; id++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		var entry Entry
+		if direct {
+			b, ok := reader.TokenBytes(id)
+			if !ok {
+				continue
+			}
+			entry = Entry{ID: id, Bytes: b}
+		} else {
+			text, err := tokenizer.Decode(ctx, []int{id})
+			if err != nil {
+				return fmt.Errorf("failed to decode token %d: %w", id, err)
+			}
+			entry = Entry{ID: id, Bytes: []byte(text)}
+		}
+
+		if query.Regex != nil && !query.Regex.Match(entry.Bytes) {
+			continue
+		}
+
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Match is the result of looking up an exact string
+type Match struct {
+	Text    string
+	Entry   *Entry             // Vocabulary entry with exactly this text, if any
+	Encoded []tokenizers.Token // How the string is tokenized on its own
+}
+
+// Lookup checks whether text, and text with a leading space, are single
+// vocabulary entries, and shows how each of them is tokenized
+func Lookup(ctx context.Context, tokenizer tokenizers.Tokenizer, text string) ([]Match, error) {
+	variants := []string{strings.TrimPrefix(text, " ")}
+	variants = append(variants, " "+variants[0])
+
+	matches := make([]Match, len(variants))
+	for i, variant := range variants {
+		matches[i].Text = variant
+
+		var result *tokenizers.TokenizationResult
+		var err error
+		if special, ok := tokenizer.(tokenizers.SpecialTokenEncoder); ok {
+			result, err = special.EncodeWithSpecial(ctx, variant, false)
+		} else {
+			result, err = tokenizer.Encode(ctx, variant)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("tokenization failed for %q: %w", variant, err)
+		}
+		matches[i].Encoded = result.Tokens
+
+		// A single token is usually the entry, unless a normalizer changed the text
+		if len(result.Tokens) == 1 {
+			id := result.Tokens[0].ID
+			b := []byte(variant)
+			if reader, ok := tokenizer.(tokenizers.VocabReader); ok {
+				b, _ = reader.TokenBytes(id)
+			}
+			if string(b) == variant {
+				matches[i].Entry = &Entry{ID: id, Bytes: b}
+			}
+		}
+	}
+
+	// The encoder may not pick an entry that exists, so search the vocabulary as well
+	if matches[0].Entry == nil || matches[1].Entry == nil {
+		if tokenizers.VocabSize(tokenizer) == 0 {
+			return matches, nil
+		}
+
+		err := Scan(ctx, tokenizer, Query{To: -1}, func(entry Entry) error {
+			for i := range matches {
+				if matches[i].Entry == nil && string(entry.Bytes) == matches[i].Text {
+					e := entry
+					matches[i].Entry = &e
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return matches, nil
+}
@@ -0,0 +1,154 @@
+package vocab
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/spandigital/token-visualizer/internal/tokenizers"
+)
+
+// testVocab has byte-level pieces for the two bytes of "é" and an unused ID 7
+var testVocab = []string{"a", "b", " ", " a", "ab", "\xc3", "\xa9", "", "ba"}
+
+// vocabTokenizer encodes every byte as its single-byte entry, so longer
+// entries are only found by scanning the vocabulary
+type vocabTokenizer struct{}
+
+func (vocabTokenizer) Name() string { return "test" }
+
+func (vocabTokenizer) Encode(ctx context.Context, text string) (*tokenizers.TokenizationResult, error) {
+	result := &tokenizers.TokenizationResult{Text: text, Model: "test"}
+	for i := range len(text) {
+		id := slices.Index(testVocab, text[i:i+1])
+		if id < 0 {
+			return nil, fmt.Errorf("no entry for %q", text[i:i+1])
+		}
+		result.Tokens = append(result.Tokens, tokenizers.Token{Text: text[i : i+1], ID: id, Start: i, End: i + 1})
+	}
+	result.TotalCount = len(result.Tokens)
+	return result, nil
+}
+
+func (vocabTokenizer) CountTokens(ctx context.Context, text string) (int, error) {
+	return len(text), nil
+}
+
+func (vocabTokenizer) SupportsTokenIDs() bool { return true }
+
+func (vocabTokenizer) SupportsDecoding() bool { return true }
+
+func (vocabTokenizer) Decode(ctx context.Context, ids []int) (string, error) {
+	var b strings.Builder
+	for _, id := range ids {
+		if id < 0 || id >= len(testVocab) {
+			return "", fmt.Errorf("unknown token %d", id)
+		}
+		b.WriteString(testVocab[id])
+	}
+	return b.String(), nil
+}
+
+func (vocabTokenizer) VocabSize() int { return len(testVocab) }
+
+// readerTokenizer reads the vocabulary directly
+type readerTokenizer struct{ vocabTokenizer }
+
+func (readerTokenizer) TokenBytes(id int) ([]byte, bool) {
+	if id < 0 || id >= len(testVocab) || testVocab[id] == "" {
+		return nil, false
+	}
+	return []byte(testVocab[id]), true
+}
+
+// scan returns the IDs and texts of the entries matching query
+func scan(t *testing.T, tokenizer tokenizers.Tokenizer, query Query) ([]string, error) {
+	t.Helper()
+	var entries []string
+	err := Scan(context.Background(), tokenizer, query, func(entry Entry) error {
+		entries = append(entries, fmt.Sprintf("%d:%q", entry.ID, entry.Text()))
+		return nil
+	})
+	return entries, err
+}
+
+func TestScan(t *testing.T) {
+	tests := []struct {
+		name      string
+		tokenizer tokenizers.Tokenizer
+		query     Query
+		want      []string
+	}{
+		{"by ID", readerTokenizer{}, Query{From: 4, To: 4}, []string{`4:"ab"`}},
+		{"byte pieces", readerTokenizer{}, Query{From: 5, To: 6}, []string{`5:"\xc3"`, `6:"\xa9"`}},
+		{"unused ID", readerTokenizer{}, Query{From: 7, To: 7}, nil},
+		{"past the end", readerTokenizer{}, Query{From: 20, To: -1}, nil},
+		{"whole vocabulary", readerTokenizer{}, Query{To: -1}, []string{
+			`0:"a"`, `1:"b"`, `2:" "`, `3:" a"`, `4:"ab"`, `5:"\xc3"`, `6:"\xa9"`, `8:"ba"`,
+		}},
+		{"regex", readerTokenizer{}, Query{To: -1, Regex: regexp.MustCompile(`^ ?a`)}, []string{`0:"a"`, `3:" a"`, `4:"ab"`}},
+		{"decoded", vocabTokenizer{}, Query{From: 3, To: 6}, []string{`3:" a"`, `4:"ab"`, `5:"\xc3"`, `6:"\xa9"`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := scan(t, tt.tokenizer, tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("entries = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("decode error", func(t *testing.T) {
+		if _, err := scan(t, vocabTokenizer{}, Query{From: 8, To: 9}); err == nil || !strings.Contains(err.Error(), "token 9") {
+			t.Errorf("error = %v, want the failure to decode token 9", err)
+		}
+	})
+}
+
+func TestLookup(t *testing.T) {
+	tests := []struct {
+		name      string
+		tokenizer tokenizers.Tokenizer
+		text      string
+		want      []string // Entry ID of each variant, or "-" if it's missing, and its encoding
+	}{
+		// "a" is encoded as its entry, " a" is found by scanning
+		{"leading space", readerTokenizer{}, " a", []string{`0 [0]`, `3 [2 0]`}},
+		{"entry not picked by the encoder", readerTokenizer{}, "ab", []string{`4 [0 1]`, `- [2 0 1]`}},
+		{"decoded entries", vocabTokenizer{}, "ba", []string{`8 [1 0]`, `- [2 1 0]`}},
+		{"missing", readerTokenizer{}, "bb", []string{`- [1 1]`, `- [2 1 1]`}},
+		{"byte pieces", readerTokenizer{}, "\xc3", []string{`5 [5]`, `- [2 5]`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matches, err := Lookup(context.Background(), tt.tokenizer, tt.text)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, match := range matches {
+				entry := "-"
+				if match.Entry != nil {
+					entry = fmt.Sprint(match.Entry.ID)
+					if match.Entry.Text() != match.Text {
+						t.Errorf("entry %d has text %q, want %q", match.Entry.ID, match.Entry.Text(), match.Text)
+					}
+				}
+				ids := make([]int, len(match.Encoded))
+				for i, token := range match.Encoded {
+					ids[i] = token.ID
+				}
+				got = append(got, fmt.Sprintf("%s %v", entry, ids))
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("matches = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}